@@ -17,6 +17,9 @@ function Locations() {
     country: 'USA',
     phone: '',
     email: '',
+    time_zone: 'America/New_York',
+    allows_key_drop: false,
+    capacity: '',
   });
   const [error, setError] = useState('');
 
@@ -44,7 +47,10 @@ function Locations() {
     setError('');
 
     try {
-      await api.post('/api/locations', formData);
+      await api.post('/api/locations', {
+        ...formData,
+        capacity: formData.capacity === '' ? null : parseInt(formData.capacity, 10),
+      });
       setFormData({
         organization_id: '',
         name: '',
@@ -56,6 +62,9 @@ function Locations() {
         country: 'USA',
         phone: '',
         email: '',
+        time_zone: 'America/New_York',
+        allows_key_drop: false,
+        capacity: '',
       });
       setShowForm(false);
       fetchData();
@@ -223,6 +232,45 @@ function Locations() {
                           />
                         </div>
                       </div>
+                      <div className="row">
+                        <div className="col-md-6 mb-3">
+                          <label className="form-label">Time Zone</label>
+                          <input
+                            type="text"
+                            className="form-control"
+                            value={formData.time_zone}
+                            onChange={(e) => setFormData({ ...formData, time_zone: e.target.value })}
+                            placeholder="e.g., America/Los_Angeles"
+                          />
+                        </div>
+                        <div className="col-md-6 mb-3 d-flex align-items-end">
+                          <div className="form-check">
+                            <input
+                              type="checkbox"
+                              className="form-check-input"
+                              id="allows_key_drop"
+                              checked={formData.allows_key_drop}
+                              onChange={(e) => setFormData({ ...formData, allows_key_drop: e.target.checked })}
+                            />
+                            <label className="form-check-label" htmlFor="allows_key_drop">
+                              Key drop for after-hours returns
+                            </label>
+                          </div>
+                        </div>
+                      </div>
+                      <div className="row">
+                        <div className="col-md-6 mb-3">
+                          <label className="form-label">Lot Capacity</label>
+                          <input
+                            type="number"
+                            min="0"
+                            className="form-control"
+                            value={formData.capacity}
+                            onChange={(e) => setFormData({ ...formData, capacity: e.target.value })}
+                            placeholder="Leave blank for no limit"
+                          />
+                        </div>
+                      </div>
                       <button type="submit" className="btn btn-primary">
                         Create Location
                       </button>
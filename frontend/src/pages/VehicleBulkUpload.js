@@ -1,4 +1,4 @@
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useRef } from 'react';
 import { useNavigate } from 'react-router-dom';
 import api from '../services/api';
 
@@ -9,14 +9,19 @@ function VehicleBulkUpload() {
   const [filteredLocations, setFilteredLocations] = useState([]);
   const [selectedOrganization, setSelectedOrganization] = useState('');
   const [selectedLocation, setSelectedLocation] = useState('');
+  const [profiles, setProfiles] = useState([]);
+  const [selectedProfile, setSelectedProfile] = useState('');
   const [file, setFile] = useState(null);
   const [uploading, setUploading] = useState(false);
   const [result, setResult] = useState(null);
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(true);
+  const [job, setJob] = useState(null);
+  const pollRef = useRef(null);
 
   useEffect(() => {
     fetchData();
+    return () => clearTimeout(pollRef.current);
   }, []);
 
   useEffect(() => {
@@ -32,6 +37,17 @@ function VehicleBulkUpload() {
     }
   }, [selectedOrganization, locations]);
 
+  useEffect(() => {
+    setSelectedProfile('');
+    setProfiles([]);
+    if (selectedOrganization) {
+      api
+        .get('/api/import-profiles', { params: { organization_id: selectedOrganization } })
+        .then((response) => setProfiles(response.data || []))
+        .catch(() => setProfiles([]));
+    }
+  }, [selectedOrganization]);
+
   const fetchData = async () => {
     try {
       const [orgsResponse, locsResponse] = await Promise.all([
@@ -49,8 +65,8 @@ function VehicleBulkUpload() {
 
   const handleFileChange = (e) => {
     const selectedFile = e.target.files[0];
-    if (selectedFile && selectedFile.type !== 'text/csv') {
-      setError('Please select a CSV file');
+    if (selectedFile && !/\.(csv|xlsx|jsonl|ndjson)$/i.test(selectedFile.name)) {
+      setError('Please select a CSV, Excel (.xlsx) or JSON Lines file');
       setFile(null);
       return;
     }
@@ -62,9 +78,10 @@ function VehicleBulkUpload() {
     e.preventDefault();
     setError('');
     setResult(null);
+    setJob(null);
 
     if (!file || !selectedOrganization || !selectedLocation) {
-      setError('Please select organization, location, and CSV file');
+      setError('Please select organization, location, and file');
       return;
     }
 
@@ -75,14 +92,17 @@ function VehicleBulkUpload() {
       formData.append('file', file);
       formData.append('organization_id', selectedOrganization);
       formData.append('location_id', selectedLocation);
+      if (selectedProfile) {
+        formData.append('profile_id', selectedProfile);
+      }
 
-      const response = await api.post('/api/vehicles/bulk-upload', formData, {
+      const response = await api.post('/api/import-jobs', formData, {
         headers: {
           'Content-Type': 'multipart/form-data',
         },
       });
 
-      setResult(response.data);
+      setJob(response.data);
       setFile(null);
 
       // Reset file input
@@ -91,17 +111,59 @@ function VehicleBulkUpload() {
         fileInput.value = '';
       }
 
+      pollJob(response.data.id);
+    } catch (err) {
+      setError(err.response?.data || 'Failed to upload vehicles');
+      setUploading(false);
+    }
+  };
+
+  const isFinished = (status) =>
+    status === 'completed' || status === 'failed' || status === 'cancelled';
+
+  // Poll the import job until it finishes
+  const pollJob = async (id) => {
+    try {
+      const response = await api.get(`/api/import-jobs/${id}`);
+      const current = response.data;
+      setJob(current);
+
+      if (!isFinished(current.status)) {
+        pollRef.current = setTimeout(() => pollJob(id), 2000);
+        return;
+      }
+
+      setUploading(false);
+      setResult({
+        status: current.status,
+        total: current.processed_rows,
+        success: current.success,
+        failed: current.failed,
+        error: current.error,
+        errors: (current.report || []).map((e) =>
+          e.column ? `Row ${e.row}, ${e.column}: ${e.message}` : `Row ${e.row}: ${e.message}`
+        ),
+      });
+
       // If all successful, redirect after a delay
-      if (response.data.failed === 0) {
+      if (current.status === 'completed' && current.failed === 0) {
         setTimeout(() => navigate('/vehicles'), 2000);
       }
     } catch (err) {
-      setError(err.response?.data || 'Failed to upload vehicles');
-    } finally {
+      setError('Failed to fetch import progress');
       setUploading(false);
     }
   };
 
+  const handleCancel = async () => {
+    if (!job) return;
+    try {
+      await api.post(`/api/import-jobs/${job.id}/cancel`);
+    } catch (err) {
+      setError(err.response?.data || 'Failed to cancel import');
+    }
+  };
+
   const downloadTemplate = () => {
     const headers = [
       'vin',
@@ -201,9 +263,46 @@ function VehicleBulkUpload() {
                 </div>
               )}
 
+              {uploading && job && (
+                <div className="alert alert-info" role="alert">
+                  <div className="d-flex justify-content-between align-items-center mb-2">
+                    <span>
+                      Importing {job.filename} ({job.status}) - {job.processed_rows} rows processed
+                    </span>
+                    <button
+                      className="btn btn-sm btn-outline-secondary"
+                      onClick={handleCancel}
+                      disabled={job.cancel_requested}
+                    >
+                      {job.cancel_requested ? 'Cancelling...' : 'Cancel'}
+                    </button>
+                  </div>
+                  <div className="progress">
+                    <div
+                      className="progress-bar"
+                      role="progressbar"
+                      style={{ width: `${job.progress}%` }}
+                      aria-valuenow={job.progress}
+                      aria-valuemin="0"
+                      aria-valuemax="100"
+                    >
+                      {job.progress}%
+                    </div>
+                  </div>
+                </div>
+              )}
+
               {result && (
-                <div className={`alert ${result.failed === 0 ? 'alert-success' : 'alert-warning'}`} role="alert">
-                  <h5 className="alert-heading">Upload Complete</h5>
+                <div
+                  className={`alert ${
+                    result.status === 'completed' && result.failed === 0 ? 'alert-success' : 'alert-warning'
+                  }`}
+                  role="alert"
+                >
+                  <h5 className="alert-heading">
+                    {result.status === 'completed' ? 'Upload Complete' : `Upload ${result.status}`}
+                  </h5>
+                  {result.error && <p>{result.error}</p>}
                   <p>
                     <strong>Total:</strong> {result.total} |{' '}
                     <strong>Success:</strong> {result.success} |{' '}
@@ -219,7 +318,7 @@ function VehicleBulkUpload() {
                       </ul>
                     </div>
                   )}
-                  {result.failed === 0 && (
+                  {result.status === 'completed' && result.failed === 0 && (
                     <p className="mb-0 mt-2">Redirecting to vehicles page...</p>
                   )}
                 </div>
@@ -286,20 +385,40 @@ function VehicleBulkUpload() {
                       </select>
                     </div>
 
+                    <div className="mb-3">
+                      <label className="form-label">Import Profile</label>
+                      <select
+                        className="form-select"
+                        value={selectedProfile}
+                        onChange={(e) => setSelectedProfile(e.target.value)}
+                        disabled={!selectedOrganization}
+                      >
+                        <option value="">Standard column names</option>
+                        {profiles.map((profile) => (
+                          <option key={profile.id} value={profile.id}>
+                            {profile.name}
+                          </option>
+                        ))}
+                      </select>
+                      <div className="form-text">
+                        Profiles map a dealer feed's column names to vehicle fields
+                      </div>
+                    </div>
+
                     <div className="mb-4">
                       <label htmlFor="csvFile" className="form-label">
-                        CSV File *
+                        File *
                       </label>
                       <input
                         type="file"
                         className="form-control"
                         id="csvFile"
-                        accept=".csv"
+                        accept=".csv,.xlsx,.jsonl,.ndjson"
                         onChange={handleFileChange}
                         required
                       />
                       <div className="form-text">
-                        Upload a CSV file containing vehicle data
+                        Upload a CSV, Excel (.xlsx, first sheet) or JSON Lines file containing vehicle data
                       </div>
                     </div>
 
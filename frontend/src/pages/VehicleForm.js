@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import api from '../services/api';
+import { moneyAmount } from '../services/money';
 
 function VehicleForm() {
   const { id } = useParams();
@@ -8,6 +9,7 @@ function VehicleForm() {
   const isEditMode = !!id;
 
   const [locations, setLocations] = useState([]);
+  const [vehicleClasses, setVehicleClasses] = useState([]);
   const [loading, setLoading] = useState(isEditMode);
   const [error, setError] = useState('');
   const [formData, setFormData] = useState({
@@ -31,6 +33,8 @@ function VehicleForm() {
     engine: '',
     mpg_city: 0,
     mpg_highway: 0,
+    fuel_capacity: 0,
+    vehicle_class_id: null,
     seats: 5,
     doors: 4,
     stock_number: '',
@@ -38,12 +42,17 @@ function VehicleForm() {
     daily_rate: 0,
     weekly_rate: 0,
     monthly_rate: 0,
+    has_warranty: false,
+    warranty_expiration_date: '',
+    warranty_type: '',
+    warranty_details: '',
     features: '',
     images: ''
   });
 
   useEffect(() => {
     fetchLocations();
+    fetchVehicleClasses();
     if (isEditMode) {
       fetchVehicle();
     }
@@ -58,6 +67,15 @@ function VehicleForm() {
     }
   };
 
+  const fetchVehicleClasses = async () => {
+    try {
+      const response = await api.get('/api/vehicle-classes');
+      setVehicleClasses(response.data || []);
+    } catch (err) {
+      setError('Failed to fetch vehicle classes');
+    }
+  };
+
   const fetchVehicle = async () => {
     try {
       const response = await api.get(`/api/vehicles/${id}`);
@@ -83,13 +101,21 @@ function VehicleForm() {
         engine: vehicle.engine || '',
         mpg_city: vehicle.mpg_city || 0,
         mpg_highway: vehicle.mpg_highway || 0,
+        fuel_capacity: vehicle.fuel_capacity || 0,
+        vehicle_class_id: vehicle.vehicle_class_id || null,
         seats: vehicle.seats || 5,
         doors: vehicle.doors || 4,
         stock_number: vehicle.stock_number || '',
         description: vehicle.description || '',
-        daily_rate: vehicle.daily_rate || 0,
-        weekly_rate: vehicle.weekly_rate || 0,
-        monthly_rate: vehicle.monthly_rate || 0,
+        daily_rate: moneyAmount(vehicle.daily_rate),
+        weekly_rate: moneyAmount(vehicle.weekly_rate),
+        monthly_rate: moneyAmount(vehicle.monthly_rate),
+        has_warranty: vehicle.has_warranty || false,
+        warranty_expiration_date: vehicle.warranty_expiration_date
+          ? vehicle.warranty_expiration_date.substring(0, 10)
+          : '',
+        warranty_type: vehicle.warranty_type || '',
+        warranty_details: vehicle.warranty_details || '',
         features: vehicle.features ? vehicle.features.join('\n') : '',
         images: vehicle.images ? vehicle.images.join('\n') : ''
       });
@@ -119,11 +145,16 @@ function VehicleForm() {
       mileage: parseInt(formData.mileage),
       mpg_city: parseInt(formData.mpg_city),
       mpg_highway: parseInt(formData.mpg_highway),
+      fuel_capacity: parseFloat(formData.fuel_capacity),
+      vehicle_class_id: formData.vehicle_class_id || null,
       seats: parseInt(formData.seats),
       doors: parseInt(formData.doors),
       daily_rate: parseFloat(formData.daily_rate),
       weekly_rate: parseFloat(formData.weekly_rate),
       monthly_rate: parseFloat(formData.monthly_rate),
+      warranty_expiration_date: formData.warranty_expiration_date
+        ? `${formData.warranty_expiration_date}T00:00:00Z`
+        : null,
       features: formData.features
         ? formData.features.split('\n').map(f => f.trim()).filter(f => f)
         : [],
@@ -186,6 +217,7 @@ function VehicleForm() {
                           value={formData.location_id}
                           onChange={handleChange}
                           required
+                          disabled={isEditMode}
                         >
                           <option value="">Select a location</option>
                           {locations.map((loc) => (
@@ -194,6 +226,32 @@ function VehicleForm() {
                             </option>
                           ))}
                         </select>
+                        {isEditMode && (
+                          <small className="text-muted">
+                            Move the vehicle with a transfer to change its location
+                          </small>
+                        )}
+                      </div>
+                      <div className="col-md-6 mb-3">
+                        <label className="form-label">Vehicle Class</label>
+                        <select
+                          className="form-select"
+                          name="vehicle_class_id"
+                          value={formData.vehicle_class_id || ''}
+                          onChange={handleChange}
+                        >
+                          <option value="">No class</option>
+                          {vehicleClasses
+                            .filter((vc) => {
+                              const location = locations.find((loc) => loc.id === formData.location_id);
+                              return !location || vc.organization_id === location.organization_id;
+                            })
+                            .map((vc) => (
+                              <option key={vc.id} value={vc.id}>
+                                {vc.code} - {vc.description}
+                              </option>
+                            ))}
+                        </select>
                       </div>
                       <div className="col-md-6 mb-3">
                         <label className="form-label">VIN *</label>
@@ -307,12 +365,15 @@ function VehicleForm() {
                           value={formData.status}
                           onChange={handleChange}
                           required
-                          disabled={!isEditMode}
+                          disabled={!isEditMode || formData.status === 'in_transit'}
                         >
                           <option value="available">Available</option>
                           <option value="rented">Rented</option>
                           <option value="maintenance">Maintenance</option>
                           <option value="inactive">Inactive</option>
+                          {formData.status === 'in_transit' && (
+                            <option value="in_transit">In Transit</option>
+                          )}
                         </select>
                       </div>
                       <div className="col-md-4 mb-3">
@@ -428,6 +489,19 @@ function VehicleForm() {
                           min="0"
                         />
                       </div>
+                      <div className="col-md-6 mb-3">
+                        <label className="form-label">Fuel Capacity</label>
+                        <input
+                          type="number"
+                          className="form-control"
+                          name="fuel_capacity"
+                          value={formData.fuel_capacity}
+                          onChange={handleChange}
+                          min="0"
+                          step="0.1"
+                          placeholder="Gallons, or kWh for electric"
+                        />
+                      </div>
                       <div className="col-md-6 mb-3">
                         <label className="form-label">Exterior Color</label>
                         <input
@@ -523,6 +597,62 @@ function VehicleForm() {
                   </div>
                 </div>
 
+                {/* Warranty */}
+                <div className="card mb-4">
+                  <div className="card-header">
+                    <h5 className="mb-0">Warranty</h5>
+                  </div>
+                  <div className="card-body">
+                    <div className="row">
+                      <div className="col-md-12 mb-3">
+                        <div className="form-check">
+                          <input
+                            className="form-check-input"
+                            type="checkbox"
+                            name="has_warranty"
+                            checked={formData.has_warranty}
+                            onChange={handleChange}
+                          />
+                          <label className="form-check-label">
+                            Under Warranty
+                          </label>
+                        </div>
+                      </div>
+                      <div className="col-md-6 mb-3">
+                        <label className="form-label">Warranty Type</label>
+                        <input
+                          type="text"
+                          className="form-control"
+                          name="warranty_type"
+                          value={formData.warranty_type}
+                          onChange={handleChange}
+                          placeholder="e.g., Factory, Extended"
+                        />
+                      </div>
+                      <div className="col-md-6 mb-3">
+                        <label className="form-label">Expiration Date</label>
+                        <input
+                          type="date"
+                          className="form-control"
+                          name="warranty_expiration_date"
+                          value={formData.warranty_expiration_date}
+                          onChange={handleChange}
+                        />
+                      </div>
+                      <div className="col-md-12 mb-3">
+                        <label className="form-label">Details</label>
+                        <textarea
+                          className="form-control"
+                          name="warranty_details"
+                          value={formData.warranty_details}
+                          onChange={handleChange}
+                          rows="2"
+                        />
+                      </div>
+                    </div>
+                  </div>
+                </div>
+
                 {/* Description & Features */}
                 <div className="card mb-4">
                   <div className="card-header">
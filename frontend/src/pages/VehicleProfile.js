@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import api from '../services/api';
+import { formatMoney } from '../services/money';
 
 function VehicleProfile() {
   const { id } = useParams();
@@ -9,11 +10,32 @@ function VehicleProfile() {
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState('');
   const [currentImageIndex, setCurrentImageIndex] = useState(0);
+  const [maintenance, setMaintenance] = useState([]);
 
   useEffect(() => {
     fetchVehicle();
+    fetchMaintenance();
   }, [id]);
 
+  const fetchMaintenance = async () => {
+    try {
+      const response = await api.get(`/api/vehicles/${id}/maintenance`);
+      setMaintenance(response.data || []);
+    } catch (err) {
+      setMaintenance([]);
+    }
+  };
+
+  const closeMaintenance = async (recordId) => {
+    try {
+      await api.post(`/api/vehicles/${id}/maintenance/${recordId}/close`);
+      fetchVehicle();
+      fetchMaintenance();
+    } catch (err) {
+      setError('Failed to close maintenance record');
+    }
+  };
+
   const fetchVehicle = async () => {
     try {
       const response = await api.get(`/api/vehicles/${id}`);
@@ -256,6 +278,52 @@ function VehicleProfile() {
                   </div>
                 </div>
               )}
+
+              {/* Service History */}
+              <div className="card mb-4">
+                <div className="card-body">
+                  <h4 className="card-title mb-3">Service History</h4>
+                  {maintenance.length === 0 ? (
+                    <p className="text-muted mb-0">No maintenance recorded</p>
+                  ) : (
+                    <table className="table table-sm mb-0">
+                      <thead>
+                        <tr>
+                          <th>Opened</th>
+                          <th>Type</th>
+                          <th>Vendor</th>
+                          <th>Odometer</th>
+                          <th>Cost</th>
+                          <th></th>
+                        </tr>
+                      </thead>
+                      <tbody>
+                        {maintenance.map((record) => (
+                          <tr key={record.id}>
+                            <td>{new Date(record.opened_at).toLocaleDateString()}</td>
+                            <td>{record.type.replace(/_/g, ' ')}</td>
+                            <td>{record.vendor}</td>
+                            <td>{record.odometer_at_service.toLocaleString()}</td>
+                            <td>${record.cost.toFixed(2)}</td>
+                            <td className="text-end">
+                              {record.closed_at ? (
+                                <span className="badge bg-secondary">Closed</span>
+                              ) : (
+                                <button
+                                  className="btn btn-sm btn-outline-success"
+                                  onClick={() => closeMaintenance(record.id)}
+                                >
+                                  Close
+                                </button>
+                              )}
+                            </td>
+                          </tr>
+                        ))}
+                      </tbody>
+                    </table>
+                  )}
+                </div>
+              </div>
             </div>
 
             {/* Right Column - Pricing & Key Info */}
@@ -264,24 +332,24 @@ function VehicleProfile() {
               <div className="card mb-4 border-primary">
                 <div className="card-body">
                   <h4 className="card-title mb-3">Rental Pricing</h4>
-                  {vehicle.daily_rate > 0 && (
+                  {vehicle.daily_rate?.amount > 0 && (
                     <div className="mb-3">
                       <h6 className="text-muted mb-1">Daily Rate</h6>
-                      <h3 className="text-primary mb-0">${vehicle.daily_rate.toFixed(2)}</h3>
+                      <h3 className="text-primary mb-0">{formatMoney(vehicle.daily_rate)}</h3>
                       <small className="text-muted">per day</small>
                     </div>
                   )}
-                  {vehicle.weekly_rate > 0 && (
+                  {vehicle.weekly_rate?.amount > 0 && (
                     <div className="mb-3">
                       <h6 className="text-muted mb-1">Weekly Rate</h6>
-                      <h4 className="mb-0">${vehicle.weekly_rate.toFixed(2)}</h4>
+                      <h4 className="mb-0">{formatMoney(vehicle.weekly_rate)}</h4>
                       <small className="text-muted">per week</small>
                     </div>
                   )}
-                  {vehicle.monthly_rate > 0 && (
+                  {vehicle.monthly_rate?.amount > 0 && (
                     <div className="mb-3">
                       <h6 className="text-muted mb-1">Monthly Rate</h6>
-                      <h4 className="mb-0">${vehicle.monthly_rate.toFixed(2)}</h4>
+                      <h4 className="mb-0">{formatMoney(vehicle.monthly_rate)}</h4>
                       <small className="text-muted">per month</small>
                     </div>
                   )}
@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import api from '../services/api';
+import { formatMoney } from '../services/money';
 
 function Vehicles() {
   const [vehicles, setVehicles] = useState([]);
@@ -36,6 +37,23 @@ function Vehicles() {
     }
   };
 
+  const handleExport = async (format) => {
+    try {
+      const response = await api.get('/api/vehicles/export', {
+        params: { format },
+        responseType: 'blob',
+      });
+      const url = window.URL.createObjectURL(response.data);
+      const a = document.createElement('a');
+      a.href = url;
+      a.download = `vehicles.${format}`;
+      a.click();
+      window.URL.revokeObjectURL(url);
+    } catch (err) {
+      setError('Failed to export vehicles');
+    }
+  };
+
   const getStatusBadge = (status) => {
     const badges = {
       available: 'bg-success',
@@ -78,6 +96,19 @@ function Vehicles() {
                     <i className="bi bi-upload me-2"></i>
                     Bulk Upload
                   </button>
+                  <button
+                    className="btn btn-outline-secondary"
+                    onClick={() => handleExport('csv')}
+                  >
+                    <i className="bi bi-download me-2"></i>
+                    Export CSV
+                  </button>
+                  <button
+                    className="btn btn-outline-secondary"
+                    onClick={() => handleExport('xlsx')}
+                  >
+                    Export Excel
+                  </button>
                 </div>
               </div>
 
@@ -117,7 +148,7 @@ function Vehicles() {
                               <td><code className="small">{vehicle.vin}</code></td>
                               <td>{vehicle.year}</td>
                               <td>{vehicle.mileage?.toLocaleString()} mi</td>
-                              <td>${vehicle.daily_rate?.toFixed(2)}/day</td>
+                              <td>{formatMoney(vehicle.daily_rate)}/day</td>
                               <td>
                                 <span className={`badge ${getStatusBadge(vehicle.status)}`}>
                                   {vehicle.status}
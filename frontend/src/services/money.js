@@ -0,0 +1,25 @@
+// Money comes from the API as { amount, currency } with the amount in minor units, such as cents
+
+// Decimal places of the currencies whose minor unit is not a hundredth
+const currencyDigits = {
+  BIF: 0, CLP: 0, DJF: 0, GNF: 0, ISK: 0, JPY: 0, KMF: 0, KRW: 0,
+  PYG: 0, RWF: 0, UGX: 0, VND: 0, VUV: 0, XAF: 0, XOF: 0, XPF: 0,
+  BHD: 3, IQD: 3, JOD: 3, KWD: 3, LYD: 3, OMR: 3, TND: 3,
+};
+
+// Converts money to a decimal amount in major units, such as dollars
+export function moneyAmount(money) {
+  if (!money) {
+    return 0;
+  }
+  const digits = currencyDigits[money.currency] ?? 2;
+  return money.amount / 10 ** digits;
+}
+
+// Formats money in its own currency
+export function formatMoney(money) {
+  return new Intl.NumberFormat(undefined, {
+    style: 'currency',
+    currency: money?.currency || 'USD',
+  }).format(moneyAmount(money));
+}
@@ -4,6 +4,7 @@ import (
 	"fleetpass/internal/models"
 	"fmt"
 	"log"
+	"math"
 	"os"
 
 	"gorm.io/driver/postgres"
@@ -84,6 +85,15 @@ func AutoMigrate(db *gorm.DB) error {
 		return fmt.Errorf("error enabling UUID extension: %w", err)
 	}
 
+	// Payments without a gateway reference used to store an empty one, which the unique index
+	// on gateway_reference would count as a duplicate
+	if db.Migrator().HasTable(&models.Payment{}) {
+		if err := db.Model(&models.Payment{}).Where("gateway_reference = ?", "").
+			Update("gateway_reference", nil).Error; err != nil {
+			return fmt.Errorf("error clearing empty gateway references: %w", err)
+		}
+	}
+
 	// Auto-migrate all models
 	err := db.AutoMigrate(
 		&models.Organization{},
@@ -92,15 +102,90 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.Role{},
 		&models.Permission{},
 		&models.Vehicle{},
+		&models.ImportJob{},
+		&models.ImportProfile{},
+		&models.MaintenanceRecord{},
+		&models.MaintenancePlan{},
+		&models.MaintenanceAlert{},
+		&models.OdometerReading{},
+		&models.Inspection{},
+		&models.WarrantyCoverage{},
+		&models.VehicleDocument{},
+		&models.VehicleTransfer{},
+		&models.LocationHoliday{},
+		&models.Rental{},
+		&models.ParkingSpace{},
+		&models.DriverProfile{},
+		&models.RentalDriver{},
+		&models.Payment{},
+		&models.PaymentEntry{},
+		&models.RentalCharge{},
+		&models.Invoice{},
+		&models.PricingRule{},
+		&models.VehicleClass{},
+		&models.ExchangeRate{},
 	)
 	if err != nil {
 		return fmt.Errorf("error running auto-migrations: %w", err)
 	}
+	if err := migrateMoneyColumns(db); err != nil {
+		return err
+	}
 
 	log.Println("Database migrations completed successfully")
 	return nil
 }
 
+// moneyColumn is a decimal money column that was replaced by an amount in minor units and a
+// currency, stored under prefix. currency is the SQL expression for the currency it was in.
+type moneyColumn struct {
+	table, column, prefix, currency string
+}
+
+var moneyColumns = []moneyColumn{
+	{"invoices", "subtotal", "subtotal_", "currency"},
+	{"invoices", "tax_total", "tax_total_", "currency"},
+	{"invoices", "total", "total_", "currency"},
+	{"invoices", "amount_paid", "amount_paid_", "currency"},
+	{"invoices", "balance_due", "balance_due_", "currency"},
+	{"payments", "amount", "amount_", "currency"},
+	{"payments", "captured_amount", "captured_", "currency"},
+	{"payments", "refunded_amount", "refunded_", "currency"},
+	{"payment_entries", "amount", "amount_", "(SELECT currency FROM payments WHERE payments.id = payment_entries.payment_id)"},
+	{"vehicles", "daily_rate", "daily_rate_", "(SELECT currency FROM locations WHERE locations.id = vehicles.location_id)"},
+	{"vehicles", "weekly_rate", "weekly_rate_", "(SELECT currency FROM locations WHERE locations.id = vehicles.location_id)"},
+	{"vehicles", "monthly_rate", "monthly_rate_", "(SELECT currency FROM locations WHERE locations.id = vehicles.location_id)"},
+}
+
+// migrateMoneyColumns copies amounts from the decimal columns money was kept in into their
+// minor-unit columns, then drops the old columns. Columns already migrated are skipped.
+func migrateMoneyColumns(db *gorm.DB) error {
+	for _, c := range moneyColumns {
+		if !db.Migrator().HasColumn(c.table, c.column) {
+			continue
+		}
+		currency := fmt.Sprintf("COALESCE(%s, '%s')", c.currency, models.DefaultCurrency)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var currencies []string
+			if err := tx.Raw(fmt.Sprintf("SELECT DISTINCT %s FROM %s", currency, c.table)).Scan(&currencies).Error; err != nil {
+				return err
+			}
+			for _, code := range currencies {
+				update := fmt.Sprintf("UPDATE %s SET %samount = ROUND(COALESCE(%s, 0) * ?), %scurrency = ? WHERE %s = ?",
+					c.table, c.prefix, c.column, c.prefix, currency)
+				if err := tx.Exec(update, math.Pow10(models.CurrencyDigits(code)), code, code).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(c.table, c.column)
+		})
+		if err != nil {
+			return fmt.Errorf("error migrating %s.%s to minor units: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
 // Init initializes the global database connection
 func Init() error {
 	config := LoadConfigFromEnv()
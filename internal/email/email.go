@@ -9,6 +9,9 @@ type Service interface {
 	SendVerificationEmail(to, token string) error
 	SendPasswordResetEmail(to, token string) error
 	SendWelcomeEmail(to, firstName string) error
+	SendMaintenanceDueEmail(to, locationName string, items []string) error
+	SendWarrantyExpiryEmail(to, locationName string, items []string) error
+	SendInvoiceEmail(to, organizationName, invoiceNumber string, pdf []byte) error
 }
 
 // MockService is a mock email service that logs to console
@@ -82,6 +85,57 @@ func (s *MockService) SendWelcomeEmail(to, firstName string) error {
 	return nil
 }
 
+// SendMaintenanceDueEmail logs a preventive maintenance alert to console
+func (s *MockService) SendMaintenanceDueEmail(to, locationName string, items []string) error {
+	log.Println("========================================")
+	log.Println("📧 EMAIL: Maintenance Due")
+	log.Println("========================================")
+	log.Printf("To: %s\n", to)
+	log.Printf("Subject: Maintenance due at %s\n", locationName)
+	log.Println("----------------------------------------")
+	log.Printf("The following vehicles at %s need service:\n", locationName)
+	log.Println()
+	for _, item := range items {
+		log.Printf("  - %s\n", item)
+	}
+	log.Println()
+	log.Println("========================================")
+	return nil
+}
+
+// SendWarrantyExpiryEmail logs a warranty expiry digest to console
+func (s *MockService) SendWarrantyExpiryEmail(to, locationName string, items []string) error {
+	log.Println("========================================")
+	log.Println("📧 EMAIL: Warranty Expiry")
+	log.Println("========================================")
+	log.Printf("To: %s\n", to)
+	log.Printf("Subject: Warranties expiring at %s\n", locationName)
+	log.Println("----------------------------------------")
+	log.Printf("Warranty coverage is ending soon for these vehicles at %s:\n", locationName)
+	log.Println()
+	for _, item := range items {
+		log.Printf("  - %s\n", item)
+	}
+	log.Println()
+	log.Println("========================================")
+	return nil
+}
+
+// SendInvoiceEmail logs an invoice email to console; the PDF would be attached
+func (s *MockService) SendInvoiceEmail(to, organizationName, invoiceNumber string, pdf []byte) error {
+	log.Println("========================================")
+	log.Println("📧 EMAIL: Invoice")
+	log.Println("========================================")
+	log.Printf("To: %s\n", to)
+	log.Printf("Subject: Invoice %s from %s\n", invoiceNumber, organizationName)
+	log.Println("----------------------------------------")
+	log.Printf("Please find invoice %s attached.\n", invoiceNumber)
+	log.Println()
+	log.Printf("Attachment: %s.pdf (%d bytes)\n", invoiceNumber, len(pdf))
+	log.Println("========================================")
+	return nil
+}
+
 // TODO: Implement real email service (SendGrid, AWS SES, etc.)
 // Example:
 //
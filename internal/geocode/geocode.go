@@ -0,0 +1,60 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"os"
+)
+
+// ErrNotFound is returned when an address cannot be placed
+var ErrNotFound = errors.New("address not found")
+
+// Address is the part of a location a geocoder needs
+type Address struct {
+	Line1   string
+	City    string
+	State   string
+	ZipCode string
+	Country string
+}
+
+// Coordinates is a point in decimal degrees
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Geocoder turns an address into coordinates
+type Geocoder interface {
+	Geocode(ctx context.Context, address Address) (Coordinates, error)
+}
+
+// earthRadiusMiles is the mean radius of the Earth
+const earthRadiusMiles = 3958.8
+
+// DistanceMiles returns the great-circle distance between two points
+func DistanceMiles(a, b Coordinates) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	lat1, lat2 := toRadians(a.Latitude), toRadians(b.Latitude)
+	dLat := lat2 - lat1
+	dLng := toRadians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Sqrt(h))
+}
+
+// GetGeocoder returns the configured geocoder. It is the offline ZIP centroid geocoder, using
+// the file named by GEOCODE_ZIP_CENTROIDS_FILE when set and the bundled dataset otherwise.
+func GetGeocoder() Geocoder {
+	// In production, check GEOCODER and return an online service here
+	if path := os.Getenv("GEOCODE_ZIP_CENTROIDS_FILE"); path != "" {
+		geocoder, err := LoadZipCentroidGeocoder(path)
+		if err == nil {
+			return geocoder
+		}
+		log.Printf("Warning: could not load ZIP centroids from %s: %v, using bundled dataset", path, err)
+	}
+	return NewZipCentroidGeocoder()
+}
@@ -0,0 +1,46 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseZipCentroids_Gazetteer(t *testing.T) {
+	gazetteer := "GEOID\tALAND\tAWATER\tALAND_SQMI\tAWATER_SQMI\tINTPTLAT\tINTPTLONG\n" +
+		"94105\t1233125\t0\t0.476\t0.000\t37.789782\t-122.394197\n" +
+		"10001\t1652788\t0\t0.638\t0.000\t40.750634\t-73.997176\n"
+
+	centroids, err := ParseZipCentroids(strings.NewReader(gazetteer))
+	if err != nil {
+		t.Fatalf("Failed to parse gazetteer: %v", err)
+	}
+	if got := centroids["10001"]; got.Latitude != 40.750634 || got.Longitude != -73.997176 {
+		t.Errorf("Unexpected centroid for 10001: %+v", got)
+	}
+
+	if _, err := ParseZipCentroids(strings.NewReader("code,x,y\n1,2,3\n")); err == nil {
+		t.Error("Expected a header without zip, latitude and longitude to be rejected")
+	}
+}
+
+func TestZipCentroidGeocoder(t *testing.T) {
+	geocoder := NewZipCentroidGeocoder()
+
+	sf, err := geocoder.Geocode(context.Background(), Address{ZipCode: "94105-1804"})
+	if err != nil {
+		t.Fatalf("Expected the bundled dataset to place 94105: %v", err)
+	}
+	la, _ := geocoder.Geocode(context.Background(), Address{ZipCode: "90012"})
+
+	// San Francisco to downtown Los Angeles is roughly 345 miles as the crow flies
+	if distance := DistanceMiles(sf, la); math.Abs(distance-345) > 10 {
+		t.Errorf("Expected about 345 miles, got %.1f", distance)
+	}
+
+	if _, err := geocoder.Geocode(context.Background(), Address{ZipCode: "00000"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package geocode
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// bundledZipCentroids holds approximate centroids for the ZIP codes of major US cities and
+// airports. Point GEOCODE_ZIP_CENTROIDS_FILE at a full dataset, such as the Census ZCTA
+// gazetteer file, for nationwide coverage.
+//
+//go:embed data/zip_centroids.csv
+var bundledZipCentroids []byte
+
+// ZipCentroidGeocoder places an address at the centroid of its ZIP code without any network access
+type ZipCentroidGeocoder struct {
+	centroids map[string]Coordinates
+}
+
+// NewZipCentroidGeocoder creates a geocoder from the bundled ZIP centroid dataset
+func NewZipCentroidGeocoder() *ZipCentroidGeocoder {
+	centroids, err := ParseZipCentroids(bytes.NewReader(bundledZipCentroids))
+	if err != nil {
+		panic(fmt.Sprintf("bundled ZIP centroids are invalid: %v", err))
+	}
+	return &ZipCentroidGeocoder{centroids: centroids}
+}
+
+// LoadZipCentroidGeocoder creates a geocoder from a ZIP centroid file on disk
+func LoadZipCentroidGeocoder(path string) (*ZipCentroidGeocoder, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	centroids, err := ParseZipCentroids(file)
+	if err != nil {
+		return nil, err
+	}
+	return &ZipCentroidGeocoder{centroids: centroids}, nil
+}
+
+// ParseZipCentroids reads a comma- or tab-separated file with a header row. The ZIP code is
+// taken from a zip, zip_code or GEOID column, and the point from latitude/longitude, lat/lng
+// or the gazetteer's INTPTLAT/INTPTLONG columns.
+func ParseZipCentroids(r io.Reader) (map[string]Coordinates, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte("\t")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = '\t'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header: %w", err)
+	}
+	zipCol, latCol, lngCol := -1, -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "zip", "zip_code", "zipcode", "geoid":
+			zipCol = i
+		case "latitude", "lat", "intptlat":
+			latCol = i
+		case "longitude", "lng", "lon", "intptlong":
+			lngCol = i
+		}
+	}
+	if zipCol < 0 || latCol < 0 || lngCol < 0 {
+		return nil, fmt.Errorf("header must name zip, latitude and longitude columns")
+	}
+
+	centroids := make(map[string]Coordinates)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(record) <= zipCol || len(record) <= latCol || len(record) <= lngCol {
+			return nil, fmt.Errorf("line %d: too few columns", line)
+		}
+
+		lat, err := strconv.ParseFloat(strings.TrimSpace(record[latCol]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude %q", line, record[latCol])
+		}
+		lng, err := strconv.ParseFloat(strings.TrimSpace(record[lngCol]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude %q", line, record[lngCol])
+		}
+		centroids[strings.TrimSpace(record[zipCol])] = Coordinates{Latitude: lat, Longitude: lng}
+	}
+	return centroids, nil
+}
+
+// Geocode returns the centroid of the address's five-digit ZIP code
+func (g *ZipCentroidGeocoder) Geocode(ctx context.Context, address Address) (Coordinates, error) {
+	zip := strings.TrimSpace(address.ZipCode)
+	// ZIP+4 codes share their five-digit centroid
+	if len(zip) > 5 && (zip[5] == '-' || zip[5] == ' ') {
+		zip = zip[:5]
+	}
+	coordinates, ok := g.centroids[zip]
+	if !ok {
+		return Coordinates{}, ErrNotFound
+	}
+	return coordinates, nil
+}
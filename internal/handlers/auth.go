@@ -336,6 +336,51 @@ func GetProfile(w http.ResponseWriter, r *http.Request) {
 
 // Helper functions
 
+// currentUserID returns the authenticated user's ID from the request's JWT claims, or nil
+func currentUserID(r *http.Request) *string {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil || claims == nil {
+		return nil
+	}
+	userID, ok := claims["user_id"].(string)
+	if !ok || userID == "" {
+		return nil
+	}
+	return &userID
+}
+
+// currentUserIsAdmin reports whether the authenticated user has the admin or super admin role
+func currentUserIsAdmin(r *http.Request) bool {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil || claims == nil {
+		return false
+	}
+	roles, _ := claims["roles"].([]interface{})
+	for _, role := range roles {
+		if role == models.RoleAdmin || role == models.RoleSuperAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// currentUserIsStaff reports whether the authenticated user works for an organization rather
+// than being a customer
+func currentUserIsStaff(r *http.Request) bool {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil || claims == nil {
+		return false
+	}
+	roles, _ := claims["roles"].([]interface{})
+	for _, role := range roles {
+		switch role {
+		case models.RoleStaff, models.RoleManager, models.RoleAdmin, models.RoleSuperAdmin:
+			return true
+		}
+	}
+	return false
+}
+
 func generateJWTToken(user *models.User) (string, error) {
 	// Get role names
 	roleNames := make([]string, len(user.Roles))
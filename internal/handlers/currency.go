@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// errNoExchangeRate is returned when no uploaded rate converts between two currencies on a date
+var errNoExchangeRate = errors.New("no exchange rate")
+
+// exchangeRateColumns are the columns an exchange rate file must have, in any order
+var exchangeRateColumns = []string{"base_currency", "quote_currency", "rate", "effective_on"}
+
+// validateCurrency upper-cases a currency code and checks it is three letters
+func validateCurrency(currency *string) error {
+	*currency = strings.ToUpper(strings.TrimSpace(*currency))
+	if len(*currency) != 3 {
+		return fmt.Errorf("currency must be a three-letter ISO 4217 code")
+	}
+	for _, c := range *currency {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("currency must be a three-letter ISO 4217 code")
+		}
+	}
+	return nil
+}
+
+// checkSameCurrency refuses to move a vehicle between locations that price in different
+// currencies, which would relabel its rates as the other currency
+func checkSameCurrency(from, to *models.Location) error {
+	if from.Currency != to.Currency {
+		return fmt.Errorf("%s prices in %s and %s in %s; vehicles cannot move between currencies",
+			from.Name, from.Currency, to.Name, to.Currency)
+	}
+	return nil
+}
+
+// locationCurrency is the currency a location prices in, which is also the currency of the
+// rates of the vehicles kept there
+func locationCurrency(locationID string) (string, error) {
+	var location models.Location
+	if err := database.DB.Select("currency").First(&location, "id = ?", locationID).Error; err != nil {
+		return "", err
+	}
+	return location.Currency, nil
+}
+
+// checkCurrencyChange lets a location's currency change only while nothing is priced in it:
+// no vehicles, no vehicles on their way to it and no open rentals from or to it. Otherwise it
+// writes a 409 and returns false.
+func checkCurrencyChange(w http.ResponseWriter, location *models.Location) bool {
+	var vehicles, transfers, rentals int64
+	err := database.DB.Model(&models.Vehicle{}).Where("location_id = ?", location.ID).Count(&vehicles).Error
+	if err == nil {
+		err = database.DB.Model(&models.VehicleTransfer{}).
+			Where("to_location_id = ? AND status IN ?", location.ID, []models.TransferStatus{models.TransferStatusRequested, models.TransferStatusInTransit}).
+			Count(&transfers).Error
+	}
+	if err == nil {
+		err = database.DB.Model(&models.Rental{}).
+			Where("(pickup_location_id = ? OR return_location_id = ?) AND status IN ?", location.ID, location.ID, models.RentalStatusesHoldingVehicle).
+			Count(&rentals).Error
+	}
+	if err != nil {
+		http.Error(w, "Failed to check location currency", http.StatusInternalServerError)
+		return false
+	}
+	if vehicles+transfers+rentals > 0 {
+		http.Error(w, fmt.Sprintf("Currency cannot change from %s while the location has vehicles, inbound transfers or open rentals",
+			location.Currency), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// findExchangeRate returns the organization's rate from one currency to another in effect on a
+// date: the latest one uploaded for the pair, or the inverse of the latest for the reverse pair
+func findExchangeRate(db *gorm.DB, organizationID, from, to string, on time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	day := on.Format("2006-01-02")
+
+	var rate models.ExchangeRate
+	err := db.Where("organization_id = ? AND base_currency = ? AND quote_currency = ? AND effective_on <= ?",
+		organizationID, from, to, day).Order("effective_on DESC").First(&rate).Error
+	if err == nil {
+		return rate.Rate, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	err = db.Where("organization_id = ? AND base_currency = ? AND quote_currency = ? AND effective_on <= ?",
+		organizationID, to, from, day).Order("effective_on DESC").First(&rate).Error
+	if err == nil && rate.Rate > 0 {
+		return 1 / rate.Rate, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	return 0, fmt.Errorf("%w from %s to %s on %s", errNoExchangeRate, from, to, day)
+}
+
+// requestedExchangeRate reads the currency query parameter and finds the organization's rate
+// into it from the given currency on a date. Without the parameter it returns the given
+// currency at a rate of 1. On an invalid currency or a missing rate it writes the error and
+// returns false.
+func requestedExchangeRate(w http.ResponseWriter, r *http.Request, organizationID, from string, on time.Time) (string, float64, bool) {
+	currency := r.URL.Query().Get("currency")
+	if currency == "" {
+		return from, 1, true
+	}
+	if err := validateCurrency(&currency); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", 0, false
+	}
+
+	rate, err := findExchangeRate(database.DB, organizationID, from, currency, on)
+	if errors.Is(err, errNoExchangeRate) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return "", 0, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch exchange rate", http.StatusInternalServerError)
+		return "", 0, false
+	}
+	return currency, rate, true
+}
+
+// parseExchangeRates reads a CSV of exchange rates with a header naming exchangeRateColumns.
+// Every problem is reported by line; the rates are only usable when there are none.
+func parseExchangeRates(reader io.Reader, organizationID string, uploadedBy *string) ([]models.ExchangeRate, []string) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, []string{"file is empty or not valid CSV"}
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var problems []string
+	for _, name := range exchangeRateColumns {
+		if _, ok := columns[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing column %s", name))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	rates := []models.ExchangeRate{}
+	seen := map[string]int{}
+	for line := 2; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		rate := models.ExchangeRate{
+			OrganizationID: organizationID,
+			BaseCurrency:   field("base_currency"),
+			QuoteCurrency:  field("quote_currency"),
+			UploadedBy:     uploadedBy,
+		}
+		if validateCurrency(&rate.BaseCurrency) != nil || validateCurrency(&rate.QuoteCurrency) != nil {
+			problems = append(problems, fmt.Sprintf("line %d: currencies must be three-letter ISO 4217 codes", line))
+			continue
+		}
+		if rate.BaseCurrency == rate.QuoteCurrency {
+			problems = append(problems, fmt.Sprintf("line %d: base and quote currencies are the same", line))
+			continue
+		}
+		if rate.Rate, err = strconv.ParseFloat(field("rate"), 64); err != nil || rate.Rate <= 0 {
+			problems = append(problems, fmt.Sprintf("line %d: rate must be a positive number", line))
+			continue
+		}
+		if rate.EffectiveOn, err = time.Parse("2006-01-02", field("effective_on")); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: effective_on must be in YYYY-MM-DD format", line))
+			continue
+		}
+
+		key := rate.BaseCurrency + rate.QuoteCurrency + field("effective_on")
+		if first, ok := seen[key]; ok {
+			problems = append(problems, fmt.Sprintf("line %d: repeats the %s/%s rate on line %d", line, rate.BaseCurrency, rate.QuoteCurrency, first))
+			continue
+		}
+		seen[key] = line
+		rates = append(rates, rate)
+	}
+	return rates, problems
+}
+
+// GetExchangeRates lists exchange rates, latest first, filtered by organization_id,
+// base_currency and quote_currency
+func GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	query := database.DB.Order("effective_on DESC, base_currency, quote_currency")
+	for _, column := range []string{"organization_id", "base_currency", "quote_currency"} {
+		if value := r.URL.Query().Get(column); value != "" {
+			query = query.Where(column+" = ?", strings.ToUpper(value))
+		}
+	}
+
+	var rates []models.ExchangeRate
+	if err := query.Find(&rates).Error; err != nil {
+		http.Error(w, "Failed to fetch exchange rates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
+
+// UploadExchangeRates loads an organization's exchange rates from a CSV file with
+// base_currency, quote_currency, rate and effective_on columns. A rate for a pair and date
+// that already has one replaces it. The file is saved whole or, if any line is invalid, not at all.
+func UploadExchangeRates(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsAdmin(r) {
+		http.Error(w, "Only administrators can upload exchange rates", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	organizationID := r.FormValue("organization_id")
+	if organizationID == "" {
+		http.Error(w, "organization_id is required", http.StatusBadRequest)
+		return
+	}
+	var organization models.Organization
+	if err := database.DB.First(&organization, "id = ?", organizationID).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	rates, problems := parseExchangeRates(file, organization.ID, currentUserID(r))
+	result := models.ExchangeRateUploadResult{Errors: []string{}, Rates: []models.ExchangeRate{}}
+	w.Header().Set("Content-Type", "application/json")
+	if len(problems) > 0 {
+		result.Errors = problems
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, rate := range rates {
+			var existing models.ExchangeRate
+			err := tx.Where("organization_id = ? AND base_currency = ? AND quote_currency = ? AND effective_on = ?",
+				rate.OrganizationID, rate.BaseCurrency, rate.QuoteCurrency, rate.EffectiveOn.Format("2006-01-02")).
+				First(&existing).Error
+			switch {
+			case err == nil:
+				existing.Rate, existing.UploadedBy = rate.Rate, rate.UploadedBy
+				if err := tx.Save(&existing).Error; err != nil {
+					return err
+				}
+				rate = existing
+				result.Updated++
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(&rate).Error; err != nil {
+					return err
+				}
+				result.Created++
+			default:
+				return err
+			}
+			result.Rates = append(result.Rates, rate)
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, "Failed to save exchange rates", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+func DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsAdmin(r) {
+		http.Error(w, "Only administrators can delete exchange rates", http.StatusForbidden)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	result := database.DB.Delete(&models.ExchangeRate{}, "id = ?", id)
+	if result.Error != nil {
+		http.Error(w, "Failed to delete exchange rate", http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		http.Error(w, "Exchange rate not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMoney(t *testing.T) {
+	if m := models.NewMoney(19.996, "USD"); m.Amount != 2000 {
+		t.Errorf("Expected 19.996 USD to round to 2000 cents, got %d", m.Amount)
+	}
+	if m := models.NewMoney(1234.5, "JPY"); m.Amount != 1235 || m.Float() != 1235 {
+		t.Errorf("Expected 1235 yen, got %d", m.Amount)
+	}
+
+	if amount := models.RoundMoney(1.2346, "KWD"); amount != 1.235 {
+		t.Errorf("Expected 1.2346 KWD to round to 1.235, got %v", amount)
+	}
+	if _, total := invoiceTaxes(models.InvoiceLines{{Amount: 4999, Taxable: true}}, models.TaxRates{{Name: "Consumption", Rate: 10}}, "JPY"); total != 500 {
+		t.Errorf("Expected 500 yen of tax, got %v", total)
+	}
+
+	converted := models.Money{Amount: 10000, Currency: "USD"}.Convert("CAD", 1.3712)
+	if converted.Amount != 13712 || converted.Currency != "CAD" {
+		t.Errorf("Expected 137.12 CAD, got %+v", converted)
+	}
+	if converted := (models.Money{Amount: 10000, Currency: "USD"}).Convert("JPY", 149.237); converted.Amount != 14924 {
+		t.Errorf("Expected 14924 yen, got %d", converted.Amount)
+	}
+}
+
+func TestParseExchangeRates(t *testing.T) {
+	file := "Base_Currency,quote_currency,rate,effective_on\n" +
+		"usd,CAD,1.3712,2026-10-01\n" +
+		"EUR,USD,1.0841,2026-10-01\n" +
+		"USD,CAD,1.38,2026-10-01\n" +
+		"USD,USD,1,2026-10-01\n" +
+		"GBP,USD,-1,2026-10-01\n" +
+		"GBP,USD,1.27,10/01/2026\n"
+
+	rates, problems := parseExchangeRates(strings.NewReader(file), "org-1", nil)
+	if len(rates) != 2 || rates[0].BaseCurrency != "USD" || rates[0].Rate != 1.3712 {
+		t.Errorf("Expected the two valid rates, got %+v", rates)
+	}
+	want := []string{
+		"line 4: repeats the USD/CAD rate on line 2",
+		"line 5: base and quote currencies are the same",
+		"line 6: rate must be a positive number",
+		"line 7: effective_on must be in YYYY-MM-DD format",
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected problems %q, got %q", want, problems)
+	}
+
+	if _, problems := parseExchangeRates(strings.NewReader("base_currency,rate\n"), "org-1", nil); len(problems) != 2 {
+		t.Errorf("Expected the two missing columns to be reported, got %q", problems)
+	}
+}
+
+func TestTallyRevenue(t *testing.T) {
+	report := &models.RevenueReport{Currency: "USD", Total: models.Money{Currency: "USD"}}
+	toronto := &models.Location{ID: "loc-1", Name: "Toronto"}
+	seattle := &models.Location{ID: "loc-2", Name: "Seattle"}
+
+	invoice := func(currency string, subtotal, tax float64) *models.Invoice {
+		return &models.Invoice{
+			Currency: currency,
+			Subtotal: models.NewMoney(subtotal, currency),
+			TaxTotal: models.NewMoney(tax, currency),
+			Total:    models.NewMoney(subtotal+tax, currency),
+		}
+	}
+	tallyRevenue(report, invoice("CAD", 100, 13), toronto, 0.73)
+	tallyRevenue(report, invoice("CAD", 200, 26), toronto, 0.72)
+	tallyRevenue(report, invoice("USD", 50, 5), seattle, 1)
+
+	if len(report.Locations) != 2 {
+		t.Fatalf("Expected 2 locations, got %+v", report.Locations)
+	}
+	if got := report.Locations[0]; got.Invoices != 2 || got.Total.Amount != 33900 || got.Converted.Amount != 8249+16272 {
+		t.Errorf("Expected 339.00 CAD converted to 245.21 USD for Toronto, got %+v", got)
+	}
+	if report.Total.Amount != 8249+16272+5500 {
+		t.Errorf("Expected a total of 300.21 USD, got %d", report.Total.Amount)
+	}
+}
+
+func TestExchangeRates_UploadAndQuote(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "Toronto")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Model(loc).Update("currency", "CAD")
+	db.Model(vehicle).Updates(models.Vehicle{DailyRate: models.NewMoney(100, "CAD")})
+
+	upload := func(csv string, roles ...string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("organization_id", org.ID)
+		part, _ := form.CreateFormFile("file", "rates.csv")
+		part.Write([]byte(csv))
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/exchange-rates/upload", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req = withRoles(t, req, roles...)
+		w := httptest.NewRecorder()
+		UploadExchangeRates(w, req)
+		return w
+	}
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	file := "base_currency,quote_currency,rate,effective_on\nUSD,CAD,1.25," + yesterday + "\n"
+	if w := upload(file, models.RoleStaff); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for staff, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := upload(file, models.RoleAdmin); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	pickupAt := time.Now().UTC().AddDate(0, 0, 7).Truncate(24 * time.Hour)
+	quote := func(currency string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/vehicles/"+vehicle.ID+"/quote?pickup_at="+pickupAt.Format(time.RFC3339)+
+			"&return_at="+pickupAt.AddDate(0, 0, 2).Format(time.RFC3339)+"&currency="+currency, nil)
+		req = withURLParams(req, "id", vehicle.ID)
+		w := httptest.NewRecorder()
+		GetVehicleQuote(w, req)
+		return w
+	}
+
+	// Only USD to CAD was uploaded, so CAD to USD uses its inverse
+	w := quote("USD")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var priced models.Quote
+	json.NewDecoder(w.Body).Decode(&priced)
+	if priced.Total.Currency != "CAD" || priced.Total.Amount != 20000 {
+		t.Errorf("Expected 200.00 CAD for 2 days, got %+v", priced.Total)
+	}
+	if priced.ConvertedTotal == nil || priced.ConvertedTotal.Amount != 16000 {
+		t.Errorf("Expected 160.00 USD converted, got %+v", priced.ConvertedTotal)
+	}
+
+	if w := quote("EUR"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d without a CAD to EUR rate, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
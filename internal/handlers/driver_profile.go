@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func validateMinimumDriverAge(age int) error {
+	if age < 16 || age > 99 {
+		return fmt.Errorf("minimum_driver_age must be between 16 and 99")
+	}
+	return nil
+}
+
+func validateDriverProfile(req *models.DriverProfileRequest) error {
+	req.LicenseNumber = strings.TrimSpace(req.LicenseNumber)
+	req.LicenseCountry = strings.TrimSpace(req.LicenseCountry)
+	if req.LicenseNumber == "" || req.LicenseCountry == "" {
+		return fmt.Errorf("license_number and license_country are required")
+	}
+	if req.LicenseExpiry.IsZero() || req.DateOfBirth.IsZero() {
+		return fmt.Errorf("license_expiry and date_of_birth are required")
+	}
+	if !req.DateOfBirth.Before(time.Now()) {
+		return fmt.Errorf("date_of_birth must be in the past")
+	}
+	return nil
+}
+
+// driverProblems lists why a user cannot drive a rental picked up and returned at the given
+// times: they need a driver profile, must be old enough for the organization on the pickup day
+// and hold a license that is still valid on the return day. With requireVerified the license
+// must also have been verified by staff. label names the driver in the reasons.
+func driverProblems(userID string, org *models.Organization, pickupAt, returnAt time.Time, requireVerified bool, label string) ([]string, error) {
+	var profile models.DriverProfile
+	err := database.DB.First(&profile, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return []string{label + " has no driver profile"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	minimumAge := org.MinimumDriverAge
+	if minimumAge == 0 {
+		minimumAge = models.DefaultMinimumDriverAge
+	}
+	if age := profile.AgeOn(pickupAt); age < minimumAge {
+		problems = append(problems, fmt.Sprintf("%s is %d, under the minimum age of %d", label, age, minimumAge))
+	}
+	if !profile.LicenseValidOn(returnAt) {
+		problems = append(problems, fmt.Sprintf("%s's license expires %s, before the return date", label, profile.LicenseExpiry.Format("2006-01-02")))
+	}
+	if requireVerified && !profile.LicenseVerified {
+		problems = append(problems, label+"'s license has not been verified")
+	}
+	return problems, nil
+}
+
+// rentalDriverProblems checks the rental's customer and every additional driver
+func rentalDriverProblems(rental *models.Rental, requireVerified bool) ([]string, error) {
+	var org models.Organization
+	if err := database.DB.First(&org, "id = ?", rental.OrganizationID).Error; err != nil {
+		return nil, err
+	}
+
+	problems := []string{}
+	if rental.CustomerID != nil {
+		customer, err := driverProblems(*rental.CustomerID, &org, rental.PickupAt, rental.ReturnAt, requireVerified, "customer")
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, customer...)
+	}
+
+	var drivers []models.RentalDriver
+	if err := database.DB.Where("rental_id = ?", rental.ID).Find(&drivers).Error; err != nil {
+		return nil, err
+	}
+	for _, driver := range drivers {
+		additional, err := driverProblems(driver.UserID, &org, rental.PickupAt, rental.ReturnAt, requireVerified, "driver "+driver.UserID)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, additional...)
+	}
+	return problems, nil
+}
+
+func writeDriverProfile(w http.ResponseWriter, userID string) {
+	var profile models.DriverProfile
+	if err := database.DB.First(&profile, "user_id = ?", userID).Error; err != nil {
+		http.Error(w, "Driver profile not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// GetMyDriverProfile returns the current user's driver profile
+func GetMyDriverProfile(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+	writeDriverProfile(w, *userID)
+}
+
+// SaveMyDriverProfile creates or replaces the current user's driver profile. Changing the
+// license details clears its verification.
+func SaveMyDriverProfile(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.DriverProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateDriverProfile(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusOK
+	profile := models.DriverProfile{UserID: *userID}
+	err := database.DB.First(&profile, "user_id = ?", *userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		status = http.StatusCreated
+	} else if err != nil {
+		http.Error(w, "Failed to save driver profile", http.StatusInternalServerError)
+		return
+	}
+
+	licenseChanged := profile.LicenseNumber != req.LicenseNumber || profile.LicenseState != req.LicenseState ||
+		profile.LicenseCountry != req.LicenseCountry || profile.LicenseClass != req.LicenseClass ||
+		!profile.LicenseExpiry.Equal(req.LicenseExpiry) || !profile.DateOfBirth.Equal(req.DateOfBirth)
+
+	profile.LicenseNumber = req.LicenseNumber
+	profile.LicenseState = req.LicenseState
+	profile.LicenseCountry = req.LicenseCountry
+	profile.LicenseClass = req.LicenseClass
+	profile.LicenseExpiry = req.LicenseExpiry
+	profile.DateOfBirth = req.DateOfBirth
+	profile.AddressLine1 = req.AddressLine1
+	profile.AddressLine2 = req.AddressLine2
+	profile.City = req.City
+	profile.State = req.State
+	profile.ZipCode = req.ZipCode
+	profile.Country = req.Country
+	if licenseChanged {
+		profile.LicenseVerified = false
+		profile.LicenseVerifiedAt = nil
+		profile.LicenseVerifiedBy = nil
+	}
+
+	if err := database.DB.Save(&profile).Error; err != nil {
+		http.Error(w, "Failed to save driver profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(profile)
+}
+
+// GetUserDriverProfile returns a user's driver profile to staff
+func GetUserDriverProfile(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can view driver profiles", http.StatusForbidden)
+		return
+	}
+	writeDriverProfile(w, chi.URLParam(r, "id"))
+}
+
+// VerifyDriverLicense records that staff have checked a user's license against the physical
+// card. An expired license cannot be verified.
+func VerifyDriverLicense(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can verify licenses", http.StatusForbidden)
+		return
+	}
+
+	var profile models.DriverProfile
+	if err := database.DB.First(&profile, "user_id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Driver profile not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	if !profile.LicenseValidOn(now) {
+		http.Error(w, "License has expired", http.StatusConflict)
+		return
+	}
+
+	profile.LicenseVerified = true
+	profile.LicenseVerifiedAt = &now
+	profile.LicenseVerifiedBy = currentUserID(r)
+
+	if err := database.DB.Save(&profile).Error; err != nil {
+		http.Error(w, "Failed to update driver profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// checkRentalDriverAccess lets staff and the rental's own customer see and change its drivers,
+// writing 403 for anyone else
+func checkRentalDriverAccess(w http.ResponseWriter, r *http.Request, rental *models.Rental) bool {
+	if currentUserIsStaff(r) {
+		return true
+	}
+	if userID := currentUserID(r); userID != nil && rental.CustomerID != nil && *rental.CustomerID == *userID {
+		return true
+	}
+	http.Error(w, "You can only manage drivers on your own rentals", http.StatusForbidden)
+	return false
+}
+
+// GetRentalDrivers lists the additional drivers authorized on a rental with their profiles
+func GetRentalDrivers(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+	if !checkRentalDriverAccess(w, r, &rental) {
+		return
+	}
+
+	var drivers []models.RentalDriver
+	if err := database.DB.Where("rental_id = ?", id).Order("created_at").Find(&drivers).Error; err != nil {
+		http.Error(w, "Failed to fetch drivers", http.StatusInternalServerError)
+		return
+	}
+	for i := range drivers {
+		var profile models.DriverProfile
+		if err := database.DB.First(&profile, "user_id = ?", drivers[i].UserID).Error; err == nil {
+			drivers[i].Driver = &profile
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(drivers)
+}
+
+// AddRentalDriver authorizes another driver on a rental that has not been returned. The driver
+// must meet the same age and license rules as the customer.
+func AddRentalDriver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.AddRentalDriverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+	if !checkRentalDriverAccess(w, r, &rental) {
+		return
+	}
+	if rental.Status != models.RentalStatusReserved && rental.Status != models.RentalStatusActive {
+		http.Error(w, fmt.Sprintf("Rental is %s and cannot take more drivers", rental.Status), http.StatusConflict)
+		return
+	}
+	if rental.CustomerID != nil && *rental.CustomerID == req.UserID {
+		http.Error(w, "Driver is already the rental's customer", http.StatusConflict)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", req.UserID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusBadRequest)
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.RentalDriver{}).Where("rental_id = ? AND user_id = ?", id, req.UserID).Count(&existing)
+	if existing > 0 {
+		http.Error(w, "Driver is already on the rental", http.StatusConflict)
+		return
+	}
+
+	var org models.Organization
+	if err := database.DB.First(&org, "id = ?", rental.OrganizationID).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusInternalServerError)
+		return
+	}
+	// Drivers added after pickup have their license checked at the counter
+	problems, err := driverProblems(req.UserID, &org, rental.PickupAt, rental.ReturnAt,
+		rental.Status == models.RentalStatusActive, "driver")
+	if err != nil {
+		http.Error(w, "Failed to check driver", http.StatusInternalServerError)
+		return
+	}
+	if len(problems) > 0 {
+		http.Error(w, "Driver is not eligible: "+strings.Join(problems, "; "), http.StatusUnprocessableEntity)
+		return
+	}
+
+	driver := models.RentalDriver{
+		RentalID: rental.ID,
+		UserID:   req.UserID,
+		AddedBy:  currentUserID(r),
+	}
+
+	if err := database.DB.Create(&driver).Error; err != nil {
+		http.Error(w, "Failed to add driver", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(driver)
+}
+
+// RemoveRentalDriver takes an additional driver off a rental
+func RemoveRentalDriver(w http.ResponseWriter, r *http.Request) {
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+	if !checkRentalDriverAccess(w, r, &rental) {
+		return
+	}
+
+	result := database.DB.Where("rental_id = ? AND user_id = ?", rental.ID, chi.URLParam(r, "userId")).
+		Delete(&models.RentalDriver{})
+	if result.Error != nil {
+		http.Error(w, "Failed to remove driver", http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		http.Error(w, "Driver not found on rental", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDriverProfileAgeAndLicense(t *testing.T) {
+	profile := &models.DriverProfile{
+		DateOfBirth:   time.Date(2004, 6, 15, 0, 0, 0, 0, time.UTC),
+		LicenseExpiry: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	if age := profile.AgeOn(time.Date(2025, 6, 14, 12, 0, 0, 0, time.UTC)); age != 20 {
+		t.Errorf("Expected age 20 the day before the birthday, got %d", age)
+	}
+	if age := profile.AgeOn(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)); age != 21 {
+		t.Errorf("Expected age 21 on the birthday, got %d", age)
+	}
+
+	if !profile.LicenseValidOn(time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the license to be valid on its expiry date")
+	}
+	if profile.LicenseValidOn(time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the license to have expired the day after its expiry date")
+	}
+}
+
+func TestAddRentalDriver_Eligibility(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Model(org).Update("minimum_driver_age", 25)
+
+	pickupAt := time.Now().UTC().AddDate(0, 0, 1)
+	rental := models.Rental{
+		OrganizationID:   org.ID,
+		VehicleID:        &vehicle.ID,
+		PickupLocationID: loc.ID,
+		ReturnLocationID: loc.ID,
+		Status:           models.RentalStatusReserved,
+		PickupAt:         pickupAt,
+		ReturnAt:         pickupAt.AddDate(0, 0, 7),
+	}
+	db.Create(&rental)
+
+	young := testutil.CreateTestUser(t, db, "young@example.com", "password123")
+	db.Create(&models.DriverProfile{
+		UserID: young.ID, LicenseNumber: "D1234567", LicenseCountry: "USA",
+		LicenseExpiry: pickupAt.AddDate(5, 0, 0), DateOfBirth: pickupAt.AddDate(-22, 0, 0),
+	})
+	expiring := testutil.CreateTestUser(t, db, "expiring@example.com", "password123")
+	db.Create(&models.DriverProfile{
+		UserID: expiring.ID, LicenseNumber: "D7654321", LicenseCountry: "USA",
+		LicenseExpiry: pickupAt.AddDate(0, 0, 3), DateOfBirth: pickupAt.AddDate(-40, 0, 0),
+	})
+	eligible := testutil.CreateTestUser(t, db, "eligible@example.com", "password123")
+	db.Create(&models.DriverProfile{
+		UserID: eligible.ID, LicenseNumber: "D1111111", LicenseCountry: "USA",
+		LicenseExpiry: pickupAt.AddDate(5, 0, 0), DateOfBirth: pickupAt.AddDate(-30, 0, 0),
+	})
+
+	add := func(userID string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.AddRentalDriverRequest{UserID: userID})
+		req := httptest.NewRequest(http.MethodPost, "/api/rentals/"+rental.ID+"/drivers", bytes.NewBuffer(body))
+		req = withRoles(t, withURLParams(req, "id", rental.ID), "staff")
+		w := httptest.NewRecorder()
+		AddRentalDriver(w, req)
+		return w
+	}
+
+	if w := add(young.ID); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d for an under-age driver, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if w := add(expiring.ID); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d for a license expiring before the return, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if w := add(eligible.ID); w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// Other customers cannot see the drivers' profiles
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/api/rentals/"+rental.ID+"/drivers", nil), "id", rental.ID)
+	w := httptest.NewRecorder()
+	GetRentalDrivers(w, withUser(t, req, young.ID))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d listing another rental's drivers, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,601 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// ImportJobConfig configures background import processing
+type ImportJobConfig struct {
+	Workers        int
+	StorageDir     string
+	MaxUploadBytes int64
+}
+
+var importConfig = ImportJobConfig{
+	Workers:        2,
+	StorageDir:     filepath.Join(os.TempDir(), "fleetpass-imports"),
+	MaxUploadBytes: 200 << 20,
+}
+
+// importWake nudges idle workers when a job is queued
+var importWake = make(chan struct{}, 1)
+
+// maxStoredRejections caps the row errors and rejected rows persisted per job
+const maxStoredRejections = 5000
+
+// importPollInterval is how often idle workers look for queued jobs they were not woken for
+const importPollInterval = 30 * time.Second
+
+var errImportCancelled = errors.New("import cancelled")
+
+// StartImportWorkers starts the background workers that process queued import jobs
+func StartImportWorkers(ctx context.Context, config ImportJobConfig) error {
+	importConfig = config
+	if err := os.MkdirAll(config.StorageDir, 0o750); err != nil {
+		return fmt.Errorf("error creating import storage directory: %w", err)
+	}
+
+	// Jobs left running by a previous process cannot be resumed safely
+	now := time.Now()
+	database.DB.Model(&models.ImportJob{}).
+		Where("status = ?", models.ImportJobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":       models.ImportJobStatusFailed,
+			"error":        "Interrupted by server restart",
+			"completed_at": now,
+		})
+
+	for i := 0; i < config.Workers; i++ {
+		go importWorker(ctx)
+	}
+	wakeImportWorkers()
+	return nil
+}
+
+func wakeImportWorkers() {
+	select {
+	case importWake <- struct{}{}:
+	default:
+	}
+}
+
+func importWorker(ctx context.Context) {
+	ticker := time.NewTicker(importPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			job := claimImportJob()
+			if job == nil {
+				break
+			}
+			runImportJob(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-importWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimImportJob marks the oldest queued job as running and returns it, or nil if none is queued
+func claimImportJob() *models.ImportJob {
+	for {
+		var job models.ImportJob
+		if err := database.DB.Where("status = ?", models.ImportJobStatusQueued).Order("created_at").First(&job).Error; err != nil {
+			return nil
+		}
+
+		now := time.Now()
+		result := database.DB.Model(&models.ImportJob{}).
+			Where("id = ? AND status = ?", job.ID, models.ImportJobStatusQueued).
+			Updates(map[string]interface{}{"status": models.ImportJobStatusRunning, "started_at": now})
+		if result.Error != nil {
+			return nil
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.ImportJobStatusRunning
+			job.StartedAt = &now
+			return &job
+		}
+		// Another worker claimed it first; try the next one
+	}
+}
+
+// countingReader tracks how many bytes of the upload have been consumed
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// runImportJob streams the job's file through the import session in batches, recording progress
+// after each batch. Partial imports commit batch by batch; atomic imports run in one transaction.
+func runImportJob(ctx context.Context, job *models.ImportJob) {
+	defer os.Remove(job.FilePath)
+
+	result := newBulkUploadResult(job.Mode, job.OnConflict)
+	var rejected []*importRow
+
+	runErr := func() error {
+		file, err := os.Open(job.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to open uploaded file: %w", err)
+		}
+		defer file.Close()
+
+		// Workbooks are read whole, so byte progress is only meaningful for line-based formats
+		counter := &countingReader{r: file}
+		var input io.Reader = counter
+		if job.Format == ImportFormatXLSX {
+			input = file
+		}
+
+		var profile *models.ImportProfile
+		if job.ProfileID != nil {
+			if profile, err = loadImportProfile(*job.ProfileID, job.OrganizationID); err != nil {
+				return err
+			}
+		}
+
+		source, err := openImportSource(input, job.Format, job.Sheet, profile)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+
+		headers := source.Headers()
+		job.Headers = models.StringArray(headers)
+
+		session, err := newImportSession(headers, job.OrganizationID, job.LocationID, job.Mode, job.OnConflict, job.DeactivateMissing)
+		if err != nil {
+			return err
+		}
+		if session.currency, err = locationCurrency(job.LocationID); err != nil {
+			return err
+		}
+
+		process := func(tx *gorm.DB) error {
+			var aborted bool
+			batch := make([]*importRow, 0, importBatchSize)
+
+			flush := func() error {
+				session.plan(batch)
+
+				switch job.Mode {
+				case BulkUploadModeDryRun:
+				case BulkUploadModeAtomic:
+					// After the first rejected row keep validating, but stop writing
+					if !aborted && !hasRejectedRows(batch) {
+						session.write(tx, batch)
+					}
+					aborted = aborted || hasRejectedRows(batch)
+				default:
+					session.write(tx, batch)
+				}
+
+				for _, row := range batch {
+					result.tally(row, true)
+					if len(row.errors) > 0 {
+						rejected = append(rejected, row)
+					}
+				}
+				batch = batch[:0]
+
+				// Per-row results are not persisted for jobs; keep memory flat on large files
+				result.Rows = result.Rows[:0]
+				result.Errors = result.Errors[:0]
+				if len(result.Report) > maxStoredRejections+1 {
+					result.Report = result.Report[:maxStoredRejections+1]
+				}
+				if len(rejected) > maxStoredRejections+1 {
+					rejected = rejected[:maxStoredRejections+1]
+				}
+
+				job.BytesProcessed = counter.n
+				job.ProcessedRows = result.Total
+				saveImportProgress(job, result)
+
+				if ctx.Err() != nil || importCancelRequested(job.ID) {
+					return errImportCancelled
+				}
+				return nil
+			}
+
+			line := 1 // header is row 1
+			for {
+				record, err := source.Next()
+				if err == io.EOF {
+					break
+				}
+				line++
+				batch = append(batch, session.parseRow(line, record, err))
+
+				if len(batch) == importBatchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			if len(batch) > 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+			if aborted {
+				return errImportRejected
+			}
+
+			if job.DeactivateMissing {
+				ids, skipped, err := session.deactivate(tx, job.Mode == BulkUploadModeDryRun)
+				if err != nil {
+					return err
+				}
+				result.DeactivatedIDs = ids
+				result.Deactivated = len(ids)
+				result.DeactivationSkipped = skipped
+			}
+			job.BytesProcessed = counter.n
+			return nil
+		}
+
+		if job.Mode == BulkUploadModeAtomic {
+			return database.DB.Transaction(process)
+		}
+		return process(database.DB)
+	}()
+
+	// A rolled back atomic import wrote nothing
+	if job.Mode == BulkUploadModeAtomic && runErr != nil {
+		result.Success, result.Created, result.Updated, result.Unchanged, result.Skipped = 0, 0, 0, 0, 0
+		result.Failed = result.Total
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+	switch {
+	case runErr == nil:
+		job.Status = models.ImportJobStatusCompleted
+	case errors.Is(runErr, errImportCancelled):
+		job.Status = models.ImportJobStatusCancelled
+	case errors.Is(runErr, errImportRejected):
+		job.Status = models.ImportJobStatusFailed
+		job.Error = "Some rows were rejected; nothing was imported"
+	default:
+		job.Status = models.ImportJobStatusFailed
+		job.Error = runErr.Error()
+	}
+
+	job.Report = models.ImportRowErrors(result.Report)
+	records := rejectedRecords(rejected)
+	if len(job.Report) > maxStoredRejections || len(records) > maxStoredRejections {
+		job.ReportTruncated = true
+		if len(job.Report) > maxStoredRejections {
+			job.Report = job.Report[:maxStoredRejections]
+		}
+		if len(records) > maxStoredRejections {
+			records = records[:maxStoredRejections]
+		}
+	}
+	job.Rejected = models.ImportRejectedRows(records)
+	applyImportCounts(job, result)
+
+	// The vehicles are already in, so this only flags a lot the import overfilled
+	var warnings []string
+	if result.Created > 0 {
+		var location models.Location
+		if err := database.DB.First(&location, "id = ?", job.LocationID).Error; err == nil {
+			if warning, err := capacityWarning(database.DB, &location, 0); err == nil && warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	if len(result.DeactivationSkipped) > 0 {
+		warnings = append(warnings, deactivationWarning(result.DeactivationSkipped))
+	}
+	job.Warning = strings.Join(warnings, "; ")
+
+	if err := database.DB.Save(job).Error; err != nil {
+		log.Printf("Failed to save import job %s: %v", job.ID, err)
+	}
+}
+
+func hasRejectedRows(rows []*importRow) bool {
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func applyImportCounts(job *models.ImportJob, result *BulkUploadResult) {
+	job.ProcessedRows = result.Total
+	job.Success = result.Success
+	job.Failed = result.Failed
+	job.Created = result.Created
+	job.Updated = result.Updated
+	job.Unchanged = result.Unchanged
+	job.Skipped = result.Skipped
+	job.Deactivated = result.Deactivated
+}
+
+// saveImportProgress records progress outside any import transaction so pollers can see it
+func saveImportProgress(job *models.ImportJob, result *BulkUploadResult) {
+	applyImportCounts(job, result)
+	database.DB.Model(&models.ImportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"bytes_processed": job.BytesProcessed,
+		"processed_rows":  job.ProcessedRows,
+		"success":         job.Success,
+		"failed":          job.Failed,
+		"created":         job.Created,
+		"updated":         job.Updated,
+		"unchanged":       job.Unchanged,
+		"skipped":         job.Skipped,
+	})
+}
+
+func importCancelRequested(id string) bool {
+	var job models.ImportJob
+	if err := database.DB.Select("cancel_requested").First(&job, "id = ?", id).Error; err != nil {
+		return false
+	}
+	return job.CancelRequested
+}
+
+// CreateImportJob stores an uploaded inventory file and queues it for background import.
+// It accepts the same form fields as BulkUploadVehicles and responds 202 with the queued job.
+func CreateImportJob(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, importConfig.MaxUploadBytes)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart form data", http.StatusBadRequest)
+		return
+	}
+
+	// Stream the file part to disk instead of buffering it in memory
+	fields := make(map[string]string)
+	var filePath, filename string
+	var fileSize int64
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			removeUpload(filePath)
+			http.Error(w, "Failed to read upload", http.StatusBadRequest)
+			return
+		}
+
+		if part.FormName() == "file" && filePath == "" {
+			filename = part.FileName()
+			dest, err := os.CreateTemp(importConfig.StorageDir, "import-*"+filepath.Ext(filename))
+			if err != nil {
+				http.Error(w, "Failed to store upload", http.StatusInternalServerError)
+				return
+			}
+			filePath = dest.Name()
+			fileSize, err = io.Copy(dest, part)
+			dest.Close()
+			if err != nil {
+				removeUpload(filePath)
+				http.Error(w, "Failed to store upload", http.StatusRequestEntityTooLarge)
+				return
+			}
+			continue
+		}
+
+		value, _ := io.ReadAll(io.LimitReader(part, 1024))
+		fields[part.FormName()] = string(value)
+	}
+
+	if filePath == "" {
+		http.Error(w, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	format, err := detectStoredImportFormat(fields["format"], filename, filePath)
+	if err != nil {
+		removeUpload(filePath)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mode := fields["mode"]
+	onConflict := fields["on_conflict"]
+	if err := validateImportOptions(&mode, &onConflict); err != nil {
+		removeUpload(filePath)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	organizationID := fields["organization_id"]
+	locationID := fields["location_id"]
+	if organizationID == "" || locationID == "" {
+		removeUpload(filePath)
+		http.Error(w, "organization_id and location_id are required", http.StatusBadRequest)
+		return
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ? AND organization_id = ?", locationID, organizationID).Error; err != nil {
+		removeUpload(filePath)
+		http.Error(w, "Location not found or does not belong to organization", http.StatusBadRequest)
+		return
+	}
+
+	var profileID *string
+	if id := fields["profile_id"]; id != "" {
+		if _, err := loadImportProfile(id, organizationID); err != nil {
+			removeUpload(filePath)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		profileID = &id
+	}
+
+	job := models.ImportJob{
+		OrganizationID:    organizationID,
+		LocationID:        locationID,
+		CreatedBy:         currentUserID(r),
+		Filename:          filename,
+		Format:            format,
+		Sheet:             fields["sheet"],
+		ProfileID:         profileID,
+		FilePath:          filePath,
+		FileSize:          fileSize,
+		Mode:              mode,
+		OnConflict:        onConflict,
+		DeactivateMissing: fields["deactivate_missing"] == "true",
+		Status:            models.ImportJobStatusQueued,
+	}
+
+	if err := database.DB.Create(&job).Error; err != nil {
+		removeUpload(filePath)
+		http.Error(w, "Failed to create import job", http.StatusInternalServerError)
+		return
+	}
+
+	wakeImportWorkers()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/import-jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// detectStoredImportFormat detects the format of an upload already written to disk
+func detectStoredImportFormat(requested, filename, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	return detectImportFormat(requested, filename, head[:n])
+}
+
+func removeUpload(path string) {
+	if path != "" {
+		os.Remove(path)
+	}
+}
+
+// GetImportJobs lists import jobs, newest first, optionally filtered by organization_id
+func GetImportJobs(w http.ResponseWriter, r *http.Request) {
+	query := database.DB.Omit("report", "rejected", "headers").Order("created_at DESC")
+	if organizationID := r.URL.Query().Get("organization_id"); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+
+	var jobs []models.ImportJob
+	if err := query.Find(&jobs).Error; err != nil {
+		http.Error(w, "Failed to fetch import jobs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// GetImportJob returns an import job's progress and, once finished, its results
+func GetImportJob(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var job models.ImportJob
+	if err := database.DB.First(&job, "id = ?", id).Error; err != nil {
+		http.Error(w, "Import job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// CancelImportJob cancels a queued job immediately or asks a running job to stop after its
+// current batch. Batches already committed by a partial import are kept.
+func CancelImportJob(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var job models.ImportJob
+	if err := database.DB.First(&job, "id = ?", id).Error; err != nil {
+		http.Error(w, "Import job not found", http.StatusNotFound)
+		return
+	}
+
+	if job.IsFinished() {
+		http.Error(w, "Import job has already finished", http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	result := database.DB.Model(&models.ImportJob{}).
+		Where("id = ? AND status = ?", id, models.ImportJobStatusQueued).
+		Updates(map[string]interface{}{
+			"status":           models.ImportJobStatusCancelled,
+			"cancel_requested": true,
+			"completed_at":     now,
+		})
+	if result.Error != nil {
+		http.Error(w, "Failed to cancel import job", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 1 {
+		removeUpload(job.FilePath)
+	} else if err := database.DB.Model(&models.ImportJob{}).Where("id = ?", id).Update("cancel_requested", true).Error; err != nil {
+		http.Error(w, "Failed to cancel import job", http.StatusInternalServerError)
+		return
+	}
+
+	database.DB.First(&job, "id = ?", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetImportJobRejectedRows downloads a finished job's rejected rows as CSV with an errors column
+func GetImportJobRejectedRows(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var job models.ImportJob
+	if err := database.DB.First(&job, "id = ?", id).Error; err != nil {
+		http.Error(w, "Import job not found", http.StatusNotFound)
+		return
+	}
+
+	if !job.IsFinished() {
+		http.Error(w, "Import job has not finished yet", http.StatusConflict)
+		return
+	}
+
+	writeRejectedRowsCSV(w, http.StatusOK, job.Headers, job.Rejected)
+}
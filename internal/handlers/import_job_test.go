@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestImportJob_ProcessesQueuedUpload(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+	importConfig.StorageDir = t.TempDir()
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+
+	csvContent := `vin,make,model,year
+1HGBH41JXMN109186,Honda,Accord,2022
+1FTFW1ET8EFA12345,Ford,F-150,not-a-year`
+
+	req := newBulkUploadRequest(t, map[string]string{
+		"organization_id": org.ID,
+		"location_id":     loc.ID,
+	}, "vehicles.csv", csvContent)
+	w := httptest.NewRecorder()
+
+	CreateImportJob(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+
+	var queued models.ImportJob
+	if err := json.NewDecoder(w.Body).Decode(&queued); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if queued.Status != models.ImportJobStatusQueued {
+		t.Errorf("Expected status queued, got %s", queued.Status)
+	}
+
+	// Run the job the way a worker would
+	job := claimImportJob()
+	if job == nil || job.ID != queued.ID {
+		t.Fatal("Expected the queued job to be claimed")
+	}
+	runImportJob(context.Background(), job)
+
+	var finished models.ImportJob
+	db.First(&finished, "id = ?", queued.ID)
+
+	if finished.Status != models.ImportJobStatusCompleted {
+		t.Fatalf("Expected status completed, got %s (%s)", finished.Status, finished.Error)
+	}
+	if finished.Success != 1 || finished.Failed != 1 {
+		t.Errorf("Expected 1 success and 1 failure, got %d/%d", finished.Success, finished.Failed)
+	}
+	if finished.Progress != 100 {
+		t.Errorf("Expected progress 100, got %d", finished.Progress)
+	}
+	if _, err := os.Stat(job.FilePath); !os.IsNotExist(err) {
+		t.Error("Expected the stored upload to be removed")
+	}
+
+	// The rejected row can be downloaded for correction
+	req = httptest.NewRequest(http.MethodGet, "/api/import-jobs/"+queued.ID+"/rejected-rows", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", queued.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w = httptest.NewRecorder()
+
+	GetImportJobRejectedRows(w, req)
+
+	if !strings.Contains(w.Body.String(), "1FTFW1ET8EFA12345") {
+		t.Errorf("Expected rejected row in CSV, got %s", w.Body.String())
+	}
+}
+
+func TestCancelImportJob_Queued(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+
+	job := models.ImportJob{
+		OrganizationID: org.ID,
+		LocationID:     loc.ID,
+		Mode:           BulkUploadModePartial,
+		OnConflict:     OnConflictError,
+		Status:         models.ImportJobStatusQueued,
+	}
+	db.Create(&job)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import-jobs/"+job.ID+"/cancel", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", job.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	CancelImportJob(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	db.First(&job, "id = ?", job.ID)
+	if job.Status != models.ImportJobStatusCancelled {
+		t.Errorf("Expected status cancelled, got %s", job.Status)
+	}
+
+	// A cancelled job is never picked up
+	if claimImportJob() != nil {
+		t.Error("Expected no job to be claimed")
+	}
+}
@@ -0,0 +1,474 @@
+package handlers
+
+import (
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// importFields lists the vehicle fields an import column can map to
+var importFields = append([]string{"vin"}, importUpdatableColumns...)
+
+// importIntegerFields are written back as whole numbers after a unit conversion
+var importIntegerFields = map[string]bool{
+	"year": true, "mileage": true, "mpg_city": true, "mpg_highway": true, "seats": true, "doors": true,
+}
+
+// importNumericFields may use the unit transform
+var importNumericFields = map[string]bool{
+	"mileage": true, "mpg_city": true, "mpg_highway": true,
+	"daily_rate": true, "weekly_rate": true, "monthly_rate": true,
+}
+
+// importDateFields may use the date transform
+var importDateFields = map[string]bool{
+	"warranty_expiration_date": true,
+}
+
+// importUnitConversions are keyed by "from:to"
+var importUnitConversions = map[string]func(float64) float64{
+	"km:mi":           func(v float64) float64 { return v * 0.621371192 },
+	"mi:km":           func(v float64) float64 { return v * 1.609344 },
+	"kpl:mpg":         func(v float64) float64 { return v * 2.352145833 },
+	"mpg:kpl":         func(v float64) float64 { return v / 2.352145833 },
+	"l_per_100km:mpg": func(v float64) float64 { return 235.2145833 / v },
+	"mpg:l_per_100km": func(v float64) float64 { return 235.2145833 / v },
+}
+
+// validateImportColumns checks a profile's column mappings
+func validateImportColumns(columns []models.ImportColumnMapping) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("columns must map at least one header")
+	}
+
+	known := make(map[string]bool, len(importFields))
+	for _, field := range importFields {
+		known[field] = true
+	}
+
+	sources := make(map[string]bool)
+	fields := make(map[string]bool)
+	for _, column := range columns {
+		source := normalizeHeader(column.Source)
+		if source == "" {
+			return fmt.Errorf("source is required for every column")
+		}
+		if !known[column.Field] {
+			return fmt.Errorf("unknown field: %s", column.Field)
+		}
+		if sources[source] {
+			return fmt.Errorf("source %q is mapped more than once", column.Source)
+		}
+		if fields[column.Field] {
+			return fmt.Errorf("field %s is mapped more than once", column.Field)
+		}
+		sources[source] = true
+		fields[column.Field] = true
+
+		if err := validateImportTransform(column.Field, column.Transform); err != nil {
+			return fmt.Errorf("%s: %s", column.Field, err.Error())
+		}
+	}
+	return nil
+}
+
+func validateImportTransform(field string, transform *models.ImportTransform) error {
+	if transform == nil {
+		return nil
+	}
+
+	switch transform.Type {
+	case models.ImportTransformUnit:
+		if !importNumericFields[field] {
+			return fmt.Errorf("unit transform only applies to numeric fields")
+		}
+		if _, exists := importUnitConversions[transform.From+":"+transform.To]; !exists {
+			return fmt.Errorf("unsupported unit conversion from %q to %q", transform.From, transform.To)
+		}
+	case models.ImportTransformMap:
+		if len(transform.Values) == 0 {
+			return fmt.Errorf("map transform requires values")
+		}
+	case models.ImportTransformDate:
+		if !importDateFields[field] {
+			return fmt.Errorf("date transform only applies to date fields")
+		}
+		if transform.Format == "" {
+			return fmt.Errorf("date transform requires a format")
+		}
+	default:
+		return fmt.Errorf("transform type must be one of unit, map or date")
+	}
+	return nil
+}
+
+// loadImportProfile fetches a profile that belongs to the organization
+func loadImportProfile(id, organizationID string) (*models.ImportProfile, error) {
+	var profile models.ImportProfile
+	if err := database.DB.First(&profile, "id = ? AND organization_id = ?", id, organizationID).Error; err != nil {
+		return nil, fmt.Errorf("Import profile not found")
+	}
+	return &profile, nil
+}
+
+// openImportSource opens a file and, when a profile is given, maps its columns
+func openImportSource(r io.Reader, format, sheet string, profile *models.ImportProfile) (importSource, error) {
+	source, err := newImportSource(r, format, sheet)
+	if err != nil || profile == nil {
+		return source, err
+	}
+	return newMappedSource(source, profile), nil
+}
+
+type mappedColumn struct {
+	index     int
+	field     string
+	transform *models.ImportTransform
+}
+
+// mappedSource renames a file's columns to vehicle fields and transforms their values, so a
+// dealer feed passes through the same validation as a file using our own header names.
+// Headers that are not mapped but already name a vehicle field are passed through; the rest
+// are dropped. Rejected rows are reported with the mapped headers and values.
+type mappedSource struct {
+	source  importSource
+	headers []string
+	columns []mappedColumn
+}
+
+// newMappedSource applies a profile to a source. Mapped headers missing from the file are
+// ignored; missing required fields are reported by the import session.
+func newMappedSource(source importSource, profile *models.ImportProfile) *mappedSource {
+	indexes := make(map[string]int)
+	for i, header := range source.Headers() {
+		if _, exists := indexes[normalizeHeader(header)]; !exists {
+			indexes[normalizeHeader(header)] = i
+		}
+	}
+
+	mapped := &mappedSource{source: source}
+	used := make(map[int]bool)
+	targeted := make(map[string]bool)
+	for _, column := range profile.Columns {
+		index, exists := indexes[normalizeHeader(column.Source)]
+		if !exists {
+			continue
+		}
+		mapped.headers = append(mapped.headers, column.Field)
+		mapped.columns = append(mapped.columns, mappedColumn{index: index, field: column.Field, transform: column.Transform})
+		used[index] = true
+		targeted[column.Field] = true
+	}
+
+	for _, field := range importFields {
+		index, exists := indexes[field]
+		if !exists || used[index] || targeted[field] {
+			continue
+		}
+		mapped.headers = append(mapped.headers, field)
+		mapped.columns = append(mapped.columns, mappedColumn{index: index, field: field})
+	}
+
+	return mapped
+}
+
+func (s *mappedSource) Headers() []string { return s.headers }
+
+func (s *mappedSource) Next() ([]string, error) {
+	record, err := s.source.Next()
+	if err != nil {
+		return record, err
+	}
+
+	mapped := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		if column.index < len(record) {
+			mapped[i] = applyImportTransform(column.transform, column.field, record[column.index])
+		}
+	}
+	return mapped, nil
+}
+
+func (s *mappedSource) Close() error { return s.source.Close() }
+
+// applyImportTransform converts a value for a field. Transforms are best effort: a value that
+// cannot be converted is passed through unchanged and rejected by the usual validation.
+func applyImportTransform(transform *models.ImportTransform, field, value string) string {
+	trimmed := strings.TrimSpace(value)
+	if transform == nil || trimmed == "" {
+		return value
+	}
+
+	switch transform.Type {
+	case models.ImportTransformUnit:
+		number, err := strconv.ParseFloat(trimmed, 64)
+		convert, exists := importUnitConversions[transform.From+":"+transform.To]
+		if err != nil || !exists || number < 0 {
+			return value
+		}
+		converted := convert(number)
+		if math.IsInf(converted, 0) || math.IsNaN(converted) {
+			return value
+		}
+		if importIntegerFields[field] {
+			return strconv.Itoa(int(math.Round(converted)))
+		}
+		return strconv.FormatFloat(math.Round(converted*100)/100, 'f', -1, 64)
+
+	case models.ImportTransformMap:
+		// An exact match wins; otherwise keys are tried in sorted order so that of keys
+		// differing only in case the same one always applies
+		froms := make([]string, 0, len(transform.Values))
+		for from, to := range transform.Values {
+			if strings.TrimSpace(from) == trimmed {
+				return to
+			}
+			froms = append(froms, from)
+		}
+		sort.Strings(froms)
+		for _, from := range froms {
+			if strings.EqualFold(strings.TrimSpace(from), trimmed) {
+				return transform.Values[from]
+			}
+		}
+		if transform.Default != "" {
+			return transform.Default
+		}
+		return value
+
+	case models.ImportTransformDate:
+		if parsed, err := time.Parse(dateLayout(transform.Format), trimmed); err == nil {
+			return parsed.Format("2006-01-02")
+		}
+		// Spreadsheets store dates as days since 1899-12-30
+		if serial, err := strconv.ParseFloat(trimmed, 64); err == nil && serial > 0 && serial < 2958466 {
+			return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)).Format("2006-01-02")
+		}
+		return value
+	}
+	return value
+}
+
+// dateLayout turns a YYYY/MM/DD style format into a Go layout. Formats without YY tokens are
+// taken to be Go layouts already.
+func dateLayout(format string) string {
+	if !strings.Contains(format, "YY") {
+		return format
+	}
+
+	tokens := []struct{ token, layout string }{
+		{"YYYY", "2006"}, {"YY", "06"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"}, {"DD", "02"}, {"D", "2"},
+	}
+
+	var layout strings.Builder
+	for i := 0; i < len(format); {
+		matched := false
+		for _, t := range tokens {
+			if strings.HasPrefix(format[i:], t.token) {
+				layout.WriteString(t.layout)
+				i += len(t.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			layout.WriteByte(format[i])
+			i++
+		}
+	}
+	return layout.String()
+}
+
+// ImportMappingSuggestion proposes a vehicle field for a source header. Field is empty when
+// nothing matched well enough.
+type ImportMappingSuggestion struct {
+	Source    string                  `json:"source"`
+	Field     string                  `json:"field,omitempty"`
+	Score     float64                 `json:"score"`
+	Transform *models.ImportTransform `json:"transform,omitempty"`
+}
+
+// importFieldAliases are names dealer feeds commonly use for each field
+var importFieldAliases = map[string][]string{
+	"vin":                      {"vehicle identification number"},
+	"make":                     {"manufacturer", "brand"},
+	"model":                    {"model name"},
+	"year":                     {"model year"},
+	"trim":                     {"trim level", "series"},
+	"color_exterior":           {"exterior color", "color", "paint"},
+	"color_interior":           {"interior color", "upholstery"},
+	"condition":                {"new used", "vehicle condition"},
+	"mileage":                  {"odometer", "miles", "kilometers"},
+	"license_plate":            {"plate", "plate number", "tag"},
+	"body_style":               {"body", "body type"},
+	"transmission":             {"gearbox"},
+	"drivetrain":               {"drive type", "drive"},
+	"fuel_type":                {"fuel"},
+	"engine":                   {"engine description", "motor"},
+	"mpg_city":                 {"city mpg", "city fuel economy"},
+	"mpg_highway":              {"highway mpg", "highway fuel economy"},
+	"seats":                    {"seating capacity", "passengers"},
+	"doors":                    {"door count"},
+	"stock_number":             {"stock", "stock id"},
+	"description":              {"comments", "notes", "remarks"},
+	"daily_rate":               {"daily price", "price per day", "rate"},
+	"weekly_rate":              {"weekly price", "price per week"},
+	"monthly_rate":             {"monthly price", "price per month"},
+	"features":                 {"options", "equipment"},
+	"has_warranty":             {"warranty", "under warranty"},
+	"warranty_expiration_date": {"warranty expiration", "warranty ends", "warranty expiry"},
+	"warranty_type":            {"warranty plan", "coverage"},
+	"warranty_details":         {"warranty notes", "coverage details"},
+}
+
+// importHeaderAbbreviations expand the short forms found in feed headers
+var importHeaderAbbreviations = map[string]string{
+	"ext": "exterior", "int": "interior", "hwy": "highway", "cty": "city", "yr": "year",
+	"no": "number", "num": "number", "nbr": "number", "stk": "stock", "colour": "color",
+	"trans": "transmission", "mfr": "manufacturer", "odo": "odometer", "desc": "description",
+	"km": "kilometers", "kms": "kilometers", "mi": "miles",
+}
+
+// minSuggestionScore is the similarity below which no field is suggested
+const minSuggestionScore = 0.6
+
+// suggestImportMappings matches each header to its most similar vehicle field. Each field is
+// suggested for at most one header, preferring the best match.
+func suggestImportMappings(headers []string) []ImportMappingSuggestion {
+	type candidate struct {
+		header int
+		field  string
+		score  float64
+	}
+
+	var candidates []candidate
+	for i, header := range headers {
+		tokens := headerTokens(header)
+		if len(tokens) == 0 {
+			continue
+		}
+		for _, field := range importFields {
+			best := similarity(tokens, headerTokens(strings.ReplaceAll(field, "_", " ")))
+			for _, alias := range importFieldAliases[field] {
+				best = math.Max(best, similarity(tokens, headerTokens(alias)))
+			}
+			if best >= minSuggestionScore {
+				candidates = append(candidates, candidate{header: i, field: field, score: best})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	suggestions := make([]ImportMappingSuggestion, len(headers))
+	for i, header := range headers {
+		suggestions[i] = ImportMappingSuggestion{Source: header}
+	}
+
+	assigned := make(map[string]bool)
+	for _, c := range candidates {
+		if suggestions[c.header].Field != "" || assigned[c.field] {
+			continue
+		}
+		assigned[c.field] = true
+		suggestions[c.header].Field = c.field
+		suggestions[c.header].Score = math.Round(c.score*100) / 100
+		suggestions[c.header].Transform = suggestTransform(headers[c.header], c.field)
+	}
+	return suggestions
+}
+
+// suggestTransform proposes a unit conversion when a header names a metric unit
+func suggestTransform(header, field string) *models.ImportTransform {
+	tokens := headerTokens(header)
+	has := func(token string) bool {
+		for _, t := range tokens {
+			if t == token {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case field == "mileage" && has("kilometers"):
+		return &models.ImportTransform{Type: models.ImportTransformUnit, From: "km", To: "mi"}
+	case (field == "mpg_city" || field == "mpg_highway") && has("l") && has("100km"):
+		return &models.ImportTransform{Type: models.ImportTransformUnit, From: "l_per_100km", To: "mpg"}
+	case (field == "mpg_city" || field == "mpg_highway") && has("kpl"):
+		return &models.ImportTransform{Type: models.ImportTransformUnit, From: "kpl", To: "mpg"}
+	}
+	return nil
+}
+
+// normalizeHeader lowercases a header and trims surrounding space
+func normalizeHeader(header string) string {
+	return strings.TrimSpace(strings.ToLower(header))
+}
+
+// headerTokens splits a header into lowercase words, expanding abbreviations and "#"
+func headerTokens(header string) []string {
+	header = strings.ReplaceAll(normalizeHeader(header), "#", " number ")
+	words := strings.FieldsFunc(header, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if expanded, exists := importHeaderAbbreviations[word]; exists {
+			word = expanded
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// similarity scores two token lists between 0 and 1, taking the better of word overlap and
+// the edit distance between the joined words
+func similarity(a, b []string) float64 {
+	seen := make(map[string]int)
+	for _, token := range a {
+		seen[token]++
+	}
+	var shared int
+	for _, token := range b {
+		if seen[token] > 0 {
+			seen[token]--
+			shared++
+		}
+	}
+	overlap := 2 * float64(shared) / float64(len(a)+len(b))
+
+	joinedA, joinedB := strings.Join(a, ""), strings.Join(b, "")
+	longest := math.Max(float64(len(joinedA)), float64(len(joinedB)))
+	edit := 1 - float64(levenshtein(joinedA, joinedB))/longest
+
+	return math.Max(overlap, edit)
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
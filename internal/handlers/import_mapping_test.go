@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dealerFeedColumns maps testdata/dealer_feed.csv onto vehicle fields
+var dealerFeedColumns = []models.ImportColumnMapping{
+	{Source: "Stock #", Field: "stock_number"},
+	{Source: "Model Year", Field: "year"},
+	{Source: "Ext Color", Field: "color_exterior"},
+	{Source: "City MPG", Field: "mpg_city"},
+	{Source: "Odometer (km)", Field: "mileage", Transform: &models.ImportTransform{Type: models.ImportTransformUnit, From: "km", To: "mi"}},
+	{Source: "Cond", Field: "condition", Transform: &models.ImportTransform{
+		Type:   models.ImportTransformMap,
+		Values: map[string]string{"pre-owned": "used", "brand new": "new", "cpo": "certified_pre_owned"},
+	}},
+	{Source: "Warranty Ends", Field: "warranty_expiration_date", Transform: &models.ImportTransform{Type: models.ImportTransformDate, Format: "MM/DD/YYYY"}},
+}
+
+func TestMappedSource_DealerFeed(t *testing.T) {
+	if err := validateImportColumns(dealerFeedColumns); err != nil {
+		t.Fatalf("Expected valid columns, got %v", err)
+	}
+
+	file, err := os.Open(filepath.Join("testdata", "dealer_feed.csv"))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer file.Close()
+
+	source, err := openImportSource(file, ImportFormatCSV, "", &models.ImportProfile{Columns: dealerFeedColumns})
+	if err != nil {
+		t.Fatalf("Failed to open source: %v", err)
+	}
+
+	session, err := newImportSession(source.Headers(), "org-id", "loc-id", BulkUploadModePartial, OnConflictError, false)
+	if err != nil {
+		t.Fatalf("Expected mapped headers to satisfy the session, got %v", err)
+	}
+
+	record, err := source.Next()
+	row := session.parseRow(2, record, err)
+	if len(row.errors) > 0 {
+		t.Fatalf("Expected no errors, got %v", row.errors)
+	}
+
+	vehicle := row.vehicle
+	if vehicle.StockNumber != "A-100" || vehicle.Year != 2022 || vehicle.ColorExterior != "Silver" || vehicle.MPGCity != 30 {
+		t.Errorf("Unexpected mapped vehicle: %+v", vehicle)
+	}
+	if vehicle.Mileage != 15000 {
+		t.Errorf("Expected 24140 km to become 15000 mi, got %d", vehicle.Mileage)
+	}
+	if vehicle.Condition != models.VehicleConditionUsed {
+		t.Errorf("Expected condition used, got %s", vehicle.Condition)
+	}
+	if vehicle.WarrantyExpirationDate == nil || vehicle.WarrantyExpirationDate.Format("2006-01-02") != "2027-03-31" {
+		t.Errorf("Expected warranty expiration 2027-03-31, got %v", vehicle.WarrantyExpirationDate)
+	}
+
+	record, err = source.Next()
+	row = session.parseRow(3, record, err)
+	if len(row.errors) > 0 || row.vehicle.Condition != models.VehicleConditionNew {
+		t.Errorf("Expected second row to map to a new vehicle, got %+v %v", row.vehicle, row.errors)
+	}
+}
+
+func TestApplyImportTransform(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform *models.ImportTransform
+		field     string
+		value     string
+		expected  string
+	}{
+		{"miles to km rounds whole fields", &models.ImportTransform{Type: "unit", From: "mi", To: "km"}, "mileage", "100", "161"},
+		{"l/100km to mpg", &models.ImportTransform{Type: "unit", From: "l_per_100km", To: "mpg"}, "mpg_city", "7.8", "30"},
+		{"unconvertible value passes through", &models.ImportTransform{Type: "unit", From: "km", To: "mi"}, "mileage", "lots", "lots"},
+		{"map is case insensitive", &models.ImportTransform{Type: "map", Values: map[string]string{"CPO": "certified_pre_owned"}}, "condition", "cpo", "certified_pre_owned"},
+		{"map prefers the exact key", &models.ImportTransform{Type: "map", Values: map[string]string{"n": "used", "N": "new", "New": "new"}}, "condition", "n", "used"},
+		{"map picks between keys by case in sorted order", &models.ImportTransform{Type: "map", Values: map[string]string{"Cpo": "used", "CPO": "certified_pre_owned"}}, "condition", "cpo", "certified_pre_owned"},
+		{"map default", &models.ImportTransform{Type: "map", Values: map[string]string{"N": "new"}, Default: "used"}, "condition", "Demo", "used"},
+		{"go layout date", &models.ImportTransform{Type: "date", Format: "02.01.2006"}, "warranty_expiration_date", "31.03.2027", "2027-03-31"},
+		{"token date", &models.ImportTransform{Type: "date", Format: "D MMM YYYY"}, "warranty_expiration_date", "5 Mar 2027", "2027-03-05"},
+		{"spreadsheet serial date", &models.ImportTransform{Type: "date", Format: "MM/DD/YYYY"}, "warranty_expiration_date", "46477", "2027-03-31"},
+		{"empty value untouched", &models.ImportTransform{Type: "map", Values: map[string]string{"N": "new"}, Default: "used"}, "condition", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyImportTransform(tt.transform, tt.field, tt.value); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateImportColumns_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []models.ImportColumnMapping
+	}{
+		{"empty", nil},
+		{"unknown field", []models.ImportColumnMapping{{Source: "Colour", Field: "colour"}}},
+		{"duplicate source", []models.ImportColumnMapping{{Source: "VIN", Field: "vin"}, {Source: " vin ", Field: "stock_number"}}},
+		{"duplicate field", []models.ImportColumnMapping{{Source: "VIN", Field: "vin"}, {Source: "Serial", Field: "vin"}}},
+		{"unit on text field", []models.ImportColumnMapping{{Source: "Make", Field: "make", Transform: &models.ImportTransform{Type: "unit", From: "km", To: "mi"}}}},
+		{"unknown unit", []models.ImportColumnMapping{{Source: "Odo", Field: "mileage", Transform: &models.ImportTransform{Type: "unit", From: "furlong", To: "mi"}}}},
+		{"date on numeric field", []models.ImportColumnMapping{{Source: "Odo", Field: "mileage", Transform: &models.ImportTransform{Type: "date", Format: "YYYY"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateImportColumns(tt.columns); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestSuggestImportMappings(t *testing.T) {
+	headers := []string{"Stock #", "VIN", "Ext Color", "Int Colour", "City MPG", "Hwy MPG", "Odometer (km)", "Model Year", "Dealer Notes", "Lot Manager"}
+	expected := map[string]string{
+		"Stock #":       "stock_number",
+		"VIN":           "vin",
+		"Ext Color":     "color_exterior",
+		"Int Colour":    "color_interior",
+		"City MPG":      "mpg_city",
+		"Hwy MPG":       "mpg_highway",
+		"Odometer (km)": "mileage",
+		"Model Year":    "year",
+		"Dealer Notes":  "description",
+		"Lot Manager":   "",
+	}
+
+	for _, suggestion := range suggestImportMappings(headers) {
+		if suggestion.Field != expected[suggestion.Source] {
+			t.Errorf("Expected %q to map to %q, got %q (score %.2f)", suggestion.Source, expected[suggestion.Source], suggestion.Field, suggestion.Score)
+		}
+		if suggestion.Source == "Odometer (km)" && (suggestion.Transform == nil || suggestion.Transform.From != "km") {
+			t.Errorf("Expected a km to mi transform for the odometer, got %+v", suggestion.Transform)
+		}
+	}
+}
+
+func TestBulkUploadVehicles_WithProfile(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+
+	body, _ := json.Marshal(models.CreateImportProfileRequest{
+		OrganizationID: org.ID,
+		Name:           "Dealer feed",
+		Columns:        dealerFeedColumns,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/import-profiles", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	CreateImportProfile(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var profile models.ImportProfile
+	json.NewDecoder(w.Body).Decode(&profile)
+
+	content, err := os.ReadFile(filepath.Join("testdata", "dealer_feed.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	req = newBulkUploadRequest(t, map[string]string{
+		"organization_id": org.ID,
+		"location_id":     loc.ID,
+		"profile_id":      profile.ID,
+	}, "dealer_feed.csv", string(content))
+	w = httptest.NewRecorder()
+
+	BulkUploadVehicles(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var vehicle models.Vehicle
+	db.First(&vehicle, "vin = ?", "1HGBH41JXMN109186")
+	if vehicle.Mileage != 15000 || vehicle.StockNumber != "A-100" {
+		t.Errorf("Expected mapped mileage and stock number, got %d and %q", vehicle.Mileage, vehicle.StockNumber)
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetImportProfiles lists import profiles, optionally filtered by organization_id
+func GetImportProfiles(w http.ResponseWriter, r *http.Request) {
+	query := database.DB.Order("name")
+	if organizationID := r.URL.Query().Get("organization_id"); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+
+	var profiles []models.ImportProfile
+	if err := query.Find(&profiles).Error; err != nil {
+		http.Error(w, "Failed to fetch import profiles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profiles)
+}
+
+func GetImportProfile(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var profile models.ImportProfile
+	if err := database.DB.First(&profile, "id = ?", id).Error; err != nil {
+		http.Error(w, "Import profile not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+func CreateImportProfile(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateImportProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validation
+	if req.OrganizationID == "" || strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Organization ID and name are required", http.StatusBadRequest)
+		return
+	}
+	if err := validateImportColumns(req.Columns); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var organization models.Organization
+	if err := database.DB.First(&organization, "id = ?", req.OrganizationID).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusBadRequest)
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.ImportProfile{}).Where("organization_id = ? AND name = ?", req.OrganizationID, req.Name).Count(&count)
+	if count > 0 {
+		http.Error(w, "An import profile with this name already exists", http.StatusConflict)
+		return
+	}
+
+	profile := models.ImportProfile{
+		OrganizationID: req.OrganizationID,
+		Name:           strings.TrimSpace(req.Name),
+		Columns:        models.ImportColumnMappings(req.Columns),
+	}
+
+	if err := database.DB.Create(&profile).Error; err != nil {
+		http.Error(w, "Failed to create import profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(profile)
+}
+
+func UpdateImportProfile(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.UpdateImportProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if err := validateImportColumns(req.Columns); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var profile models.ImportProfile
+	if err := database.DB.First(&profile, "id = ?", id).Error; err != nil {
+		http.Error(w, "Import profile not found", http.StatusNotFound)
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.ImportProfile{}).
+		Where("organization_id = ? AND name = ? AND id <> ?", profile.OrganizationID, req.Name, id).
+		Count(&count)
+	if count > 0 {
+		http.Error(w, "An import profile with this name already exists", http.StatusConflict)
+		return
+	}
+
+	// Update fields
+	profile.Name = strings.TrimSpace(req.Name)
+	profile.Columns = models.ImportColumnMappings(req.Columns)
+
+	if err := database.DB.Save(&profile).Error; err != nil {
+		http.Error(w, "Failed to update import profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+func DeleteImportProfile(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	result := database.DB.Delete(&models.ImportProfile{}, "id = ?", id)
+	if result.Error != nil {
+		http.Error(w, "Failed to delete import profile", http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		http.Error(w, "Import profile not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SuggestImportMappings proposes a vehicle field for each header of a feed. Headers come from
+// a JSON body ({"headers": [...]}) or from the header row of an uploaded file, which accepts
+// the same file, format and sheet form values as BulkUploadVehicles.
+func SuggestImportMappings(w http.ResponseWriter, r *http.Request) {
+	var headers []string
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			http.Error(w, "Failed to parse form", http.StatusBadRequest)
+			return
+		}
+
+		file, fileHeader, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Failed to read file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		buffered := bufio.NewReader(file)
+		head, _ := buffered.Peek(512)
+		format, err := detectImportFormat(r.FormValue("format"), fileHeader.Filename, head)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		source, err := newImportSource(buffered, format, r.FormValue("sheet"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer source.Close()
+		headers = source.Headers()
+	} else {
+		var req struct {
+			Headers []string `json:"headers"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		headers = req.Headers
+	}
+
+	if len(headers) == 0 {
+		http.Error(w, "headers are required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestImportMappings(headers))
+}
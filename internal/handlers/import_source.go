@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Import file formats
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatXLSX  = "xlsx"
+	ImportFormatJSONL = "jsonl"
+)
+
+// importSource yields the rows of an uploaded inventory file as records aligned with its
+// headers, so every format shares the same column mapping and validation.
+// Next returns io.EOF after the last row; any other error rejects only that row.
+type importSource interface {
+	Headers() []string
+	Next() ([]string, error)
+	Close() error
+}
+
+// importRecordError explains why a single row could not be read
+type importRecordError string
+
+func (e importRecordError) Error() string {
+	return string(e)
+}
+
+// detectImportFormat resolves the format of an upload from the requested format, the file
+// extension or, failing both, the first bytes of the file
+func detectImportFormat(requested, filename string, head []byte) (string, error) {
+	switch strings.ToLower(requested) {
+	case "":
+	case ImportFormatCSV:
+		return ImportFormatCSV, nil
+	case ImportFormatXLSX:
+		return ImportFormatXLSX, nil
+	case ImportFormatJSONL, "ndjson":
+		return ImportFormatJSONL, nil
+	default:
+		return "", fmt.Errorf("format must be one of csv, xlsx or jsonl")
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ImportFormatCSV, nil
+	case ".xlsx":
+		return ImportFormatXLSX, nil
+	case ".jsonl", ".ndjson":
+		return ImportFormatJSONL, nil
+	case ".xls":
+		return "", fmt.Errorf("Legacy .xls workbooks are not supported; save the file as .xlsx")
+	}
+
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return ImportFormatXLSX, nil
+	}
+	if trimmed := bytes.TrimLeft(head, " \t\r\n\uFEFF"); len(trimmed) > 0 && trimmed[0] == '{' {
+		return ImportFormatJSONL, nil
+	}
+	return ImportFormatCSV, nil
+}
+
+// newImportSource opens a reader of the given format. Sheet selects an XLSX worksheet by name;
+// the first sheet is used when it is empty.
+func newImportSource(r io.Reader, format, sheet string) (importSource, error) {
+	switch format {
+	case ImportFormatXLSX:
+		return newXLSXSource(r, sheet)
+	case ImportFormatJSONL:
+		return newJSONLSource(r)
+	default:
+		return newCSVSource(r)
+	}
+}
+
+type csvSource struct {
+	reader  *csv.Reader
+	headers []string
+}
+
+func newCSVSource(r io.Reader) (*csvSource, error) {
+	reader := csv.NewReader(r)
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("Failed to read CSV headers")
+	}
+	return &csvSource{reader: reader, headers: headers}, nil
+}
+
+func (s *csvSource) Headers() []string       { return s.headers }
+func (s *csvSource) Next() ([]string, error) { return s.reader.Read() }
+func (s *csvSource) Close() error            { return nil }
+
+// xlsxSource streams the rows of one worksheet. Raw cell values are used so number formats
+// such as currency do not leak into the data; cells formatted as dates, which hold a serial
+// day number, are given as YYYY-MM-DD instead.
+type xlsxSource struct {
+	file       *excelize.File
+	rows       *excelize.Rows
+	sheet      string
+	row        int
+	date1904   bool
+	dateStyles map[int]bool
+	headers    []string
+}
+
+func newXLSXSource(r io.Reader, sheet string) (*xlsxSource, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, errors.New("Failed to read XLSX workbook")
+	}
+
+	if sheet == "" {
+		sheet = file.GetSheetName(0)
+	} else if index, _ := file.GetSheetIndex(sheet); index < 0 {
+		file.Close()
+		return nil, fmt.Errorf("Sheet not found: %s", sheet)
+	}
+
+	rows, err := file.Rows(sheet)
+	if err != nil {
+		file.Close()
+		return nil, errors.New("Failed to read XLSX workbook")
+	}
+
+	source := &xlsxSource{file: file, rows: rows, sheet: sheet, dateStyles: make(map[int]bool)}
+	if props, err := file.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+		source.date1904 = *props.Date1904
+	}
+	headers, err := source.Next()
+	if err != nil {
+		source.Close()
+		return nil, errors.New("Failed to read XLSX headers")
+	}
+	source.headers = headers
+	return source, nil
+}
+
+func (s *xlsxSource) Headers() []string { return s.headers }
+
+func (s *xlsxSource) Next() ([]string, error) {
+	for s.rows.Next() {
+		s.row++
+		record, err := s.rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, err
+		}
+		// Skip blank rows, as the CSV reader skips blank lines
+		for _, cell := range record {
+			if strings.TrimSpace(cell) != "" {
+				return s.formatDates(record)
+			}
+		}
+	}
+	if err := s.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// formatDates replaces the serial day numbers of date-formatted cells in the current row
+func (s *xlsxSource) formatDates(record []string) ([]string, error) {
+	for i, cell := range record {
+		serial, err := strconv.ParseFloat(cell, 64)
+		if err != nil || serial < 0 {
+			continue
+		}
+		name, err := excelize.CoordinatesToCellName(i+1, s.row)
+		if err != nil {
+			return nil, err
+		}
+		styleID, err := s.file.GetCellStyle(s.sheet, name)
+		if err != nil {
+			return nil, err
+		}
+		if !s.isDateStyle(styleID) {
+			continue
+		}
+		if date, err := excelize.ExcelDateToTime(serial, s.date1904); err == nil {
+			record[i] = date.Format("2006-01-02")
+		}
+	}
+	return record, nil
+}
+
+// isDateStyle reports whether a cell style shows numbers as dates
+func (s *xlsxSource) isDateStyle(styleID int) bool {
+	if isDate, ok := s.dateStyles[styleID]; ok {
+		return isDate
+	}
+	isDate := false
+	if style, err := s.file.GetStyle(styleID); err == nil {
+		if style.CustomNumFmt != nil {
+			isDate = isDateNumFmt(*style.CustomNumFmt)
+		} else {
+			isDate = xlsxDateNumFmts[style.NumFmt]
+		}
+	}
+	s.dateStyles[styleID] = isDate
+	return isDate
+}
+
+// xlsxDateNumFmts are the built-in number formats that show a date, with or without a time
+var xlsxDateNumFmts = map[int]bool{
+	14: true, 15: true, 16: true, 17: true, 22: true,
+	27: true, 28: true, 29: true, 30: true, 31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+	50: true, 51: true, 52: true, 53: true, 54: true, 55: true, 56: true, 57: true, 58: true,
+}
+
+// isDateNumFmt reports whether a custom number format shows a year or a day, ignoring
+// quoted text, escaped characters and bracketed colors and locales
+func isDateNumFmt(format string) bool {
+	// Only the format for positive numbers matters
+	format, _, _ = strings.Cut(format, ";")
+	quoted, bracketed := false, false
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case quoted:
+			quoted = c != '"'
+		case bracketed:
+			bracketed = c != ']'
+		case c == '"':
+			quoted = true
+		case c == '[':
+			bracketed = true
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		case c == 'y' || c == 'Y' || c == 'd' || c == 'D':
+			return true
+		}
+	}
+	return false
+}
+
+func (s *xlsxSource) Close() error {
+	s.rows.Close()
+	return s.file.Close()
+}
+
+// jsonlSource reads one JSON object per line. The headers are every key found in the file,
+// in the order they first appear, so a key left out of the first object is still read from
+// the lines that have it. Arrays are joined with "|" so features can be given as a list.
+type jsonlSource struct {
+	lines   [][]byte
+	headers []string
+	columns map[string]int
+}
+
+func newJSONLSource(r io.Reader) (*jsonlSource, error) {
+	source := &jsonlSource{columns: make(map[string]int)}
+
+	// The whole file is read first, as an XLSX upload is, to gather the keys of every line
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, errors.New("Failed to read JSON Lines file")
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			source.lines = append(source.lines, line)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if len(source.lines) == 0 {
+		return nil, errors.New("Failed to read JSON Lines headers")
+	}
+
+	for i, line := range source.lines {
+		keys, err := objectKeys(line)
+		if err != nil {
+			// A broken line is rejected as a row when it is read; only a broken first line
+			// leaves nothing to go on
+			if i == 0 {
+				return nil, errors.New("Failed to read JSON Lines headers")
+			}
+			continue
+		}
+		for _, key := range keys {
+			if _, exists := source.columns[key]; !exists {
+				source.columns[key] = len(source.headers)
+				source.headers = append(source.headers, key)
+			}
+		}
+	}
+	return source, nil
+}
+
+func (s *jsonlSource) Headers() []string { return s.headers }
+
+func (s *jsonlSource) Next() ([]string, error) {
+	if len(s.lines) == 0 {
+		return nil, io.EOF
+	}
+	line := s.lines[0]
+	s.lines = s.lines[1:]
+	return s.record(line)
+}
+
+func (s *jsonlSource) Close() error { return nil }
+
+func (s *jsonlSource) record(line []byte) ([]string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(line, &object); err != nil {
+		return nil, importRecordError("Failed to parse row: line is not a JSON object")
+	}
+
+	record := make([]string, len(s.headers))
+	for key, raw := range object {
+		index, exists := s.columns[key]
+		if !exists {
+			return nil, importRecordError(fmt.Sprintf("Failed to parse row: unexpected field %q", key))
+		}
+		value, err := jsonCellValue(raw)
+		if err != nil {
+			return nil, importRecordError(fmt.Sprintf("Failed to parse row: field %q %s", key, err.Error()))
+		}
+		record[index] = value
+	}
+	return record, nil
+}
+
+// objectKeys returns the keys of a JSON object in document order
+func objectKeys(line []byte) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, token.(string))
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// jsonCellValue converts a JSON value into the text a CSV cell would hold
+func jsonCellValue(raw json.RawMessage) (string, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return "", errors.New("must be a list of strings")
+			}
+			items[i] = text
+		}
+		return strings.Join(items, "|"), nil
+	default:
+		return "", errors.New("must not be an object")
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// readFixture parses every row of a testdata file through the shared import path
+func readFixture(t *testing.T, name, sheet string) ([]*models.Vehicle, []RowError) {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer file.Close()
+
+	format, err := detectImportFormat("", name, nil)
+	if err != nil {
+		t.Fatalf("Failed to detect format: %v", err)
+	}
+
+	source, err := newImportSource(file, format, sheet)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", name, err)
+	}
+	defer source.Close()
+
+	session, err := newImportSession(source.Headers(), "org-id", "loc-id", BulkUploadModePartial, OnConflictError, false)
+	if err != nil {
+		t.Fatalf("Failed to start session: %v", err)
+	}
+
+	var vehicles []*models.Vehicle
+	var errs []RowError
+	line := 1
+	for {
+		record, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		line++
+		row := session.parseRow(line, record, err)
+		errs = append(errs, row.errors...)
+		if row.vehicle != nil {
+			vehicles = append(vehicles, row.vehicle)
+		}
+	}
+	return vehicles, errs
+}
+
+func TestImportSources_FormatsAgree(t *testing.T) {
+	expected, errs := readFixture(t, "vehicles.csv", "")
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors in CSV fixture, got %v", errs)
+	}
+	if len(expected) != 2 {
+		t.Fatalf("Expected 2 vehicles in CSV fixture, got %d", len(expected))
+	}
+	if expected[0].DailyRate.Amount != 4550 || len(expected[0].Features) != 2 {
+		t.Errorf("Unexpected CSV vehicle: %+v", expected[0])
+	}
+
+	tests := []struct {
+		name  string
+		file  string
+		sheet string
+	}{
+		{"xlsx named sheet", "vehicles.xlsx", "Inventory"},
+		{"json lines", "vehicles.jsonl", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vehicles, errs := readFixture(t, tt.file, tt.sheet)
+			if len(errs) > 0 {
+				t.Fatalf("Expected no errors, got %v", errs)
+			}
+			if !reflect.DeepEqual(vehicles, expected) {
+				t.Errorf("Expected vehicles to match the CSV fixture.\ngot:  %+v\nwant: %+v", vehicles, expected)
+			}
+		})
+	}
+}
+
+func TestImportSources_XLSXSheetSelection(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "vehicles.xlsx"))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := newImportSource(file, ImportFormatXLSX, "Missing"); err == nil {
+		t.Error("Expected an error for an unknown sheet")
+	}
+
+	file.Seek(0, io.SeekStart)
+	source, err := newImportSource(file, ImportFormatXLSX, "")
+	if err != nil {
+		t.Fatalf("Failed to open first sheet: %v", err)
+	}
+	defer source.Close()
+
+	// The first sheet holds notes, not inventory
+	if _, err := newImportSession(source.Headers(), "org-id", "loc-id", BulkUploadModePartial, OnConflictError, false); err == nil {
+		t.Error("Expected the first sheet to be missing required headers")
+	}
+}
+
+func TestDetectImportFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		filename  string
+		head      string
+		expected  string
+		wantErr   bool
+	}{
+		{"requested wins", "jsonl", "vehicles.csv", "", ImportFormatJSONL, false},
+		{"ndjson alias", "ndjson", "", "", ImportFormatJSONL, false},
+		{"xlsx extension", "", "Stock.XLSX", "", ImportFormatXLSX, false},
+		{"ndjson extension", "", "export.ndjson", "", ImportFormatJSONL, false},
+		{"zip contents", "", "upload", "PK\x03\x04rest", ImportFormatXLSX, false},
+		{"json contents", "", "upload", "\n  {\"vin\":\"1\"}", ImportFormatJSONL, false},
+		{"csv fallback", "", "upload", "vin,make", ImportFormatCSV, false},
+		{"legacy xls", "", "stock.xls", "", "", true},
+		{"unknown format", "xml", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := detectImportFormat(tt.requested, tt.filename, []byte(tt.head))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if format != tt.expected {
+				t.Errorf("Expected format %q, got %q", tt.expected, format)
+			}
+		})
+	}
+}
+
+func TestJSONLSource_HeadersFromEveryLine(t *testing.T) {
+	content := `{"vin":"1HGBH41JXMN109186","make":"Honda","model":"Accord","year":2022}
+
+{"vin":"1FTFW1ET8EFA12345","make":"Ford","model":"F-150","year":2023,"trim":"XLT"}`
+
+	source, err := newImportSource(strings.NewReader(content), ImportFormatJSONL, "")
+	if err != nil {
+		t.Fatalf("Failed to open source: %v", err)
+	}
+	headers := source.Headers()
+	if strings.Join(headers, ",") != "vin,make,model,year,trim" {
+		t.Fatalf("Expected the keys of every line as headers, got %v", headers)
+	}
+	session, _ := newImportSession(headers, "org-id", "loc-id", BulkUploadModePartial, OnConflictError, false)
+
+	record, err := source.Next()
+	if err != nil {
+		t.Fatalf("Failed to read first row: %v", err)
+	}
+	if len(record) != len(headers) || record[4] != "" {
+		t.Errorf("Expected a blank trim for the first row, got %q", record)
+	}
+
+	record, err = source.Next()
+	row := session.parseRow(3, record, err)
+	if len(row.errors) != 0 {
+		t.Fatalf("Expected the second row to parse, got %v", row.errors)
+	}
+	if row.vehicle.Trim != "XLT" {
+		t.Errorf("Expected trim XLT from a key the first line left out, got %q", row.vehicle.Trim)
+	}
+
+	if _, err := source.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF after the last row, got %v", err)
+	}
+}
+
+func TestBulkUploadVehicles_XLSX(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+
+	content, err := os.ReadFile(filepath.Join("testdata", "vehicles.xlsx"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	req := newBulkUploadRequest(t, map[string]string{
+		"organization_id": org.ID,
+		"location_id":     loc.ID,
+		"sheet":           "Inventory",
+	}, "vehicles.xlsx", string(content))
+	w := httptest.NewRecorder()
+
+	BulkUploadVehicles(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var result BulkUploadResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Created != 2 {
+		t.Errorf("Expected 2 vehicles created, got %d. Errors: %v", result.Created, result.Errors)
+	}
+}
+
+func TestXLSXSource_DateCells(t *testing.T) {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+	sheet := workbook.GetSheetName(0)
+	workbook.SetSheetRow(sheet, "A1", &[]interface{}{"vin", "mileage", "daily_rate", "warranty_expiration_date", "registered"})
+	workbook.SetSheetRow(sheet, "A2", &[]interface{}{"1HGBH41JXMN109186", 12000, 45.5, time.Date(2027, 3, 15, 0, 0, 0, 0, time.UTC), 46100})
+
+	currencyFormat, dateFormat := `"$"#,##0.00`, `[$-409]dd mmm yyyy`
+	currency, _ := workbook.NewStyle(&excelize.Style{CustomNumFmt: &currencyFormat})
+	workbook.SetCellStyle(sheet, "C2", "C2", currency)
+	custom, _ := workbook.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	workbook.SetCellStyle(sheet, "E2", "E2", custom)
+
+	var buf bytes.Buffer
+	if err := workbook.Write(&buf); err != nil {
+		t.Fatalf("Failed to write workbook: %v", err)
+	}
+
+	source, err := newImportSource(&buf, ImportFormatXLSX, "")
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+	defer source.Close()
+
+	record, err := source.Next()
+	if err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	want := []string{"1HGBH41JXMN109186", "12000", "45.5", "2027-03-15", "2026-03-19"}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("Expected %q, got %q", want, record)
+	}
+
+	for format, want := range map[string]bool{
+		"yyyy-mm-dd": true, "m/d/yy h:mm": true, `"Days: "0`: false, "h:mm:ss": false, "[Red]0.00": false, `0\d`: false,
+	} {
+		if got := isDateNumFmt(format); got != want {
+			t.Errorf("isDateNumFmt(%q) = %v, want %v", format, got, want)
+		}
+	}
+}
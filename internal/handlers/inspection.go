@@ -0,0 +1,509 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func validateInspection(inspection *models.Inspection) error {
+	switch inspection.Type {
+	case models.InspectionTypeCheckout, models.InspectionTypeCheckin:
+	default:
+		return fmt.Errorf("type must be checkout or checkin")
+	}
+	if inspection.Mileage < 0 {
+		return fmt.Errorf("mileage cannot be negative")
+	}
+	if inspection.FuelLevel < 0 || inspection.FuelLevel > 100 {
+		return fmt.Errorf("fuel_level must be a percentage between 0 and 100")
+	}
+	switch inspection.Cleanliness {
+	case models.InspectionCleanlinessClean, models.InspectionCleanlinessAcceptable, models.InspectionCleanlinessDirty:
+	default:
+		return fmt.Errorf("cleanliness must be clean, acceptable or dirty")
+	}
+
+	for _, damage := range inspection.Damages {
+		valid := false
+		for _, panel := range models.DamagePanels {
+			if damage.Panel == panel {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid damage panel: %s", damage.Panel)
+		}
+		if damage.Severity.Rank() == 0 {
+			return fmt.Errorf("damage severity must be minor, moderate or severe")
+		}
+	}
+	return nil
+}
+
+// lastCheckout finds the checkout a checkin closes: the vehicle's latest checkout before it,
+// on the same rental when one is given
+func lastCheckout(tx *gorm.DB, checkin *models.Inspection) (*models.Inspection, error) {
+	query := tx.Where("vehicle_id = ? AND type = ? AND inspected_at <= ?", checkin.VehicleID, models.InspectionTypeCheckout, checkin.InspectedAt)
+	if checkin.RentalID != nil {
+		query = query.Where("rental_id = ?", *checkin.RentalID)
+	}
+
+	var checkout models.Inspection
+	err := query.Order("inspected_at DESC").First(&checkout).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkout, nil
+}
+
+// checkWalkInCheckout refuses to hand out a vehicle without a rental when it is booked before
+// returnAt or is needed to cover its class's reservations at its location, writing the error
+func checkWalkInCheckout(w http.ResponseWriter, vehicle *models.Vehicle, pickupAt, returnAt time.Time) bool {
+	if !returnAt.After(pickupAt) {
+		http.Error(w, "return_at must be after the checkout", http.StatusBadRequest)
+		return false
+	}
+
+	booked, err := vehicleBooked(database.DB, vehicle.ID, pickupAt, returnAt, "")
+	if err != nil {
+		http.Error(w, "Failed to create inspection", http.StatusInternalServerError)
+		return false
+	}
+	if booked {
+		http.Error(w, "Vehicle is booked before it would be returned", http.StatusConflict)
+		return false
+	}
+
+	if vehicle.VehicleClassID == nil {
+		return true
+	}
+	var class models.VehicleClass
+	if err := database.DB.First(&class, "id = ?", *vehicle.VehicleClassID).Error; err != nil {
+		http.Error(w, "Failed to create inspection", http.StatusInternalServerError)
+		return false
+	}
+	counts, err := classAvailability(database.DB, &class, vehicle.LocationID, pickupAt, returnAt)
+	if err != nil {
+		http.Error(w, "Failed to create inspection", http.StatusInternalServerError)
+		return false
+	}
+	if counts.Available < 1 {
+		http.Error(w, fmt.Sprintf("Vehicle is needed for %s reservations before it would be returned", class.Code), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// GetVehicleInspections returns a vehicle's inspections, newest first
+func GetVehicleInspections(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	var inspections []models.Inspection
+	if err := database.DB.Where("vehicle_id = ?", id).Order("inspected_at DESC").Find(&inspections).Error; err != nil {
+		http.Error(w, "Failed to fetch inspections", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inspections)
+}
+
+// GetLocationInspections lists the inspections taken at a location's counter on one day,
+// newest first. date is YYYY-MM-DD in the location's time zone and defaults to today there;
+// type narrows to checkout or checkin.
+func GetLocationInspections(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	zone := location.Zone()
+	now := time.Now().In(zone)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, zone)
+	if param := r.URL.Query().Get("date"); param != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", param, zone)
+		if err != nil {
+			http.Error(w, "date must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		day = parsed
+	}
+
+	query := database.DB.Where("location_id = ? AND inspected_at >= ? AND inspected_at < ?", id, day, day.AddDate(0, 0, 1))
+	if inspectionType := r.URL.Query().Get("type"); inspectionType != "" {
+		query = query.Where("type = ?", inspectionType)
+	}
+
+	var inspections []models.Inspection
+	if err := query.Order("inspected_at DESC").Find(&inspections).Error; err != nil {
+		http.Error(w, "Failed to fetch inspections", http.StatusInternalServerError)
+		return
+	}
+	for i := range inspections {
+		inspections[i].InspectedAt = inspections[i].InspectedAt.In(zone)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inspections)
+}
+
+func GetInspection(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var inspection models.Inspection
+	if err := database.DB.First(&inspection, "id = ?", id).Error; err != nil {
+		http.Error(w, "Inspection not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inspection)
+}
+
+// CreateInspection records a checkout or checkin at the counter. A checkout needs an available,
+// service-eligible vehicle with no open maintenance and marks it rented; a checkin needs a
+// rented vehicle, is linked to the checkout it closes and makes the vehicle available again
+// at the location it was checked in at. With a rental_id the checkout picks up a reserved
+// rental, assigning the vehicle to a class reservation, and the checkin completes the rental,
+// flagging a return made outside the location's hours, raising the late, fuel and mileage
+// charges of the organization's return policy in the rental's currency and releasing its
+// deposit. A checkout without a rental must not take a vehicle booked, or needed for its
+// class's reservations, before return_at. The inspected mileage (the vehicle's current
+// mileage if omitted) is logged as an odometer reading.
+func CreateInspection(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateInspectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	inspection := models.Inspection{
+		OrganizationID: vehicle.OrganizationID,
+		LocationID:     vehicle.LocationID,
+		VehicleID:      vehicle.ID,
+		RentalID:       req.RentalID,
+		Type:           req.Type,
+		Mileage:        req.Mileage,
+		FuelLevel:      req.FuelLevel,
+		Cleanliness:    req.Cleanliness,
+		Damages:        models.Damages(req.Damages),
+		Notes:          req.Notes,
+		InspectedBy:    currentUserID(r),
+		InspectedAt:    time.Now(),
+	}
+	if inspection.Mileage == 0 {
+		inspection.Mileage = vehicle.Mileage
+	}
+	if inspection.Damages == nil {
+		inspection.Damages = models.Damages{}
+	}
+
+	if err := validateInspection(&inspection); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The counter taking the inspection may differ from the vehicle's home location
+	if req.LocationID != "" && req.LocationID != vehicle.LocationID {
+		var location models.Location
+		if err := database.DB.First(&location, "id = ?", req.LocationID).Error; err != nil {
+			http.Error(w, "Location not found", http.StatusBadRequest)
+			return
+		}
+		if location.OrganizationID != vehicle.OrganizationID {
+			http.Error(w, "Location does not belong to the vehicle's organization", http.StatusBadRequest)
+			return
+		}
+		inspection.LocationID = location.ID
+	}
+
+	// A return without rental_id closes the rental the vehicle is out on, so its return charges
+	// and deposit release are not skipped. Walk-in checkouts have no rental.
+	if inspection.Type == models.InspectionTypeCheckin && inspection.RentalID == nil {
+		var active models.Rental
+		err := database.DB.Where("vehicle_id = ? AND status = ?", vehicle.ID, models.RentalStatusActive).First(&active).Error
+		if err == nil {
+			inspection.RentalID = &active.ID
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Failed to create inspection", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// An inspection on a rental picks it up or returns it
+	var rental *models.Rental
+	if inspection.RentalID != nil {
+		rental = &models.Rental{}
+		if err := database.DB.First(rental, "id = ?", *inspection.RentalID).Error; err != nil {
+			http.Error(w, "Rental not found", http.StatusBadRequest)
+			return
+		}
+		if rental.VehicleID != nil && *rental.VehicleID != vehicle.ID {
+			http.Error(w, "Rental is for a different vehicle", http.StatusBadRequest)
+			return
+		}
+		if rental.VehicleID == nil && (rental.VehicleClassID == nil || vehicle.VehicleClassID == nil || *vehicle.VehicleClassID != *rental.VehicleClassID) {
+			http.Error(w, "Vehicle is not of the rental's class", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var policy models.ReturnPolicy
+	status := models.VehicleStatusRented
+	source := models.OdometerSourceCheckout
+	switch inspection.Type {
+	case models.InspectionTypeCheckout:
+		if vehicle.Status != models.VehicleStatusAvailable {
+			http.Error(w, fmt.Sprintf("Vehicle is %s and cannot be checked out", vehicle.Status), http.StatusConflict)
+			return
+		}
+		if !vehicle.IsEligibleForService {
+			http.Error(w, "Vehicle is not eligible for service", http.StatusConflict)
+			return
+		}
+		if err := checkVehicleRentable(database.DB, vehicle.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if rental != nil {
+			if rental.Status != models.RentalStatusReserved {
+				http.Error(w, fmt.Sprintf("Rental is %s and cannot be picked up", rental.Status), http.StatusConflict)
+				return
+			}
+			// A class reservation is given this vehicle, which must be at the pickup location and
+			// free until the return
+			if rental.VehicleID == nil {
+				if vehicle.LocationID != rental.PickupLocationID {
+					http.Error(w, "Vehicle is not at the rental's pickup location", http.StatusConflict)
+					return
+				}
+				booked, err := vehicleBooked(database.DB, vehicle.ID, inspection.InspectedAt, rental.ReturnAt, rental.ID)
+				if err != nil {
+					http.Error(w, "Failed to create inspection", http.StatusInternalServerError)
+					return
+				}
+				if booked {
+					http.Error(w, "Vehicle is booked before the rental's return", http.StatusConflict)
+					return
+				}
+				rental.VehicleID = &vehicle.ID
+			}
+			// Every driver's license must have been seen by staff before the keys are handed over
+			problems, err := rentalDriverProblems(rental, true)
+			if err != nil {
+				http.Error(w, "Failed to check drivers", http.StatusInternalServerError)
+				return
+			}
+			if len(problems) > 0 {
+				http.Error(w, "Drivers are not cleared to drive: "+strings.Join(problems, "; "), http.StatusConflict)
+				return
+			}
+			rental.Status = models.RentalStatusActive
+			rental.PickedUpAt = &inspection.InspectedAt
+		} else {
+			returnAt := inspection.InspectedAt.AddDate(0, 0, 1)
+			if req.ReturnAt != nil {
+				returnAt = *req.ReturnAt
+			}
+			if !checkWalkInCheckout(w, &vehicle, inspection.InspectedAt, returnAt) {
+				return
+			}
+		}
+	case models.InspectionTypeCheckin:
+		if vehicle.Status != models.VehicleStatusRented {
+			http.Error(w, "Vehicle is not checked out", http.StatusConflict)
+			return
+		}
+		status = models.VehicleStatusAvailable
+		source = models.OdometerSourceReturn
+		// A one-way return leaves the vehicle at the location it is checked in at, which must
+		// have room for it as a transfer's destination must
+		if inspection.LocationID != vehicle.LocationID {
+			var location models.Location
+			if err := database.DB.First(&location, "id = ?", inspection.LocationID).Error; err != nil {
+				http.Error(w, "Location not found", http.StatusBadRequest)
+				return
+			}
+			if !checkLocationCapacity(w, &location, 1, req.AllowOverCapacity) {
+				return
+			}
+		}
+		if rental != nil {
+			if rental.Status != models.RentalStatusActive {
+				http.Error(w, fmt.Sprintf("Rental is %s and cannot be returned", rental.Status), http.StatusConflict)
+				return
+			}
+			var location models.Location
+			if err := database.DB.First(&location, "id = ?", inspection.LocationID).Error; err != nil {
+				http.Error(w, "Location not found", http.StatusBadRequest)
+				return
+			}
+			holidays, err := locationHolidays(database.DB, location.ID, inspection.InspectedAt.In(location.Zone()))
+			if err != nil {
+				http.Error(w, "Failed to create inspection", http.StatusInternalServerError)
+				return
+			}
+			var org models.Organization
+			if err := database.DB.First(&org, "id = ?", rental.OrganizationID).Error; err != nil {
+				http.Error(w, "Organization not found", http.StatusBadRequest)
+				return
+			}
+			policy, err = convertReturnPolicy(database.DB, &org, rental.Currency, inspection.InspectedAt)
+			if errors.Is(err, errNoExchangeRate) {
+				http.Error(w, "Return policy fees cannot be converted: "+err.Error(), http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to create inspection", http.StatusInternalServerError)
+				return
+			}
+			rental.Status = models.RentalStatusCompleted
+			rental.ReturnedAt = &inspection.InspectedAt
+			rental.ReturnLocationID = location.ID
+			rental.AfterHoursReturn = !location.OpenAt(inspection.InspectedAt, holidays)
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var checkout *models.Inspection
+		if inspection.Type == models.InspectionTypeCheckin {
+			var err error
+			if checkout, err = lastCheckout(tx, &inspection); err != nil {
+				return err
+			}
+			if checkout != nil {
+				inspection.CheckoutID = &checkout.ID
+			}
+		}
+
+		if err := recordOdometerReading(tx, &vehicle, &models.OdometerReading{
+			Reading:    inspection.Mileage,
+			Source:     source,
+			ReadAt:     inspection.InspectedAt,
+			RecordedBy: inspection.InspectedBy,
+		}); err != nil {
+			return err
+		}
+		updates := map[string]interface{}{"status": status}
+		// A one-way return leaves the vehicle at the location it was checked in at, giving up
+		// any parking space it held at the old one
+		if inspection.Type == models.InspectionTypeCheckin && inspection.LocationID != vehicle.LocationID {
+			updates["location_id"] = inspection.LocationID
+			if err := releaseParkingSpace(tx, vehicle.ID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&vehicle).Updates(updates).Error; err != nil {
+			return err
+		}
+		if rental != nil {
+			// The rental was checked before the transaction; it must not have been picked up,
+			// returned or cancelled by another request since
+			from := models.RentalStatusActive
+			if inspection.Type == models.InspectionTypeCheckout {
+				from = models.RentalStatusReserved
+			}
+			if err := transitionRental(tx, rental, from); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&inspection).Error; err != nil {
+			return err
+		}
+
+		if rental != nil && rental.Status == models.RentalStatusCompleted {
+			inspection.Charges = returnCharges(policy, &vehicle, rental, checkout, &inspection)
+			if len(inspection.Charges) > 0 {
+				return tx.Create(&inspection.Charges).Error
+			}
+		}
+		return nil
+	})
+	var invalid *odometerError
+	if errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), invalid.status())
+		return
+	}
+	if errors.Is(err, errRentalChanged) {
+		http.Error(w, "Rental was changed by another request; reload it and try again", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create inspection", http.StatusInternalServerError)
+		return
+	}
+
+	// A returned rental no longer needs its security deposit held. The return stands even if
+	// the gateway fails; the hold can be voided from the rental's payments.
+	if rental != nil && rental.Status == models.RentalStatusCompleted {
+		releaseRentalHolds(r.Context(), rental.ID, []models.PaymentKind{models.PaymentKindDeposit},
+			"released at check-in", inspection.InspectedBy)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(inspection)
+}
+
+// GetInspectionComparison sets a checkin against the checkout before it, listing damage that
+// is new or worse, the miles driven and the fuel used
+func GetInspectionComparison(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var checkin models.Inspection
+	if err := database.DB.First(&checkin, "id = ?", id).Error; err != nil {
+		http.Error(w, "Inspection not found", http.StatusNotFound)
+		return
+	}
+
+	if checkin.Type != models.InspectionTypeCheckin {
+		http.Error(w, "Only checkins can be compared", http.StatusBadRequest)
+		return
+	}
+
+	comparison := models.InspectionComparison{Checkin: &checkin}
+	if checkin.CheckoutID != nil {
+		var checkout models.Inspection
+		if err := database.DB.First(&checkout, "id = ?", *checkin.CheckoutID).Error; err == nil {
+			comparison.Checkout = &checkout
+			milesDriven := checkin.Mileage - checkout.Mileage
+			fuelUsed := checkout.FuelLevel - checkin.FuelLevel
+			comparison.MilesDriven = &milesDriven
+			comparison.FuelUsed = &fuelUsed
+		}
+	}
+	comparison.NewDamage = checkin.NewDamage(comparison.Checkout)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
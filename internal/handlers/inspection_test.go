@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInspectionNewDamage(t *testing.T) {
+	checkout := &models.Inspection{Damages: models.Damages{
+		{Panel: "front_bumper", Severity: models.DamageSeverityMinor},
+		{Panel: "hood", Severity: models.DamageSeverityModerate},
+	}}
+	checkin := &models.Inspection{Damages: models.Damages{
+		{Panel: "front_bumper", Severity: models.DamageSeverityModerate},
+		{Panel: "hood", Severity: models.DamageSeverityMinor},
+		{Panel: "left_mirror", Severity: models.DamageSeverityMinor},
+	}}
+
+	newDamage := checkin.NewDamage(checkout)
+	if len(newDamage) != 2 || newDamage[0].Panel != "front_bumper" || newDamage[1].Panel != "left_mirror" {
+		t.Errorf("Expected worsened bumper and new mirror damage, got %+v", newDamage)
+	}
+
+	// Without a checkout everything counts as new
+	if got := len(checkin.NewDamage(nil)); got != 3 {
+		t.Errorf("Expected 3 new damages without a checkout, got %d", got)
+	}
+}
+
+func TestInspection_CheckoutAndCheckin(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	inspect := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/inspections", bytes.NewBufferString(body))
+		req = withURLParams(req, "id", vehicle.ID)
+		w := httptest.NewRecorder()
+		CreateInspection(w, req)
+		return w
+	}
+
+	w := inspect(`{"type":"checkout","mileage":12000,"fuel_level":100,"cleanliness":"clean","damages":[{"panel":"rear_bumper","severity":"minor"}]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Status != models.VehicleStatusRented || vehicle.Mileage != 12000 {
+		t.Errorf("Expected a rented vehicle at 12000 mi, got %s at %d", vehicle.Status, vehicle.Mileage)
+	}
+
+	// A second checkout is refused
+	if w := inspect(`{"type":"checkout","fuel_level":100,"cleanliness":"clean"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = inspect(`{"type":"checkin","mileage":12450,"fuel_level":40,"cleanliness":"dirty","damages":[{"panel":"rear_bumper","severity":"minor"},{"panel":"windshield","severity":"moderate","photos":["inspections/windshield.jpg"]}]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var checkin models.Inspection
+	json.NewDecoder(w.Body).Decode(&checkin)
+	if checkin.CheckoutID == nil {
+		t.Fatal("Expected the checkin to be linked to the checkout")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/inspections/"+checkin.ID+"/comparison", nil)
+	req = withURLParams(req, "id", checkin.ID)
+	w = httptest.NewRecorder()
+	GetInspectionComparison(w, req)
+
+	var comparison models.InspectionComparison
+	json.NewDecoder(w.Body).Decode(&comparison)
+	if len(comparison.NewDamage) != 1 || comparison.NewDamage[0].Panel != "windshield" {
+		t.Errorf("Expected new windshield damage, got %+v", comparison.NewDamage)
+	}
+	if comparison.MilesDriven == nil || *comparison.MilesDriven != 450 {
+		t.Errorf("Expected 450 miles driven, got %v", comparison.MilesDriven)
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Status != models.VehicleStatusAvailable {
+		t.Errorf("Expected vehicle available after checkin, got %s", vehicle.Status)
+	}
+}
+
+func TestInspection_OneWayReturnMovesVehicle(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	pickup := testutil.CreateTestLocation(t, db, org.ID, "Airport", "San Francisco")
+	dropoff := testutil.CreateTestLocation(t, db, org.ID, "Downtown", "Oakland")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, pickup.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	space := models.ParkingSpace{LocationID: pickup.ID, Name: "A1", VehicleID: &vehicle.ID}
+	db.Create(&space)
+
+	inspect := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/inspections", bytes.NewBufferString(body))
+		req = withURLParams(req, "id", vehicle.ID)
+		w := httptest.NewRecorder()
+		CreateInspection(w, req)
+		return w
+	}
+
+	if w := inspect(`{"type":"checkout","mileage":12000,"fuel_level":100,"cleanliness":"clean"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// The return location is full, so the return goes ahead only when told to
+	db.Model(dropoff).Update("capacity", 1)
+	testutil.CreateTestVehicle(t, db, org.ID, dropoff.ID, "2HGBH41JXMN109187", "Honda", "Civic", 2021)
+	checkin := `{"type":"checkin","location_id":"` + dropoff.ID + `","mileage":12080,"fuel_level":90,"cleanliness":"clean"`
+	if w := inspect(checkin + `}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a return to a full location, got %d", http.StatusConflict, w.Code)
+	}
+	w := inspect(checkin + `,"allow_over_capacity":true}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Warning") == "" {
+		t.Error("Expected a warning for a return over capacity")
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.LocationID != dropoff.ID {
+		t.Errorf("Expected the vehicle to be at the return location, got %s", vehicle.LocationID)
+	}
+	db.First(&space, "id = ?", space.ID)
+	if space.VehicleID != nil {
+		t.Error("Expected the vehicle's parking space at the pickup location to be released")
+	}
+}
+
+func TestInspection_CheckinFindsActiveRental(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Model(vehicle).Update("status", models.VehicleStatusRented)
+
+	pickupAt := time.Now().UTC().AddDate(0, 0, -2)
+	rental := models.Rental{
+		OrganizationID:   org.ID,
+		VehicleID:        &vehicle.ID,
+		PickupLocationID: loc.ID,
+		ReturnLocationID: loc.ID,
+		Status:           models.RentalStatusActive,
+		PickupAt:         pickupAt,
+		ReturnAt:         pickupAt.AddDate(0, 0, 3),
+		PickedUpAt:       &pickupAt,
+	}
+	db.Create(&rental)
+
+	// The return names no rental, but the one the vehicle is out on is closed
+	body := bytes.NewBufferString(`{"type":"checkin","mileage":12080,"fuel_level":90,"cleanliness":"clean"}`)
+	req := withURLParams(httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/inspections", body), "id", vehicle.ID)
+	w := httptest.NewRecorder()
+	CreateInspection(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	db.First(&rental, "id = ?", rental.ID)
+	if rental.Status != models.RentalStatusCompleted || rental.ReturnedAt == nil {
+		t.Errorf("Expected the active rental to be returned, got %s", rental.Status)
+	}
+}
@@ -0,0 +1,774 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/pdf"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func validateTaxRates(taxes []models.TaxRate) error {
+	for _, tax := range taxes {
+		if strings.TrimSpace(tax.Name) == "" {
+			return fmt.Errorf("every tax needs a name")
+		}
+		if tax.Rate < 0 || tax.Rate > 100 {
+			return fmt.Errorf("tax rate for %s must be between 0 and 100", tax.Name)
+		}
+	}
+	return nil
+}
+
+func validateBranding(branding *models.OrganizationBranding) error {
+	if branding.BrandColor != "" {
+		if _, err := pdf.ParseHexColor(branding.BrandColor); err != nil {
+			return fmt.Errorf("brand_color must be a hex color such as #1F6FEB")
+		}
+	}
+	if len(branding.InvoicePrefix) > 10 {
+		return fmt.Errorf("invoice_prefix cannot be longer than 10 characters")
+	}
+	return nil
+}
+
+// rentalDays is the number of started 24-hour periods between pickup and return, at least one
+func rentalDays(pickupAt, returnAt time.Time) int {
+	days := int(math.Ceil(returnAt.Sub(pickupAt).Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+	return days
+}
+
+// vehicleDescription names a vehicle on invoice lines
+func vehicleDescription(vehicle *models.Vehicle) string {
+	return fmt.Sprintf("%d %s %s", vehicle.Year, vehicle.Make, vehicle.Model)
+}
+
+// bookingLines prices a booking's rental days at the rates and pricing rules in effect when it
+// is made. Without a vehicle the booking is for the class and is priced at the class's rates.
+func bookingLines(vehicle *models.Vehicle, class *models.VehicleClass, pickup *models.Location, bookedAt, pickupAt, returnAt time.Time) (models.InvoiceLines, error) {
+	var priced models.Vehicle
+	var description string
+	var err error
+	if vehicle != nil {
+		priced, err = vehicleRates(*vehicle, nil)
+		description = vehicleDescription(vehicle)
+	} else {
+		unassigned := models.Vehicle{OrganizationID: class.OrganizationID, DailyRate: models.Money{Currency: pickup.Currency}}
+		priced, err = vehicleRates(unassigned, &class.ID)
+		description = fmt.Sprintf("%s (%s) or similar", class.Description, class.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := rentalPricing(&priced, pickup, bookedAt, pickupAt, returnAt)
+	if err != nil {
+		return nil, err
+	}
+	return rentalQuote(description, pickup.Currency, &priced, days), nil
+}
+
+// ratePeriod is a run of days charged at one of a vehicle's rates: count periods at price
+// each, covering days days in all
+type ratePeriod struct {
+	name  string
+	count int
+	price float64
+	days  int
+}
+
+// ratePeriods breaks a booking into the vehicle's rates, using whole months and weeks where the
+// vehicle has those rates and a week instead of the remaining days when that is cheaper
+func ratePeriods(vehicle *models.Vehicle, days int) []ratePeriod {
+	daily, weekly, monthly := vehicle.DailyRate.Float(), vehicle.WeeklyRate.Float(), vehicle.MonthlyRate.Float()
+	periods := []ratePeriod{}
+	remaining := days
+	if monthly > 0 && remaining >= 30 {
+		periods = append(periods, ratePeriod{"monthly rate", remaining / 30, monthly, remaining / 30 * 30})
+		remaining %= 30
+	}
+	if weekly > 0 && remaining >= 7 {
+		periods = append(periods, ratePeriod{"weekly rate", remaining / 7, weekly, remaining / 7 * 7})
+		remaining %= 7
+	}
+	if remaining > 0 {
+		if weekly > 0 && float64(remaining)*daily > weekly {
+			periods = append(periods, ratePeriod{"weekly rate", 1, weekly, remaining})
+		} else {
+			periods = append(periods, ratePeriod{"daily rate", remaining, daily, remaining})
+		}
+	}
+	return periods
+}
+
+// dailyBaseRates spreads the price of each of a booking's rate periods evenly over its days,
+// giving every day its share in date order
+func dailyBaseRates(vehicle *models.Vehicle, days int) []float64 {
+	rates := make([]float64, 0, days)
+	for _, period := range ratePeriods(vehicle, days) {
+		share := float64(period.count) * period.price / float64(period.days)
+		for i := 0; i < period.days; i++ {
+			rates = append(rates, share)
+		}
+	}
+	return rates
+}
+
+// rentalQuote prices a booking in currency from its priced days, describing its lines by
+// description. The booking is charged at the vehicle's rates as ratePeriods breaks it up.
+// Pricing rules adjust each day's share of its period's price, and the adjustments follow as
+// lines of their own, with days under the same rules and adjustment on one line.
+func rentalQuote(description, currency string, vehicle *models.Vehicle, days []models.PricedDay) models.InvoiceLines {
+	lines := models.InvoiceLines{}
+	add := func(rate string, quantity int, price float64) {
+		lines = append(lines, models.InvoiceLine{
+			Kind:        "rental",
+			Description: description + ", " + rate,
+			Quantity:    float64(quantity),
+			UnitPrice:   price,
+			Amount:      models.RoundMoney(float64(quantity)*price, currency),
+			Taxable:     true,
+		})
+	}
+
+	for _, period := range ratePeriods(vehicle, len(days)) {
+		add(period.name, period.count, period.price)
+	}
+
+	type adjustment struct {
+		rules  string
+		amount float64
+		days   int
+	}
+	var adjustments []adjustment
+	for _, day := range days {
+		amount := models.RoundMoney(day.Rate-day.BaseRate, currency)
+		if len(day.Rules) == 0 || amount == 0 {
+			continue
+		}
+		rules := strings.Join(day.Rules, ", ")
+		found := false
+		for i := range adjustments {
+			if adjustments[i].rules == rules && adjustments[i].amount == amount {
+				adjustments[i].days++
+				found = true
+			}
+		}
+		if !found {
+			adjustments = append(adjustments, adjustment{rules: rules, amount: amount, days: 1})
+		}
+	}
+	for _, a := range adjustments {
+		add(a.rules+" adjustment", a.days, a.amount)
+	}
+	return lines
+}
+
+// invoiceTaxes applies each tax to the taxable lines, rounding to the currency's minor unit
+func invoiceTaxes(lines models.InvoiceLines, rates models.TaxRates, currency string) (models.InvoiceTaxes, float64) {
+	var taxable float64
+	for _, line := range lines {
+		if line.Taxable {
+			taxable += line.Amount
+		}
+	}
+	taxable = models.RoundMoney(taxable, currency)
+
+	taxes := models.InvoiceTaxes{}
+	var total float64
+	for _, rate := range rates {
+		amount := models.RoundMoney(taxable*rate.Rate/100, currency)
+		taxes = append(taxes, models.InvoiceTax{Name: rate.Name, Rate: rate.Rate, TaxableAmount: taxable, Amount: amount})
+		total += amount
+	}
+	return taxes, models.RoundMoney(total, currency)
+}
+
+// rentalInvoiced reports whether the rental already has an invoice, after which its charges
+// are fixed
+func rentalInvoiced(rentalID string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Invoice{}).Where("rental_id = ?", rentalID).Count(&count).Error
+	return count > 0, err
+}
+
+func GetRentalCharges(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+
+	var charges []models.RentalCharge
+	if err := database.DB.Where("rental_id = ?", id).Order("created_at").Find(&charges).Error; err != nil {
+		http.Error(w, "Failed to fetch charges", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(charges)
+}
+
+// CreateRentalCharge adds an extra such as fuel, a late return or damage to a rental that has
+// not been invoiced
+func CreateRentalCharge(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can add rental charges", http.StatusForbidden)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateRentalChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	valid := false
+	for _, kind := range models.RentalCharges {
+		valid = valid || req.Kind == kind
+	}
+	if !valid {
+		http.Error(w, fmt.Sprintf("invalid kind: %s", req.Kind), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Description) == "" {
+		http.Error(w, "description is required", http.StatusBadRequest)
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 || req.UnitPrice < 0 {
+		http.Error(w, "quantity and unit_price cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+	if rental.Status == models.RentalStatusCancelled {
+		http.Error(w, "Rental is cancelled", http.StatusConflict)
+		return
+	}
+	invoiced, err := rentalInvoiced(id)
+	if err != nil {
+		http.Error(w, "Failed to create charge", http.StatusInternalServerError)
+		return
+	}
+	if invoiced {
+		http.Error(w, "Rental has already been invoiced", http.StatusConflict)
+		return
+	}
+
+	charge := models.RentalCharge{
+		RentalID:    id,
+		Kind:        req.Kind,
+		Description: strings.TrimSpace(req.Description),
+		Quantity:    req.Quantity,
+		UnitPrice:   models.RoundMoney(req.UnitPrice, rental.Currency),
+		Amount:      models.RoundMoney(req.Quantity*req.UnitPrice, rental.Currency),
+		Taxable:     req.Taxable == nil || *req.Taxable,
+		CreatedBy:   currentUserID(r),
+	}
+
+	if err := database.DB.Create(&charge).Error; err != nil {
+		http.Error(w, "Failed to create charge", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(charge)
+}
+
+// DeleteRentalCharge removes an extra from a rental that has not been invoiced
+func DeleteRentalCharge(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can remove rental charges", http.StatusForbidden)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	invoiced, err := rentalInvoiced(id)
+	if err != nil {
+		http.Error(w, "Failed to delete charge", http.StatusInternalServerError)
+		return
+	}
+	if invoiced {
+		http.Error(w, "Rental has already been invoiced", http.StatusConflict)
+		return
+	}
+
+	result := database.DB.Where("id = ? AND rental_id = ?", chi.URLParam(r, "chargeId"), id).Delete(&models.RentalCharge{})
+	if result.Error != nil {
+		http.Error(w, "Failed to delete charge", http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		http.Error(w, "Charge not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// buildInvoice prices a completed rental: its quote, its extra charges and the pickup
+// location's taxes, less what has been paid, billed to the customer
+func buildInvoice(rental *models.Rental) (*models.Invoice, error) {
+	var pickup models.Location
+	if err := database.DB.Unscoped().First(&pickup, "id = ?", rental.PickupLocationID).Error; err != nil {
+		return nil, err
+	}
+
+	invoice := &models.Invoice{
+		OrganizationID: rental.OrganizationID,
+		RentalID:       rental.ID,
+		CustomerID:     rental.CustomerID,
+		Currency:       rental.Currency,
+		Lines:          append(models.InvoiceLines{}, rental.PricedLines...),
+	}
+
+	// Rentals booked before bookings were priced up front are priced as they stand now
+	if len(invoice.Lines) == 0 {
+		var vehicle models.Vehicle
+		if err := database.DB.Unscoped().First(&vehicle, "id = ?", rental.VehicleID).Error; err != nil {
+			return nil, err
+		}
+		// A class reservation is charged at the class's rates, whichever vehicle went out
+		priced, err := vehicleRates(vehicle, rental.VehicleClassID)
+		if err != nil {
+			return nil, err
+		}
+		days, err := rentalPricing(&priced, &pickup, rental.CreatedAt, rental.PickupAt, rental.ReturnAt)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Lines = rentalQuote(vehicleDescription(&vehicle), rental.Currency, &priced, days)
+	}
+
+	var charges []models.RentalCharge
+	if err := database.DB.Where("rental_id = ?", rental.ID).Order("created_at").Find(&charges).Error; err != nil {
+		return nil, err
+	}
+	for _, charge := range charges {
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			Kind:        string(charge.Kind),
+			Description: charge.Description,
+			Quantity:    charge.Quantity,
+			UnitPrice:   charge.UnitPrice,
+			Amount:      charge.Amount,
+			Taxable:     charge.Taxable,
+		})
+	}
+
+	invoice.Subtotal = models.Money{Currency: rental.Currency}
+	for _, line := range invoice.Lines {
+		invoice.Subtotal = invoice.Subtotal.Add(models.NewMoney(line.Amount, rental.Currency))
+	}
+	taxes, taxTotal := invoiceTaxes(invoice.Lines, pickup.Taxes, rental.Currency)
+	invoice.Taxes, invoice.TaxTotal = taxes, models.NewMoney(taxTotal, rental.Currency)
+	invoice.Total = invoice.Subtotal.Add(invoice.TaxTotal)
+
+	var paid []models.Payment
+	if err := database.DB.Where("rental_id = ?", rental.ID).Find(&paid).Error; err != nil {
+		return nil, err
+	}
+	invoice.AmountPaid = models.Money{Currency: rental.Currency}
+	for _, payment := range paid {
+		invoice.AmountPaid = invoice.AmountPaid.Add(payment.CapturedAmount.Sub(payment.RefundedAmount))
+	}
+	invoice.BalanceDue = invoice.Total.Sub(invoice.AmountPaid)
+
+	if rental.CustomerID != nil {
+		var customer models.User
+		if err := database.DB.First(&customer, "id = ?", *rental.CustomerID).Error; err == nil {
+			invoice.BillToName = strings.TrimSpace(customer.FirstName + " " + customer.LastName)
+			invoice.BillToEmail = customer.Email
+		}
+		var profile models.DriverProfile
+		if err := database.DB.First(&profile, "user_id = ?", *rental.CustomerID).Error; err == nil {
+			cityLine := strings.TrimSpace(strings.Join([]string{profile.City, profile.State, profile.ZipCode}, " "))
+			var address []string
+			for _, part := range []string{profile.AddressLine1, profile.AddressLine2, cityLine, profile.Country} {
+				if part != "" {
+					address = append(address, part)
+				}
+			}
+			invoice.BillToAddress = strings.Join(address, "\n")
+		}
+	}
+	return invoice, nil
+}
+
+// CreateInvoice issues the invoice for a completed rental, numbering it next in its
+// organization's sequence. A rental is invoiced once.
+func CreateInvoice(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can issue invoices", http.StatusForbidden)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+	if rental.Status != models.RentalStatusCompleted {
+		http.Error(w, fmt.Sprintf("Rental is %s; only completed rentals are invoiced", rental.Status), http.StatusConflict)
+		return
+	}
+	invoiced, err := rentalInvoiced(id)
+	if err != nil {
+		http.Error(w, "Failed to create invoice", http.StatusInternalServerError)
+		return
+	}
+	if invoiced {
+		http.Error(w, "Rental has already been invoiced", http.StatusConflict)
+		return
+	}
+
+	invoice, err := buildInvoice(&rental)
+	if err != nil {
+		http.Error(w, "Failed to create invoice", http.StatusInternalServerError)
+		return
+	}
+	invoice.IssuedAt = time.Now()
+	invoice.IssuedBy = currentUserID(r)
+
+	// Taking the number and saving the invoice together keeps the sequence free of gaps; the
+	// counter row stays locked until the invoice is in
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Organization{}).Where("id = ?", rental.OrganizationID).
+			UpdateColumn("last_invoice_number", gorm.Expr("last_invoice_number + 1")).Error; err != nil {
+			return err
+		}
+		var org models.Organization
+		if err := tx.First(&org, "id = ?", rental.OrganizationID).Error; err != nil {
+			return err
+		}
+		prefix := org.InvoicePrefix
+		if prefix == "" {
+			prefix = "INV"
+		}
+		invoice.Sequence = org.LastInvoiceNumber
+		invoice.Number = fmt.Sprintf("%s-%06d", prefix, org.LastInvoiceNumber)
+		return tx.Create(invoice).Error
+	})
+	if err != nil {
+		http.Error(w, "Failed to create invoice", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invoice)
+}
+
+// checkInvoiceAccess lets staff reach any invoice and a customer only their own, writing a 403
+// otherwise
+func checkInvoiceAccess(w http.ResponseWriter, r *http.Request, invoice *models.Invoice) bool {
+	if currentUserIsStaff(r) {
+		return true
+	}
+	if userID := currentUserID(r); userID != nil && invoice.CustomerID != nil && *invoice.CustomerID == *userID {
+		return true
+	}
+	http.Error(w, "You can only view your own invoices", http.StatusForbidden)
+	return false
+}
+
+func GetRentalInvoice(w http.ResponseWriter, r *http.Request) {
+	var invoice models.Invoice
+	if err := database.DB.First(&invoice, "rental_id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Invoice not found", http.StatusNotFound)
+		return
+	}
+	if !checkInvoiceAccess(w, r, &invoice) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invoice)
+}
+
+// GetInvoices lists invoices newest first, filtered by organization_id and customer_id
+func GetInvoices(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can list invoices", http.StatusForbidden)
+		return
+	}
+
+	query := database.DB.Order("issued_at DESC")
+	for _, column := range []string{"organization_id", "customer_id"} {
+		if value := r.URL.Query().Get(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	var invoices []models.Invoice
+	if err := query.Find(&invoices).Error; err != nil {
+		http.Error(w, "Failed to fetch invoices", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invoices)
+}
+
+// findInvoice loads the invoice named in the URL and its organization, writing a 404 if the
+// invoice is missing and a 403 if it is not the caller's to see
+func findInvoice(w http.ResponseWriter, r *http.Request) (*models.Invoice, *models.Organization, bool) {
+	var invoice models.Invoice
+	if err := database.DB.First(&invoice, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Invoice not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	if !checkInvoiceAccess(w, r, &invoice) {
+		return nil, nil, false
+	}
+
+	var org models.Organization
+	if err := database.DB.Unscoped().First(&org, "id = ?", invoice.OrganizationID).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return &invoice, &org, true
+}
+
+func GetInvoice(w http.ResponseWriter, r *http.Request) {
+	invoice, _, ok := findInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invoice)
+}
+
+// GetInvoicePDF renders an invoice as a PDF under its organization's branding
+func GetInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	invoice, org, ok := findInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", invoice.Number+".pdf"))
+	w.Write(pdf.RenderInvoice(invoice, org))
+}
+
+// EmailInvoice sends the invoice PDF to the given address or the bill-to email. Customers can
+// only send their invoices to their own email on file.
+func EmailInvoice(w http.ResponseWriter, r *http.Request) {
+	var req models.EmailInvoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	invoice, org, ok := findInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	to := strings.TrimSpace(req.To)
+	if !currentUserIsStaff(r) {
+		var customer models.User
+		if err := database.DB.First(&customer, "id = ?", *invoice.CustomerID).Error; err != nil {
+			http.Error(w, "Customer not found", http.StatusBadRequest)
+			return
+		}
+		if to != "" && !strings.EqualFold(to, customer.Email) {
+			http.Error(w, "Invoices can only be sent to your own email", http.StatusForbidden)
+			return
+		}
+		to = customer.Email
+	}
+	if to == "" {
+		to = invoice.BillToEmail
+	}
+	if to == "" {
+		http.Error(w, "Invoice has no bill-to email; give one in to", http.StatusBadRequest)
+		return
+	}
+
+	if err := emailService.SendInvoiceEmail(to, org.Name, invoice.Number, pdf.RenderInvoice(invoice, org)); err != nil {
+		http.Error(w, "Failed to send invoice", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	invoice.EmailedAt = &now
+	invoice.EmailedTo = to
+	if err := database.DB.Model(invoice).Updates(map[string]interface{}{"emailed_at": now, "emailed_to": to}).Error; err != nil {
+		http.Error(w, "Failed to update invoice", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invoice)
+}
+
+// tallyRevenue adds an invoice to the report under the location its rental was picked up at,
+// converting its total into the report's currency at rate
+func tallyRevenue(report *models.RevenueReport, invoice *models.Invoice, location *models.Location, rate float64) {
+	var revenue *models.LocationRevenue
+	for i := range report.Locations {
+		if report.Locations[i].LocationID == location.ID && report.Locations[i].Total.Currency == invoice.Currency {
+			revenue = &report.Locations[i]
+			break
+		}
+	}
+	if revenue == nil {
+		report.Locations = append(report.Locations, models.LocationRevenue{
+			LocationID:   location.ID,
+			LocationName: location.Name,
+			Subtotal:     models.Money{Currency: invoice.Currency},
+			TaxTotal:     models.Money{Currency: invoice.Currency},
+			Total:        models.Money{Currency: invoice.Currency},
+			Converted:    models.Money{Currency: report.Currency},
+		})
+		revenue = &report.Locations[len(report.Locations)-1]
+	}
+
+	converted := invoice.Total.Convert(report.Currency, rate)
+	revenue.Invoices++
+	revenue.Subtotal.Amount += invoice.Subtotal.Amount
+	revenue.TaxTotal.Amount += invoice.TaxTotal.Amount
+	revenue.Total.Amount += invoice.Total.Amount
+	revenue.Converted.Amount += converted.Amount
+	report.Total.Amount += converted.Amount
+}
+
+// GetRevenueReport totals the organization's invoices issued from from to to (YYYY-MM-DD in
+// UTC, the month to date by default) by pickup location and overall. Totals are converted into
+// currency, the organization's by default, at the exchange rate on each invoice's issue date.
+func GetRevenueReport(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var org models.Organization
+	if err := database.DB.First(&org, "id = ?", id).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for name, date := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := r.URL.Query().Get(name); value != "" {
+			parsed, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				http.Error(w, name+" must be in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+			*date = parsed
+		}
+	}
+	if to.Before(from) {
+		http.Error(w, "to cannot be before from", http.StatusBadRequest)
+		return
+	}
+
+	currency := org.Currency
+	if value := r.URL.Query().Get("currency"); value != "" {
+		if err := validateCurrency(&value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		currency = value
+	}
+
+	var invoices []models.Invoice
+	if err := database.DB.Where("organization_id = ? AND issued_at >= ? AND issued_at < ?", org.ID, from, to.AddDate(0, 0, 1)).
+		Order("issued_at").Find(&invoices).Error; err != nil {
+		http.Error(w, "Failed to fetch invoices", http.StatusInternalServerError)
+		return
+	}
+
+	rentalIDs := make([]string, len(invoices))
+	for i, invoice := range invoices {
+		rentalIDs[i] = invoice.RentalID
+	}
+	var rentals []models.Rental
+	if len(rentalIDs) > 0 {
+		if err := database.DB.Select("id", "pickup_location_id").Where("id IN ?", rentalIDs).
+			Find(&rentals).Error; err != nil {
+			http.Error(w, "Failed to fetch rentals", http.StatusInternalServerError)
+			return
+		}
+	}
+	pickupOf := make(map[string]string, len(rentals))
+	for _, rental := range rentals {
+		pickupOf[rental.ID] = rental.PickupLocationID
+	}
+
+	var locations []models.Location
+	if err := database.DB.Unscoped().Where("organization_id = ?", org.ID).Find(&locations).Error; err != nil {
+		http.Error(w, "Failed to fetch locations", http.StatusInternalServerError)
+		return
+	}
+	locationsByID := make(map[string]*models.Location, len(locations))
+	for i := range locations {
+		locationsByID[locations[i].ID] = &locations[i]
+	}
+
+	report := models.RevenueReport{
+		OrganizationID: org.ID,
+		From:           from.Format("2006-01-02"),
+		To:             to.Format("2006-01-02"),
+		Currency:       currency,
+		Locations:      []models.LocationRevenue{},
+		Total:          models.Money{Currency: currency},
+	}
+	rates := map[string]float64{}
+	for i := range invoices {
+		invoice := &invoices[i]
+		location, ok := locationsByID[pickupOf[invoice.RentalID]]
+		if !ok {
+			location = &models.Location{ID: pickupOf[invoice.RentalID]}
+		}
+
+		day := invoice.IssuedAt.UTC().Format("2006-01-02")
+		rate, ok := rates[invoice.Currency+day]
+		if !ok {
+			var err error
+			rate, err = findExchangeRate(database.DB, org.ID, invoice.Currency, currency, invoice.IssuedAt.UTC())
+			if errors.Is(err, errNoExchangeRate) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to fetch exchange rate", http.StatusInternalServerError)
+				return
+			}
+			rates[invoice.Currency+day] = rate
+		}
+		tallyRevenue(&report, invoice, location, rate)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
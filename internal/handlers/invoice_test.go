@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRentalQuote_UsesLongerRates(t *testing.T) {
+	vehicle := &models.Vehicle{Make: "Honda", Model: "Accord", Year: 2022, DailyRate: models.NewMoney(50, "USD"), WeeklyRate: models.NewMoney(280, "USD"), MonthlyRate: models.NewMoney(1000, "USD")}
+	pickupAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		returnAt time.Time
+		total    float64
+	}{
+		{"part of a day", pickupAt.Add(3 * time.Hour), 50},
+		{"started day", pickupAt.Add(49 * time.Hour), 150},
+		{"week is cheaper than six days", pickupAt.AddDate(0, 0, 6), 280},
+		{"month, week and day", pickupAt.AddDate(0, 0, 38), 1330},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var total float64
+			days := priceDays(vehicle, &models.Location{}, nil, nil, pickupAt, pickupAt, rentalDays(pickupAt, tt.returnAt))
+			for _, line := range rentalQuote("2022 Honda Accord", "USD", vehicle, days) {
+				total += line.Amount
+			}
+			if total != tt.total {
+				t.Errorf("Expected %.2f, got %.2f", tt.total, total)
+			}
+		})
+	}
+}
+
+func TestInvoiceTaxes_OnlyTaxableLines(t *testing.T) {
+	lines := models.InvoiceLines{
+		{Amount: 100, Taxable: true},
+		{Amount: 40, Taxable: false},
+		{Amount: 0.35, Taxable: true},
+	}
+	taxes, total := invoiceTaxes(lines, models.TaxRates{{Name: "State", Rate: 6.25}, {Name: "Airport", Rate: 10}}, "USD")
+
+	if len(taxes) != 2 || taxes[0].TaxableAmount != 100.35 {
+		t.Fatalf("Expected two taxes on 100.35, got %+v", taxes)
+	}
+	if taxes[0].Amount != 6.27 || taxes[1].Amount != 10.04 || total != 16.31 {
+		t.Errorf("Unexpected tax amounts %+v, total %.2f", taxes, total)
+	}
+}
+
+func TestCreateInvoice_SequentialNumbers(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	db.Model(org).Update("invoice_prefix", "TST")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	db.Model(loc).Update("taxes", models.TaxRates{{Name: "Sales tax", Rate: 10}})
+
+	invoice := func(vin string) (*httptest.ResponseRecorder, models.Rental) {
+		vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, vin, "Honda", "Accord", 2022)
+		db.Model(vehicle).Updates(models.Vehicle{DailyRate: models.NewMoney(50, "USD")})
+		pickupAt := time.Now().UTC().AddDate(0, 0, -3)
+		rental := models.Rental{
+			OrganizationID:   org.ID,
+			VehicleID:        &vehicle.ID,
+			PickupLocationID: loc.ID,
+			ReturnLocationID: loc.ID,
+			Status:           models.RentalStatusCompleted,
+			PickupAt:         pickupAt,
+			ReturnAt:         pickupAt.AddDate(0, 0, 2),
+		}
+		db.Create(&rental)
+
+		req := withURLParams(httptest.NewRequest(http.MethodPost, "/api/rentals/"+rental.ID+"/invoice", nil), "id", rental.ID)
+		req = withRoles(t, req, models.RoleStaff)
+		w := httptest.NewRecorder()
+		CreateInvoice(w, req)
+		return w, rental
+	}
+
+	// Invoices are staff business
+	w := httptest.NewRecorder()
+	GetInvoices(w, httptest.NewRequest(http.MethodGet, "/api/invoices", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d listing invoices without a staff role, got %d", http.StatusForbidden, w.Code)
+	}
+
+	w, rental := invoice("1HGBH41JXMN109186")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var first models.Invoice
+	json.NewDecoder(w.Body).Decode(&first)
+	if first.Number != "TST-000001" || first.Subtotal.Amount != 10000 || first.TaxTotal.Amount != 1000 || first.BalanceDue.Amount != 11000 {
+		t.Errorf("Unexpected invoice %s: subtotal %+v, tax %+v, balance %+v", first.Number, first.Subtotal, first.TaxTotal, first.BalanceDue)
+	}
+
+	// Invoiced rentals are closed to new charges and to a second invoice
+	body, _ := json.Marshal(models.CreateRentalChargeRequest{Kind: models.RentalChargeFuel, Description: "Refuel", UnitPrice: 30})
+	req := withURLParams(httptest.NewRequest(http.MethodPost, "/api/rentals/"+rental.ID+"/charges", bytes.NewBuffer(body)), "id", rental.ID)
+	req = withRoles(t, req, models.RoleStaff)
+	w = httptest.NewRecorder()
+	CreateRentalCharge(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a charge on an invoiced rental, got %d", http.StatusConflict, w.Code)
+	}
+
+	req = withURLParams(httptest.NewRequest(http.MethodPost, "/api/rentals/"+rental.ID+"/invoice", nil), "id", rental.ID)
+	req = withRoles(t, req, models.RoleStaff)
+	w = httptest.NewRecorder()
+	CreateInvoice(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a second invoice, got %d", http.StatusConflict, w.Code)
+	}
+
+	w, _ = invoice("2HGBH41JXMN109187")
+	var second models.Invoice
+	json.NewDecoder(w.Body).Decode(&second)
+	if second.Number != "TST-000002" {
+		t.Errorf("Expected the next number TST-000002, got %s", second.Number)
+	}
+}
+
+func TestBuildInvoice_UsesBookedPrice(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Model(vehicle).Updates(models.Vehicle{DailyRate: models.NewMoney(50, "USD")})
+
+	pickupAt := time.Now().UTC().Add(time.Hour).Truncate(time.Minute)
+	body, _ := json.Marshal(models.CreateRentalRequest{VehicleID: vehicle.ID, PickupAt: pickupAt, ReturnAt: pickupAt.AddDate(0, 0, 2)})
+	req := httptest.NewRequest(http.MethodPost, "/api/rentals", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	CreateRental(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var rental models.Rental
+	json.NewDecoder(w.Body).Decode(&rental)
+
+	// A rate raised after booking does not reach the invoice
+	db.Model(vehicle).Updates(models.Vehicle{DailyRate: models.NewMoney(80, "USD")})
+	db.First(&rental, "id = ?", rental.ID)
+	invoice, err := buildInvoice(&rental)
+	if err != nil {
+		t.Fatalf("Failed to build invoice: %v", err)
+	}
+	if invoice.Subtotal.Amount != 10000 {
+		t.Errorf("Expected a subtotal of 100.00 for 2 days at the booked rate, got %+v", invoice.Subtotal)
+	}
+}
+
+func TestInvoice_CustomerAccess(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	customer := testutil.CreateTestUser(t, db, "customer@example.com", "password")
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password")
+
+	pickupAt := time.Now().UTC().AddDate(0, 0, -3)
+	rental := models.Rental{
+		OrganizationID: org.ID, VehicleID: &vehicle.ID, CustomerID: &customer.ID,
+		PickupLocationID: loc.ID, ReturnLocationID: loc.ID,
+		Status: models.RentalStatusCompleted, PickupAt: pickupAt, ReturnAt: pickupAt.AddDate(0, 0, 2),
+	}
+	db.Create(&rental)
+	invoice, err := buildInvoice(&rental)
+	if err != nil {
+		t.Fatalf("Failed to build invoice: %v", err)
+	}
+	invoice.Number, invoice.IssuedAt = "INV-000001", time.Now()
+	db.Create(invoice)
+
+	get := func(userID string) int {
+		req := withURLParams(httptest.NewRequest(http.MethodGet, "/api/invoices/"+invoice.ID, nil), "id", invoice.ID)
+		w := httptest.NewRecorder()
+		GetInvoice(w, withUser(t, req, userID))
+		return w.Code
+	}
+	if code := get(other.ID); code != http.StatusForbidden {
+		t.Errorf("Expected status %d for another customer's invoice, got %d", http.StatusForbidden, code)
+	}
+	if code := get(customer.ID); code != http.StatusOK {
+		t.Errorf("Expected status %d for the customer's own invoice, got %d", http.StatusOK, code)
+	}
+
+	// A customer cannot mail their invoice to someone else
+	req := withURLParams(httptest.NewRequest(http.MethodPost, "/api/invoices/"+invoice.ID+"/email",
+		bytes.NewBufferString(`{"to":"someone@example.com"}`)), "id", invoice.ID)
+	w := httptest.NewRecorder()
+	EmailInvoice(w, withUser(t, req, customer.ID))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d emailing another address, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fleetpass/internal/database"
 	"fleetpass/internal/models"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -47,6 +49,37 @@ func CreateLocation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.TimeZone == "" {
+		req.TimeZone = "UTC"
+	}
+	if err := validateLocationHours(req.TimeZone, req.OpeningHours); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCapacity(req.Capacity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateTaxRates(req.Taxes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Currency == "" {
+		var org models.Organization
+		if err := database.DB.First(&org, "id = ?", req.OrganizationID).Error; err != nil {
+			http.Error(w, "Organization not found", http.StatusBadRequest)
+			return
+		}
+		req.Currency = org.Currency
+	} else if err := validateCurrency(&req.Currency); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	location := models.Location{
 		OrganizationID: req.OrganizationID,
 		Name:           req.Name,
@@ -60,6 +93,17 @@ func CreateLocation(w http.ResponseWriter, r *http.Request) {
 		Email:          req.Email,
 		IsActive:       true,
 	}
+	location.TimeZone = req.TimeZone
+	location.OpeningHours = models.OpeningHours(req.OpeningHours)
+	location.AllowsKeyDrop = req.AllowsKeyDrop
+	location.Capacity = req.Capacity
+	location.Taxes = models.TaxRates(req.Taxes)
+	location.Currency = req.Currency
+	if req.Latitude != nil {
+		location.Latitude, location.Longitude = req.Latitude, req.Longitude
+	} else {
+		geocodeLocation(r.Context(), &location)
+	}
 
 	if err := database.DB.Create(&location).Error; err != nil {
 		http.Error(w, "Failed to create location", http.StatusInternalServerError)
@@ -71,6 +115,8 @@ func CreateLocation(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(location)
 }
 
+// UpdateLocation replaces a location. Omitted fields take their create defaults, including
+// UTC for the time zone and the organization's currency; PATCH changes only the fields given.
 func UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -86,6 +132,43 @@ func UpdateLocation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.TimeZone == "" {
+		req.TimeZone = "UTC"
+	}
+	if err := validateLocationHours(req.TimeZone, req.OpeningHours); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCapacity(req.Capacity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateTaxRates(req.Taxes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Currency == "" {
+		var org models.Organization
+		if err := database.DB.First(&org, "id = ?", location.OrganizationID).Error; err != nil {
+			http.Error(w, "Organization not found", http.StatusBadRequest)
+			return
+		}
+		req.Currency = org.Currency
+	} else if err := validateCurrency(&req.Currency); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Currency != location.Currency && !checkCurrencyChange(w, &location) {
+		return
+	}
+
+	addressChanged := location.AddressLine1 != req.AddressLine1 || location.City != req.City ||
+		location.State != req.State || location.ZipCode != req.ZipCode || location.Country != req.Country
+
 	// Update fields
 	location.Name = req.Name
 	location.AddressLine1 = req.AddressLine1
@@ -97,6 +180,17 @@ func UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	location.Phone = req.Phone
 	location.Email = req.Email
 	location.IsActive = req.IsActive
+	location.TimeZone = req.TimeZone
+	location.OpeningHours = models.OpeningHours(req.OpeningHours)
+	location.AllowsKeyDrop = req.AllowsKeyDrop
+	location.Capacity = req.Capacity
+	location.Taxes = models.TaxRates(req.Taxes)
+	location.Currency = req.Currency
+	if req.Latitude != nil {
+		location.Latitude, location.Longitude = req.Latitude, req.Longitude
+	} else if addressChanged || location.Latitude == nil {
+		geocodeLocation(r.Context(), &location)
+	}
 
 	if err := database.DB.Save(&location).Error; err != nil {
 		http.Error(w, "Failed to update location", http.StatusInternalServerError)
@@ -107,10 +201,111 @@ func UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(location)
 }
 
+// locationPatchFields lists the location fields that may be changed through PATCH
+var locationPatchFields = []string{
+	"name", "address_line1", "address_line2", "city", "state", "zip_code",
+	"country", "phone", "email", "is_active", "time_zone", "opening_hours",
+	"allows_key_drop", "latitude", "longitude", "capacity", "taxes", "currency",
+}
+
+// PatchLocation applies a JSON Merge Patch or JSON Patch to a location, updating only changed columns
+func PatchLocation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	var patched models.Location
+	changed, err := applyPatchRequest(r, location, &patched, locationPatchFields)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	if len(changed) > 0 {
+		for _, field := range changed {
+			if field == "name" && strings.TrimSpace(patched.Name) == "" {
+				http.Error(w, "name cannot be empty", http.StatusUnprocessableEntity)
+				return
+			}
+			if field == "time_zone" || field == "opening_hours" {
+				if err := validateLocationHours(patched.TimeZone, patched.OpeningHours); err != nil {
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+			}
+			if field == "capacity" {
+				if err := validateCapacity(patched.Capacity); err != nil {
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+			}
+			if field == "taxes" {
+				if err := validateTaxRates(patched.Taxes); err != nil {
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+			}
+			if field == "currency" {
+				if err := validateCurrency(&patched.Currency); err != nil {
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+				if patched.Currency != location.Currency && !checkCurrencyChange(w, &location) {
+					return
+				}
+			}
+		}
+
+		// A moved address is geocoded again unless coordinates were patched with it
+		if containsString(changed, "latitude") || containsString(changed, "longitude") {
+			if err := validateCoordinates(patched.Latitude, patched.Longitude); err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+		} else {
+			for _, field := range locationAddressFields {
+				if containsString(changed, field) {
+					geocodeLocation(r.Context(), &patched)
+					changed = append(changed, "latitude", "longitude")
+					break
+				}
+			}
+		}
+
+		if err := database.DB.Model(&location).Select(append(changed, "updated_at")).Updates(&patched).Error; err != nil {
+			http.Error(w, "Failed to update location", http.StatusInternalServerError)
+			return
+		}
+
+		if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+			http.Error(w, "Location not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
+
 func DeleteLocation(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	result := database.DB.Delete(&models.Location{}, "id = ?", id)
+	// Vehicles must be moved or deleted before their location can go
+	var vehicleCount int64
+	database.DB.Model(&models.Vehicle{}).Where("location_id = ?", id).Count(&vehicleCount)
+	if vehicleCount > 0 {
+		http.Error(w, "Location still has vehicles assigned", http.StatusConflict)
+		return
+	}
+
+	result := database.DB.Model(&models.Location{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"deleted_at": time.Now(),
+		"deleted_by": currentUserID(r),
+	})
 	if result.Error != nil {
 		http.Error(w, "Failed to delete location", http.StatusInternalServerError)
 		return
@@ -123,3 +318,30 @@ func DeleteLocation(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreLocation moves a soft-deleted location out of the trash
+func RestoreLocation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var location models.Location
+	if err := database.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&location).Error; err != nil {
+		http.Error(w, "Deleted location not found", http.StatusNotFound)
+		return
+	}
+
+	var org models.Organization
+	if err := database.DB.First(&org, "id = ?", location.OrganizationID).Error; err != nil {
+		http.Error(w, "Location's organization is deleted; restore it first", http.StatusConflict)
+		return
+	}
+
+	if err := restoreRecords(database.DB.Model(&models.Location{}).Where("id = ?", id)); err != nil {
+		http.Error(w, "Failed to restore location", http.StatusInternalServerError)
+		return
+	}
+
+	database.DB.First(&location, "id = ?", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
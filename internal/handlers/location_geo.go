@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/geocode"
+	"fleetpass/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+var geocoder geocode.Geocoder = geocode.NewZipCentroidGeocoder()
+
+// InitGeocoder sets the geocoder used to place locations
+func InitGeocoder(g geocode.Geocoder) {
+	geocoder = g
+}
+
+// locationAddressFields are the location fields that affect where it geocodes to
+var locationAddressFields = []string{"address_line1", "city", "state", "zip_code", "country"}
+
+func validateCoordinates(latitude, longitude *float64) error {
+	if (latitude == nil) != (longitude == nil) {
+		return fmt.Errorf("latitude and longitude must be given together")
+	}
+	if latitude != nil && (*latitude < -90 || *latitude > 90 || *longitude < -180 || *longitude > 180) {
+		return fmt.Errorf("latitude must be within ±90 and longitude within ±180")
+	}
+	return nil
+}
+
+// geocodeLocation places the location from its address. An address the geocoder cannot
+// place, or a failing geocoder, leaves the location's coordinates as they are; the ZIP
+// dataset is far from complete, so a miss says nothing about where the location is.
+func geocodeLocation(ctx context.Context, location *models.Location) {
+	coordinates, err := geocoder.Geocode(ctx, geocode.Address{
+		Line1:   location.AddressLine1,
+		City:    location.City,
+		State:   location.State,
+		ZipCode: location.ZipCode,
+		Country: location.Country,
+	})
+	if errors.Is(err, geocode.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to geocode location %s: %v", location.Name, err)
+		return
+	}
+	location.Latitude, location.Longitude = &coordinates.Latitude, &coordinates.Longitude
+}
+
+// GetNearbyLocations lists active locations within radius miles (default 25) of lat/lng,
+// nearest first, with how many vehicles each has available. organization_id narrows the search.
+func GetNearbyLocations(w http.ResponseWriter, r *http.Request) {
+	var origin geocode.Coordinates
+	radius := 25.0
+	for _, param := range []struct {
+		name     string
+		value    *float64
+		required bool
+	}{{"lat", &origin.Latitude, true}, {"lng", &origin.Longitude, true}, {"radius", &radius, false}} {
+		raw := r.URL.Query().Get(param.name)
+		if raw == "" {
+			if param.required {
+				http.Error(w, "lat and lng are required", http.StatusBadRequest)
+				return
+			}
+			continue
+		}
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s must be a number", param.name), http.StatusBadRequest)
+			return
+		}
+		*param.value = parsed
+	}
+	if err := validateCoordinates(&origin.Latitude, &origin.Longitude); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if radius <= 0 {
+		http.Error(w, "radius must be positive", http.StatusBadRequest)
+		return
+	}
+
+	query := database.DB.Where("is_active = ? AND latitude IS NOT NULL AND longitude IS NOT NULL", true)
+	if organizationID := r.URL.Query().Get("organization_id"); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+
+	var locations []models.Location
+	if err := query.Find(&locations).Error; err != nil {
+		http.Error(w, "Failed to fetch locations", http.StatusInternalServerError)
+		return
+	}
+
+	nearby := []models.NearbyLocation{}
+	var locationIDs []string
+	for _, location := range locations {
+		distance := geocode.DistanceMiles(origin, geocode.Coordinates{Latitude: *location.Latitude, Longitude: *location.Longitude})
+		if distance <= radius {
+			nearby = append(nearby, models.NearbyLocation{Location: location, DistanceMiles: distance})
+			locationIDs = append(locationIDs, location.ID)
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].DistanceMiles < nearby[j].DistanceMiles })
+
+	if len(locationIDs) > 0 {
+		var counts []struct {
+			LocationID string
+			Count      int64
+		}
+		if err := database.DB.Model(&models.Vehicle{}).Select("location_id, COUNT(*) AS count").
+			Where("location_id IN ? AND status = ? AND is_eligible_for_service = ?", locationIDs, models.VehicleStatusAvailable, true).
+			Group("location_id").Scan(&counts).Error; err != nil {
+			http.Error(w, "Failed to count vehicles", http.StatusInternalServerError)
+			return
+		}
+		available := make(map[string]int64, len(counts))
+		for _, count := range counts {
+			available[count.LocationID] = count.Count
+		}
+		for i := range nearby {
+			nearby[i].AvailableVehicles = available[nearby[i].ID]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(nearby)
+}
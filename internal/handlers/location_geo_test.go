@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/geocode"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// missingGeocoder finds no address, as the ZIP dataset does for uncovered ZIP codes
+type missingGeocoder struct{}
+
+func (missingGeocoder) Geocode(ctx context.Context, address geocode.Address) (geocode.Coordinates, error) {
+	return geocode.Coordinates{}, geocode.ErrNotFound
+}
+
+func TestGeocodeLocation_KeepsCoordinatesWhenNotFound(t *testing.T) {
+	InitGeocoder(missingGeocoder{})
+	defer InitGeocoder(geocode.NewZipCentroidGeocoder())
+
+	latitude, longitude := 37.7749, -122.4194
+	location := &models.Location{ZipCode: "99999", Latitude: &latitude, Longitude: &longitude}
+	geocodeLocation(context.Background(), location)
+	if location.Latitude == nil || *location.Latitude != latitude || location.Longitude == nil || *location.Longitude != longitude {
+		t.Errorf("Expected the coordinates to be kept, got %v, %v", location.Latitude, location.Longitude)
+	}
+}
+
+func TestGetNearbyLocations_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing lng", "lat=37.77"},
+		{"not a number", "lat=north&lng=-122.42"},
+		{"out of range", "lat=97.77&lng=-122.42"},
+		{"negative radius", "lat=37.77&lng=-122.42&radius=-5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			GetNearbyLocations(w, httptest.NewRequest(http.MethodGet, "/api/locations/nearby?"+tt.query, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+func TestGetNearbyLocations(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	place := func(name, city string, latitude, longitude float64) *models.Location {
+		location := testutil.CreateTestLocation(t, db, org.ID, name, city)
+		db.Model(location).Updates(map[string]interface{}{"latitude": latitude, "longitude": longitude})
+		return location
+	}
+	downtown := place("Downtown", "San Francisco", 37.7793, -122.4193)
+	oakland := place("Oakland", "Oakland", 37.8044, -122.2712)
+	place("Los Angeles", "Los Angeles", 34.0522, -118.2437)
+	closed := place("Closed", "San Francisco", 37.7750, -122.4183)
+	db.Model(closed).Update("is_active", false)
+
+	testutil.CreateTestVehicle(t, db, org.ID, downtown.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	rented := testutil.CreateTestVehicle(t, db, org.ID, downtown.ID, "2HGFC2F59KH542853", "Honda", "Civic", 2021)
+	db.Model(rented).Update("status", models.VehicleStatusRented)
+
+	w := httptest.NewRecorder()
+	GetNearbyLocations(w, httptest.NewRequest(http.MethodGet, "/api/locations/nearby?lat=37.7749&lng=-122.4194&radius=20", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var nearby []models.NearbyLocation
+	json.NewDecoder(w.Body).Decode(&nearby)
+	if len(nearby) != 2 || nearby[0].ID != downtown.ID || nearby[1].ID != oakland.ID {
+		t.Fatalf("Expected Downtown then Oakland, got %+v", nearby)
+	}
+	if nearby[0].AvailableVehicles != 1 || nearby[1].AvailableVehicles != 0 {
+		t.Errorf("Expected 1 and 0 available vehicles, got %d and %d", nearby[0].AvailableVehicles, nearby[1].AvailableVehicles)
+	}
+	if nearby[0].DistanceMiles > 1 || nearby[1].DistanceMiles < 5 || nearby[1].DistanceMiles > 15 {
+		t.Errorf("Unexpected distances %.1f and %.1f miles", nearby[0].DistanceMiles, nearby[1].DistanceMiles)
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func validateLocationHours(timeZone string, hours []models.OpeningPeriod) error {
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return fmt.Errorf("invalid time_zone: %s", timeZone)
+	}
+	return models.OpeningHours(hours).Validate()
+}
+
+// locationHolidays loads a location's holidays from the given day onwards, in date order
+func locationHolidays(db *gorm.DB, locationID string, from time.Time) ([]models.LocationHoliday, error) {
+	var holidays []models.LocationHoliday
+	err := db.Where("location_id = ? AND date >= ?", locationID, from.Format("2006-01-02")).
+		Order("date").Find(&holidays).Error
+	return holidays, err
+}
+
+// GetLocationHours returns a location's opening hours, time zone, current local time and
+// upcoming holidays
+func GetLocationHours(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now().In(location.Zone())
+	holidays, err := locationHolidays(database.DB, location.ID, now)
+	if err != nil {
+		http.Error(w, "Failed to fetch holidays", http.StatusInternalServerError)
+		return
+	}
+
+	hours := models.LocationHours{
+		LocationID:    location.ID,
+		TimeZone:      location.Zone().String(),
+		LocalTime:     now,
+		OpenNow:       location.OpenAt(now, holidays),
+		OpeningHours:  location.OpeningHours,
+		AllowsKeyDrop: location.AllowsKeyDrop,
+		Holidays:      holidays,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hours)
+}
+
+// GetLocationHolidays returns all of a location's holidays, in date order
+func GetLocationHolidays(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	var holidays []models.LocationHoliday
+	if err := database.DB.Where("location_id = ?", id).Order("date").Find(&holidays).Error; err != nil {
+		http.Error(w, "Failed to fetch holidays", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(holidays)
+}
+
+// CreateLocationHoliday closes a location for a day, given as YYYY-MM-DD in its time zone
+func CreateLocationHoliday(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateLocationHolidayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		http.Error(w, "date must be in YYYY-MM-DD format", http.StatusBadRequest)
+		return
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.LocationHoliday{}).Where("location_id = ? AND date = ?", id, req.Date).Count(&existing)
+	if existing > 0 {
+		http.Error(w, "Location already has a holiday on this date", http.StatusConflict)
+		return
+	}
+
+	holiday := models.LocationHoliday{
+		LocationID: location.ID,
+		Date:       date,
+		Name:       req.Name,
+	}
+
+	if err := database.DB.Create(&holiday).Error; err != nil {
+		http.Error(w, "Failed to create holiday", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(holiday)
+}
+
+func DeleteLocationHoliday(w http.ResponseWriter, r *http.Request) {
+	result := database.DB.Where("id = ? AND location_id = ?", chi.URLParam(r, "holidayId"), chi.URLParam(r, "id")).
+		Delete(&models.LocationHoliday{})
+	if result.Error != nil {
+		http.Error(w, "Failed to delete holiday", http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		http.Error(w, "Holiday not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+var errVehicleInMaintenance = errors.New("Vehicle has open maintenance records")
+
+// checkVehicleRentable returns errVehicleInMaintenance while the vehicle has open maintenance work
+func checkVehicleRentable(db *gorm.DB, vehicleID string) error {
+	var count int64
+	if err := db.Model(&models.MaintenanceRecord{}).
+		Where("vehicle_id = ? AND closed_at IS NULL", vehicleID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errVehicleInMaintenance
+	}
+	return nil
+}
+
+// checkMaintenanceOpenable refuses to open work on a vehicle that is out on a rental or on its
+// way between locations, writing a 409
+func checkMaintenanceOpenable(w http.ResponseWriter, vehicle *models.Vehicle, action string) bool {
+	switch vehicle.Status {
+	case models.VehicleStatusRented:
+		http.Error(w, fmt.Sprintf("Vehicle is rented; check it in before %s maintenance", action), http.StatusConflict)
+		return false
+	case models.VehicleStatusInTransit:
+		http.Error(w, fmt.Sprintf("Vehicle is in transit; receive it before %s maintenance", action), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// openMaintenance puts the vehicle into maintenance, remembering the status it should return to
+func openMaintenance(tx *gorm.DB, vehicle *models.Vehicle, record *models.MaintenanceRecord) error {
+	switch vehicle.Status {
+	case models.VehicleStatusMaintenance:
+		// Share the status saved by another open record, if there is one
+		query := tx.Where("vehicle_id = ? AND closed_at IS NULL", vehicle.ID)
+		if record.ID != "" {
+			query = query.Where("id <> ?", record.ID)
+		}
+		var other models.MaintenanceRecord
+		if err := query.Order("opened_at").First(&other).Error; err == nil {
+			record.PreviousStatus = other.PreviousStatus
+		} else {
+			record.PreviousStatus = models.VehicleStatusAvailable
+		}
+		return nil
+	default:
+		record.PreviousStatus = vehicle.Status
+		vehicle.Status = models.VehicleStatusMaintenance
+		return tx.Model(vehicle).Update("status", vehicle.Status).Error
+	}
+}
+
+// closeMaintenance returns the vehicle to its previous status once no other record is open
+func closeMaintenance(tx *gorm.DB, vehicle *models.Vehicle, record *models.MaintenanceRecord) error {
+	var open int64
+	if err := tx.Model(&models.MaintenanceRecord{}).
+		Where("vehicle_id = ? AND closed_at IS NULL AND id <> ?", vehicle.ID, record.ID).
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 || vehicle.Status != models.VehicleStatusMaintenance {
+		return nil
+	}
+
+	status := record.PreviousStatus
+	if status == "" || status == models.VehicleStatusMaintenance {
+		status = models.VehicleStatusAvailable
+	}
+	vehicle.Status = status
+	return tx.Model(vehicle).Update("status", vehicle.Status).Error
+}
+
+func validateMaintenanceRecord(record *models.MaintenanceRecord) error {
+	valid := false
+	for _, t := range models.MaintenanceTypes {
+		if record.Type == t {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid maintenance type: %s", record.Type)
+	}
+	if record.Cost < 0 || record.OdometerAtService < 0 {
+		return fmt.Errorf("cost and odometer_at_service cannot be negative")
+	}
+	for _, part := range record.Parts {
+		if part.Name == "" || part.Quantity < 0 || part.UnitCost < 0 {
+			return fmt.Errorf("parts need a name and non-negative quantity and unit_cost")
+		}
+	}
+	if record.ClosedAt != nil && record.ClosedAt.Before(record.OpenedAt) {
+		return fmt.Errorf("closed_at cannot be before opened_at")
+	}
+	return nil
+}
+
+// findMaintenanceRecord loads the vehicle and record named in the URL, writing an error if either is missing
+func findMaintenanceRecord(w http.ResponseWriter, r *http.Request) (*models.Vehicle, *models.MaintenanceRecord, bool) {
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	var record models.MaintenanceRecord
+	if err := database.DB.First(&record, "id = ? AND vehicle_id = ?", chi.URLParam(r, "recordId"), vehicle.ID).Error; err != nil {
+		http.Error(w, "Maintenance record not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return &vehicle, &record, true
+}
+
+// GetMaintenanceRecords returns a vehicle's service history, newest first.
+// status=open or status=closed narrows the list.
+func GetMaintenanceRecords(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	query := database.DB.Where("vehicle_id = ?", id).Order("opened_at DESC")
+	switch r.URL.Query().Get("status") {
+	case "":
+	case "open":
+		query = query.Where("closed_at IS NULL")
+	case "closed":
+		query = query.Where("closed_at IS NOT NULL")
+	default:
+		http.Error(w, "status must be open or closed", http.StatusBadRequest)
+		return
+	}
+
+	var records []models.MaintenanceRecord
+	if err := query.Find(&records).Error; err != nil {
+		http.Error(w, "Failed to fetch maintenance records", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+func GetMaintenanceRecord(w http.ResponseWriter, r *http.Request) {
+	_, record, ok := findMaintenanceRecord(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// CreateMaintenanceRecord logs service work. A record created without closed_at opens it and
+// puts the vehicle into maintenance; records of past work can be created already closed.
+func CreateMaintenanceRecord(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateMaintenanceRecordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	record := models.MaintenanceRecord{
+		OrganizationID:    vehicle.OrganizationID,
+		VehicleID:         vehicle.ID,
+		Type:              req.Type,
+		Vendor:            req.Vendor,
+		OdometerAtService: req.OdometerAtService,
+		Cost:              req.Cost,
+		Parts:             models.MaintenanceParts(req.Parts),
+		Notes:             req.Notes,
+		Attachments:       models.StringArray(req.Attachments),
+		OpenedAt:          time.Now(),
+		ClosedAt:          req.ClosedAt,
+		CreatedBy:         currentUserID(r),
+	}
+	if req.OpenedAt != nil {
+		record.OpenedAt = *req.OpenedAt
+	}
+	if record.OdometerAtService == 0 {
+		record.OdometerAtService = vehicle.Mileage
+	}
+	if record.Attachments == nil {
+		record.Attachments = models.StringArray{}
+	}
+
+	if err := validateMaintenanceRecord(&record); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if record.IsOpen() && !checkMaintenanceOpenable(w, &vehicle, "opening") {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if record.IsOpen() {
+			if err := openMaintenance(tx, &vehicle, &record); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		http.Error(w, "Failed to create maintenance record", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(record)
+}
+
+// UpdateMaintenanceRecord replaces a record. Setting closed_at closes it and clearing it
+// reopens it, moving the vehicle in and out of maintenance accordingly.
+func UpdateMaintenanceRecord(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateMaintenanceRecordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	vehicle, record, ok := findMaintenanceRecord(w, r)
+	if !ok {
+		return
+	}
+	wasOpen := record.IsOpen()
+
+	// Update fields
+	record.Type = req.Type
+	record.Vendor = req.Vendor
+	record.OdometerAtService = req.OdometerAtService
+	record.Cost = req.Cost
+	record.Parts = models.MaintenanceParts(req.Parts)
+	record.Notes = req.Notes
+	record.Attachments = models.StringArray(req.Attachments)
+	if req.OpenedAt != nil {
+		record.OpenedAt = *req.OpenedAt
+	}
+	record.ClosedAt = req.ClosedAt
+	if record.Attachments == nil {
+		record.Attachments = models.StringArray{}
+	}
+
+	if err := validateMaintenanceRecord(record); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !wasOpen && record.IsOpen() && !checkMaintenanceOpenable(w, vehicle, "reopening") {
+		return
+	}
+
+	if err := saveMaintenanceRecord(vehicle, record, wasOpen); err != nil {
+		http.Error(w, "Failed to update maintenance record", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// CloseMaintenanceRecord closes an open record, at the optional closed_at or now
+func CloseMaintenanceRecord(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClosedAt *time.Time `json:"closed_at"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	vehicle, record, ok := findMaintenanceRecord(w, r)
+	if !ok {
+		return
+	}
+
+	if !record.IsOpen() {
+		http.Error(w, "Maintenance record is already closed", http.StatusConflict)
+		return
+	}
+
+	closedAt := time.Now()
+	if req.ClosedAt != nil {
+		closedAt = *req.ClosedAt
+	}
+	record.ClosedAt = &closedAt
+
+	if err := validateMaintenanceRecord(record); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := saveMaintenanceRecord(vehicle, record, true); err != nil {
+		http.Error(w, "Failed to close maintenance record", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// saveMaintenanceRecord stores a record and applies any open/closed transition to the vehicle
+func saveMaintenanceRecord(vehicle *models.Vehicle, record *models.MaintenanceRecord, wasOpen bool) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		switch {
+		case wasOpen && !record.IsOpen():
+			if err := closeMaintenance(tx, vehicle, record); err != nil {
+				return err
+			}
+		case !wasOpen && record.IsOpen():
+			if err := openMaintenance(tx, vehicle, record); err != nil {
+				return err
+			}
+		}
+		return tx.Save(record).Error
+	})
+}
+
+// DeleteMaintenanceRecord removes a record. Deleting an open record releases the vehicle as closing it would.
+func DeleteMaintenanceRecord(w http.ResponseWriter, r *http.Request) {
+	vehicle, record, ok := findMaintenanceRecord(w, r)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if record.IsOpen() {
+			if err := closeMaintenance(tx, vehicle, record); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(record).Error
+	})
+	if err != nil {
+		http.Error(w, "Failed to delete maintenance record", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/jobs"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func validateMaintenancePlan(plan *models.MaintenancePlan) error {
+	if strings.TrimSpace(plan.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	valid := false
+	for _, t := range models.MaintenanceTypes {
+		if plan.Type == t {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid maintenance type: %s", plan.Type)
+	}
+	if plan.IntervalMiles < 0 || plan.IntervalMonths < 0 || plan.DueSoonMiles < 0 || plan.DueSoonDays < 0 {
+		return fmt.Errorf("intervals and due soon thresholds cannot be negative")
+	}
+	if plan.IntervalMiles == 0 && plan.IntervalMonths == 0 {
+		return fmt.Errorf("interval_miles or interval_months is required")
+	}
+	if plan.YearMin != nil && plan.YearMax != nil && *plan.YearMin > *plan.YearMax {
+		return fmt.Errorf("year_min cannot be after year_max")
+	}
+
+	if plan.VehicleID != nil {
+		if plan.Make != "" || plan.Model != "" || plan.YearMin != nil || plan.YearMax != nil {
+			return fmt.Errorf("a plan applies to either a vehicle or a make, model and years, not both")
+		}
+		var vehicle models.Vehicle
+		if err := database.DB.First(&vehicle, "id = ?", *plan.VehicleID).Error; err != nil {
+			return fmt.Errorf("vehicle not found")
+		}
+		if vehicle.OrganizationID != plan.OrganizationID {
+			return fmt.Errorf("vehicle belongs to a different organization")
+		}
+	}
+	return nil
+}
+
+// GetMaintenancePlans lists maintenance plans, optionally filtered by organization_id or vehicle_id
+func GetMaintenancePlans(w http.ResponseWriter, r *http.Request) {
+	query := database.DB.Order("name")
+	if organizationID := r.URL.Query().Get("organization_id"); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+	if vehicleID := r.URL.Query().Get("vehicle_id"); vehicleID != "" {
+		query = query.Where("vehicle_id = ?", vehicleID)
+	}
+
+	var plans []models.MaintenancePlan
+	if err := query.Find(&plans).Error; err != nil {
+		http.Error(w, "Failed to fetch maintenance plans", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plans)
+}
+
+func GetMaintenancePlan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var plan models.MaintenancePlan
+	if err := database.DB.First(&plan, "id = ?", id).Error; err != nil {
+		http.Error(w, "Maintenance plan not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+func CreateMaintenancePlan(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateMaintenancePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.OrganizationID == "" {
+		http.Error(w, "Organization ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var organization models.Organization
+	if err := database.DB.First(&organization, "id = ?", req.OrganizationID).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusBadRequest)
+		return
+	}
+
+	plan := models.MaintenancePlan{
+		OrganizationID: req.OrganizationID,
+		Name:           strings.TrimSpace(req.Name),
+		Type:           req.Type,
+		VehicleID:      req.VehicleID,
+		Make:           strings.TrimSpace(req.Make),
+		Model:          strings.TrimSpace(req.Model),
+		YearMin:        req.YearMin,
+		YearMax:        req.YearMax,
+		IntervalMiles:  req.IntervalMiles,
+		IntervalMonths: req.IntervalMonths,
+		DueSoonMiles:   500,
+		DueSoonDays:    14,
+		IsActive:       true,
+	}
+	if req.DueSoonMiles != nil {
+		plan.DueSoonMiles = *req.DueSoonMiles
+	}
+	if req.DueSoonDays != nil {
+		plan.DueSoonDays = *req.DueSoonDays
+	}
+
+	if err := validateMaintenancePlan(&plan); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DB.Create(&plan).Error; err != nil {
+		http.Error(w, "Failed to create maintenance plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(plan)
+}
+
+func UpdateMaintenancePlan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.UpdateMaintenancePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var plan models.MaintenancePlan
+	if err := database.DB.First(&plan, "id = ?", id).Error; err != nil {
+		http.Error(w, "Maintenance plan not found", http.StatusNotFound)
+		return
+	}
+
+	// Update fields
+	plan.Name = strings.TrimSpace(req.Name)
+	plan.Type = req.Type
+	plan.VehicleID = req.VehicleID
+	plan.Make = strings.TrimSpace(req.Make)
+	plan.Model = strings.TrimSpace(req.Model)
+	plan.YearMin = req.YearMin
+	plan.YearMax = req.YearMax
+	plan.IntervalMiles = req.IntervalMiles
+	plan.IntervalMonths = req.IntervalMonths
+	plan.DueSoonMiles = req.DueSoonMiles
+	plan.DueSoonDays = req.DueSoonDays
+	plan.IsActive = req.IsActive
+
+	if err := validateMaintenancePlan(&plan); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DB.Save(&plan).Error; err != nil {
+		http.Error(w, "Failed to update maintenance plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+func DeleteMaintenancePlan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	result := database.DB.Delete(&models.MaintenancePlan{}, "id = ?", id)
+	if result.Error != nil {
+		http.Error(w, "Failed to delete maintenance plan", http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		http.Error(w, "Maintenance plan not found", http.StatusNotFound)
+		return
+	}
+
+	database.DB.Where("plan_id = ?", id).Delete(&models.MaintenanceAlert{})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetVehicleMaintenanceSchedule returns the next due service for every plan that applies to the vehicle
+func GetVehicleMaintenanceSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	schedule, err := jobs.MaintenanceSchedule(database.DB, []models.Vehicle{vehicle}, time.Now())
+	if err != nil {
+		http.Error(w, "Failed to compute maintenance schedule", http.StatusInternalServerError)
+		return
+	}
+	if schedule == nil {
+		schedule = []models.MaintenanceDue{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// GetLocationMaintenanceDue lists the vehicles at a location that are overdue or due soon for
+// preventive maintenance, overdue first. status=overdue or status=due_soon narrows the list.
+func GetLocationMaintenanceDue(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	status := models.MaintenanceDueStatus(r.URL.Query().Get("status"))
+	if status != "" && status != models.MaintenanceDueStatusOverdue && status != models.MaintenanceDueStatusDueSoon {
+		http.Error(w, "status must be overdue or due_soon", http.StatusBadRequest)
+		return
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	var vehicles []models.Vehicle
+	if err := database.DB.Where("location_id = ? AND status <> ?", id, models.VehicleStatusInactive).
+		Find(&vehicles).Error; err != nil {
+		http.Error(w, "Failed to fetch vehicles", http.StatusInternalServerError)
+		return
+	}
+
+	schedule, err := jobs.MaintenanceSchedule(database.DB, vehicles, time.Now())
+	if err != nil {
+		http.Error(w, "Failed to compute maintenance schedule", http.StatusInternalServerError)
+		return
+	}
+
+	var overdue, dueSoon []models.MaintenanceDue
+	for _, item := range schedule {
+		switch item.Status {
+		case models.MaintenanceDueStatusOverdue:
+			overdue = append(overdue, item)
+		case models.MaintenanceDueStatusDueSoon:
+			dueSoon = append(dueSoon, item)
+		}
+	}
+
+	due := []models.MaintenanceDue{}
+	if status != models.MaintenanceDueStatusDueSoon {
+		due = append(due, overdue...)
+	}
+	if status != models.MaintenanceDueStatusOverdue {
+		due = append(due, dueSoon...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(due)
+}
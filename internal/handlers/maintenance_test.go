@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// withURLParams attaches chi URL parameters to a request, given as name/value pairs
+func withURLParams(req *http.Request, params ...string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestMaintenanceRecord_OpenAndClose(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	body := `{"type":"brakes","vendor":"Main St Auto","cost":320.50,"parts":[{"name":"Brake pads","quantity":4,"unit_cost":45}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/maintenance", bytes.NewBufferString(body))
+	req = withURLParams(req, "id", vehicle.ID)
+	w := httptest.NewRecorder()
+
+	CreateMaintenanceRecord(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var record models.MaintenanceRecord
+	json.NewDecoder(w.Body).Decode(&record)
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Status != models.VehicleStatusMaintenance {
+		t.Errorf("Expected vehicle in maintenance, got %s", vehicle.Status)
+	}
+
+	// Renting, or making the vehicle available, is blocked while the record is open
+	for _, status := range []string{"rented", "available"} {
+		req = httptest.NewRequest(http.MethodPatch, "/api/vehicles/"+vehicle.ID, bytes.NewBufferString(`{"status":"`+status+`"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req = withURLParams(req, "id", vehicle.ID)
+		w = httptest.NewRecorder()
+
+		PatchVehicle(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusConflict, status, w.Code)
+		}
+	}
+
+	// Closing returns the vehicle to service
+	req = httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/maintenance/"+record.ID+"/close", nil)
+	req = withURLParams(req, "id", vehicle.ID, "recordId", record.ID)
+	w = httptest.NewRecorder()
+
+	CloseMaintenanceRecord(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Status != models.VehicleStatusAvailable {
+		t.Errorf("Expected vehicle available after close, got %s", vehicle.Status)
+	}
+}
+
+func TestMaintenanceRecord_HistoricalRecordKeepsStatus(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	body := `{"type":"oil_change","opened_at":"2025-01-10T09:00:00Z","closed_at":"2025-01-10T11:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/maintenance", bytes.NewBufferString(body))
+	req = withURLParams(req, "id", vehicle.ID)
+	w := httptest.NewRecorder()
+
+	CreateMaintenanceRecord(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Status != models.VehicleStatusAvailable {
+		t.Errorf("Expected vehicle to stay available, got %s", vehicle.Status)
+	}
+}
+
+func TestMaintenanceRecord_InTransitVehicle(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Model(vehicle).Update("status", models.VehicleStatusInTransit)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/maintenance", bytes.NewBufferString(`{"type":"brakes"}`))
+	req = withURLParams(req, "id", vehicle.ID)
+	w := httptest.NewRecorder()
+
+	CreateMaintenanceRecord(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a vehicle in transit, got %d", http.StatusConflict, w.Code)
+	}
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Status != models.VehicleStatusInTransit {
+		t.Errorf("Expected the vehicle to stay in transit, got %s", vehicle.Status)
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// maxDailyMiles is the furthest a vehicle can plausibly travel per day between two readings.
+// Larger jumps are stored but flagged for review instead of updating the vehicle's mileage.
+const maxDailyMiles = 1000
+
+// odometerError is a reading the log refuses, as opposed to a database failure. Readings that
+// go backwards conflict with the log; other invalid readings are unprocessable.
+type odometerError struct {
+	message  string
+	conflict bool
+}
+
+func (e *odometerError) Error() string {
+	return e.message
+}
+
+func (e *odometerError) status() int {
+	if e.conflict {
+		return http.StatusConflict
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// recordOdometerReading checks a reading against its neighbours in the vehicle's log, flags
+// implausible jumps, stores it and re-derives the vehicle's mileage. Only plain readings are
+// held to the monotonic rule; rollovers and corrections start a new baseline.
+func recordOdometerReading(tx *gorm.DB, vehicle *models.Vehicle, reading *models.OdometerReading) error {
+	if reading.Reading < 0 {
+		return &odometerError{message: "reading cannot be negative"}
+	}
+	reading.OrganizationID = vehicle.OrganizationID
+	reading.VehicleID = vehicle.ID
+	if reading.Kind == "" {
+		reading.Kind = models.OdometerReadingKindReading
+	}
+	if reading.ReadAt.IsZero() {
+		reading.ReadAt = time.Now()
+	}
+
+	if reading.Kind == models.OdometerReadingKindReading {
+		var count int64
+		if err := tx.Model(&models.OdometerReading{}).Where("vehicle_id = ?", vehicle.ID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		// Vehicles without a log yet start from their recorded mileage, which has no reliable
+		// date to judge a jump against
+		previous := &models.OdometerReading{Reading: vehicle.Mileage, ReadAt: vehicle.UpdatedAt}
+		baseline := count == 0
+		if count > 0 {
+			previous = nil
+			var found models.OdometerReading
+			err := tx.Where("vehicle_id = ? AND flagged = ? AND read_at <= ?", vehicle.ID, false, reading.ReadAt).
+				Order("read_at DESC, created_at DESC").First(&found).Error
+			if err == nil {
+				previous = &found
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		if previous != nil && reading.Reading < previous.Reading {
+			return &odometerError{
+				message:  fmt.Sprintf("odometer reading is lower than a previous reading (%d on %s)", previous.Reading, previous.ReadAt.Format("2006-01-02")),
+				conflict: true,
+			}
+		}
+
+		// A back-dated reading must also fit below the next one, unless that one reset the baseline
+		var next models.OdometerReading
+		err := tx.Where("vehicle_id = ? AND flagged = ? AND read_at > ?", vehicle.ID, false, reading.ReadAt).
+			Order("read_at, created_at").First(&next).Error
+		if err == nil && next.Kind == models.OdometerReadingKindReading && reading.Reading > next.Reading {
+			return &odometerError{
+				message:  fmt.Sprintf("odometer reading is higher than a later reading (%d on %s)", next.Reading, next.ReadAt.Format("2006-01-02")),
+				conflict: true,
+			}
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if previous != nil && !baseline {
+			if anomaly := odometerAnomaly(previous, reading); anomaly != "" {
+				reading.Flagged = true
+				reading.Anomaly = anomaly
+			}
+		}
+	}
+
+	if err := tx.Create(reading).Error; err != nil {
+		return err
+	}
+	return syncVehicleMileage(tx, vehicle)
+}
+
+// odometerAnomaly describes a jump from previous that is further than the vehicle could have
+// driven in the time between the readings, or returns "" if the jump is plausible
+func odometerAnomaly(previous, reading *models.OdometerReading) string {
+	days := reading.ReadAt.Sub(previous.ReadAt).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+	distance := reading.Reading - previous.Reading
+	if float64(distance) <= maxDailyMiles*days {
+		return ""
+	}
+	return fmt.Sprintf("%d miles in %.1f days exceeds %d miles per day", distance, days, maxDailyMiles)
+}
+
+// syncVehicleMileage sets the vehicle's mileage to its latest unflagged reading
+func syncVehicleMileage(tx *gorm.DB, vehicle *models.Vehicle) error {
+	var latest models.OdometerReading
+	err := tx.Where("vehicle_id = ? AND flagged = ?", vehicle.ID, false).
+		Order("read_at DESC, created_at DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if latest.Reading == vehicle.Mileage {
+		return nil
+	}
+	vehicle.Mileage = latest.Reading
+	return tx.Model(vehicle).Update("mileage", vehicle.Mileage).Error
+}
+
+// GetOdometerReadings returns a vehicle's odometer log, newest first. flagged=true lists only
+// readings awaiting review.
+func GetOdometerReadings(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	query := database.DB.Where("vehicle_id = ?", id).Order("read_at DESC, created_at DESC")
+	if r.URL.Query().Get("flagged") == "true" {
+		query = query.Where("flagged = ?", true)
+	}
+
+	var readings []models.OdometerReading
+	if err := query.Find(&readings).Error; err != nil {
+		http.Error(w, "Failed to fetch odometer readings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(readings)
+}
+
+// CreateOdometerReading logs a reading. Readings lower than the previous one are refused unless
+// an administrator records them as a rollover or correction.
+func CreateOdometerReading(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateOdometerReadingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Source == "" {
+		req.Source = models.OdometerSourceManual
+	}
+	switch req.Source {
+	case models.OdometerSourceManual, models.OdometerSourceCheckout, models.OdometerSourceReturn, models.OdometerSourceTelematics:
+	default:
+		http.Error(w, fmt.Sprintf("invalid source: %s", req.Source), http.StatusBadRequest)
+		return
+	}
+	switch req.Kind {
+	case "", models.OdometerReadingKindReading:
+	case models.OdometerReadingKindRollover, models.OdometerReadingKindCorrection:
+		if !currentUserIsAdmin(r) {
+			http.Error(w, "Only administrators can record rollovers and corrections", http.StatusForbidden)
+			return
+		}
+	default:
+		http.Error(w, fmt.Sprintf("invalid kind: %s", req.Kind), http.StatusBadRequest)
+		return
+	}
+	if req.ReadAt != nil && req.ReadAt.After(time.Now().Add(time.Minute)) {
+		http.Error(w, "read_at cannot be in the future", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	reading := models.OdometerReading{
+		Reading:    req.Reading,
+		Source:     req.Source,
+		Kind:       req.Kind,
+		Notes:      req.Notes,
+		RecordedBy: currentUserID(r),
+	}
+	if req.ReadAt != nil {
+		reading.ReadAt = *req.ReadAt
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return recordOdometerReading(tx, &vehicle, &reading)
+	})
+	var invalid *odometerError
+	if errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), invalid.status())
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to record odometer reading", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reading)
+}
+
+// findOdometerReading loads the vehicle and reading named in the URL, writing an error if either is missing
+func findOdometerReading(w http.ResponseWriter, r *http.Request) (*models.Vehicle, *models.OdometerReading, bool) {
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	var reading models.OdometerReading
+	if err := database.DB.First(&reading, "id = ? AND vehicle_id = ?", chi.URLParam(r, "readingId"), vehicle.ID).Error; err != nil {
+		http.Error(w, "Odometer reading not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return &vehicle, &reading, true
+}
+
+// AcceptOdometerReading clears the flag on an anomalous reading after an administrator has
+// confirmed it, letting it update the vehicle's mileage
+func AcceptOdometerReading(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsAdmin(r) {
+		http.Error(w, "Only administrators can accept flagged readings", http.StatusForbidden)
+		return
+	}
+
+	vehicle, reading, ok := findOdometerReading(w, r)
+	if !ok {
+		return
+	}
+
+	if !reading.Flagged {
+		http.Error(w, "Odometer reading is not flagged", http.StatusConflict)
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		reading.Flagged = false
+		if err := tx.Model(reading).Update("flagged", false).Error; err != nil {
+			return err
+		}
+		return syncVehicleMileage(tx, vehicle)
+	})
+	if err != nil {
+		http.Error(w, "Failed to accept odometer reading", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reading)
+}
+
+// DeleteOdometerReading removes a mistaken reading; the vehicle's mileage falls back to the
+// latest remaining one
+func DeleteOdometerReading(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsAdmin(r) {
+		http.Error(w, "Only administrators can delete odometer readings", http.StatusForbidden)
+		return
+	}
+
+	vehicle, reading, ok := findOdometerReading(w, r)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(reading).Error; err != nil {
+			return err
+		}
+		return syncVehicleMileage(tx, vehicle)
+	})
+	if err != nil {
+		http.Error(w, "Failed to delete odometer reading", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// withRoles authenticates a request as a user holding the given roles
+func withRoles(t *testing.T, req *http.Request, roles ...string) *http.Request {
+	t.Helper()
+	auth := jwtauth.New("HS256", []byte("test-secret"), nil)
+	token, _, err := auth.Encode(map[string]interface{}{"roles": roles})
+	if err != nil {
+		t.Fatalf("Failed to encode token: %v", err)
+	}
+	return req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+}
+
+// withUser authenticates a request as the given user holding the given roles
+func withUser(t *testing.T, req *http.Request, userID string, roles ...string) *http.Request {
+	t.Helper()
+	auth := jwtauth.New("HS256", []byte("test-secret"), nil)
+	token, _, err := auth.Encode(map[string]interface{}{"user_id": userID, "roles": roles})
+	if err != nil {
+		t.Fatalf("Failed to encode token: %v", err)
+	}
+	return req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+}
+
+func TestOdometerAnomaly(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	previous := &models.OdometerReading{Reading: 10000, ReadAt: start}
+
+	tests := []struct {
+		name    string
+		reading int
+		readAt  time.Time
+		flagged bool
+	}{
+		{"normal day", 10250, start.Add(10 * time.Hour), false},
+		{"long trip within a day", 11000, start.Add(2 * time.Hour), false},
+		{"typo", 100000, start.Add(24 * time.Hour), true},
+		{"a week of driving", 16000, start.AddDate(0, 0, 7), false},
+		{"too far for a week", 18000, start.AddDate(0, 0, 7), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomaly := odometerAnomaly(previous, &models.OdometerReading{Reading: tt.reading, ReadAt: tt.readAt})
+			if (anomaly != "") != tt.flagged {
+				t.Errorf("Expected flagged=%v, got anomaly %q", tt.flagged, anomaly)
+			}
+		})
+	}
+}
+
+func TestCreateOdometerReading_MonotonicUnlessCorrected(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	post := func(body string, roles ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/odometer", bytes.NewBufferString(body))
+		req = withRoles(t, withURLParams(req, "id", vehicle.ID), roles...)
+		w := httptest.NewRecorder()
+		CreateOdometerReading(w, req)
+		return w
+	}
+
+	if w := post(`{"reading":12000,"source":"checkout"}`, models.RoleStaff); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// Going backwards is refused
+	if w := post(`{"reading":11000,"source":"return"}`, models.RoleStaff); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a lower reading, got %d", http.StatusConflict, w.Code)
+	}
+
+	// An implausible jump is kept but does not move the mileage
+	if w := post(`{"reading":120000,"source":"telematics"}`, models.RoleStaff); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Mileage != 12000 {
+		t.Errorf("Expected mileage to stay at 12000, got %d", vehicle.Mileage)
+	}
+
+	// Only an administrator can correct the odometer downwards
+	if w := post(`{"reading":9000,"kind":"correction"}`, models.RoleStaff); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for staff correction, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := post(`{"reading":9000,"kind":"correction","notes":"Cluster replaced"}`, models.RoleAdmin); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Mileage != 9000 {
+		t.Errorf("Expected mileage 9000 after correction, got %d", vehicle.Mileage)
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"fleetpass/internal/database"
 	"fleetpass/internal/models"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 func GetOrganizations(w http.ResponseWriter, r *http.Request) {
@@ -52,6 +55,32 @@ func CreateOrganization(w http.ResponseWriter, r *http.Request) {
 		Slug:     req.Slug,
 		IsActive: true,
 	}
+	if err := validateBranding(&req.OrganizationBranding); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	org.OrganizationBranding = req.OrganizationBranding
+	if err := validateReturnPolicy(&req.ReturnPolicy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	org.ReturnPolicy = req.ReturnPolicy
+	org.MinimumDriverAge = models.DefaultMinimumDriverAge
+	if req.MinimumDriverAge != nil {
+		if err := validateMinimumDriverAge(*req.MinimumDriverAge); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		org.MinimumDriverAge = *req.MinimumDriverAge
+	}
+	org.Currency = models.DefaultCurrency
+	if req.Currency != "" {
+		if err := validateCurrency(&req.Currency); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		org.Currency = req.Currency
+	}
 
 	if err := database.DB.Create(&org).Error; err != nil {
 		http.Error(w, "Failed to create organization", http.StatusInternalServerError)
@@ -82,6 +111,30 @@ func UpdateOrganization(w http.ResponseWriter, r *http.Request) {
 	org.Name = req.Name
 	org.Slug = req.Slug
 	org.IsActive = req.IsActive
+	if err := validateBranding(&req.OrganizationBranding); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	org.OrganizationBranding = req.OrganizationBranding
+	if err := validateReturnPolicy(&req.ReturnPolicy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	org.ReturnPolicy = req.ReturnPolicy
+	if req.MinimumDriverAge != nil {
+		if err := validateMinimumDriverAge(*req.MinimumDriverAge); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		org.MinimumDriverAge = *req.MinimumDriverAge
+	}
+	if req.Currency != "" {
+		if err := validateCurrency(&req.Currency); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		org.Currency = req.Currency
+	}
 
 	if err := database.DB.Save(&org).Error; err != nil {
 		http.Error(w, "Failed to update organization", http.StatusInternalServerError)
@@ -92,19 +145,156 @@ func UpdateOrganization(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(org)
 }
 
+// organizationPatchFields lists the organization fields that may be changed through PATCH
+var organizationPatchFields = []string{
+	"name", "slug", "is_active", "minimum_driver_age", "currency", "legal_name", "billing_address",
+	"billing_email", "billing_phone", "tax_id", "brand_color", "invoice_prefix",
+	"late_grace_minutes", "late_hourly_fee", "fuel_price_per_gallon", "charge_price_per_kwh",
+	"included_miles_per_day", "mileage_overage_fee",
+}
+
+// PatchOrganization applies a JSON Merge Patch or JSON Patch to an organization, updating only changed columns
+func PatchOrganization(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var org models.Organization
+	if err := database.DB.First(&org, "id = ?", id).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	}
+
+	var patched models.Organization
+	changed, err := applyPatchRequest(r, org, &patched, organizationPatchFields)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	if len(changed) > 0 {
+		for _, field := range changed {
+			switch field {
+			case "name":
+				if strings.TrimSpace(patched.Name) == "" {
+					http.Error(w, "name cannot be empty", http.StatusUnprocessableEntity)
+					return
+				}
+			case "slug":
+				if strings.TrimSpace(patched.Slug) == "" {
+					http.Error(w, "slug cannot be empty", http.StatusUnprocessableEntity)
+					return
+				}
+				var count int64
+				database.DB.Model(&models.Organization{}).Where("slug = ? AND id <> ?", patched.Slug, org.ID).Count(&count)
+				if count > 0 {
+					http.Error(w, "slug is already in use", http.StatusConflict)
+					return
+				}
+			case "minimum_driver_age":
+				if err := validateMinimumDriverAge(patched.MinimumDriverAge); err != nil {
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+			case "currency":
+				if err := validateCurrency(&patched.Currency); err != nil {
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+			case "brand_color", "invoice_prefix":
+				if err := validateBranding(&patched.OrganizationBranding); err != nil {
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+			case "late_grace_minutes", "late_hourly_fee", "fuel_price_per_gallon", "charge_price_per_kwh",
+				"included_miles_per_day", "mileage_overage_fee":
+				if err := validateReturnPolicy(&patched.ReturnPolicy); err != nil {
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+					return
+				}
+			}
+		}
+
+		if err := database.DB.Model(&org).Select(append(changed, "updated_at")).Updates(&patched).Error; err != nil {
+			http.Error(w, "Failed to update organization", http.StatusInternalServerError)
+			return
+		}
+
+		if err := database.DB.First(&org, "id = ?", id).Error; err != nil {
+			http.Error(w, "Organization not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+// DeleteOrganization soft-deletes an organization together with its locations and vehicles.
+// Children share the organization's deletion timestamp so a restore brings them back as a unit.
 func DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	result := database.DB.Delete(&models.Organization{}, "id = ?", id)
-	if result.Error != nil {
+	deletion := map[string]interface{}{
+		"deleted_at": time.Now(),
+		"deleted_by": currentUserID(r),
+	}
+
+	var found bool
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Organization{}).Where("id = ?", id).Updates(deletion)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		found = true
+
+		if err := tx.Model(&models.Vehicle{}).Where("organization_id = ?", id).Updates(deletion).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Location{}).Where("organization_id = ?", id).Updates(deletion).Error
+	})
+	if err != nil {
 		http.Error(w, "Failed to delete organization", http.StatusInternalServerError)
 		return
 	}
 
-	if result.RowsAffected == 0 {
+	if !found {
 		http.Error(w, "Organization not found", http.StatusNotFound)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreOrganization moves a soft-deleted organization out of the trash, along with the
+// locations and vehicles that were deleted with it
+func RestoreOrganization(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var org models.Organization
+	if err := database.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&org).Error; err != nil {
+		http.Error(w, "Deleted organization not found", http.StatusNotFound)
+		return
+	}
+
+	deletedAt := org.DeletedAt.Time
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := restoreRecords(tx.Model(&models.Organization{}).Where("id = ?", id)); err != nil {
+			return err
+		}
+		if err := restoreRecords(tx.Model(&models.Location{}).Where("organization_id = ? AND deleted_at = ?", id, deletedAt)); err != nil {
+			return err
+		}
+		return restoreRecords(tx.Model(&models.Vehicle{}).Where("organization_id = ? AND deleted_at = ?", id, deletedAt))
+	})
+	if err != nil {
+		http.Error(w, "Failed to restore organization", http.StatusInternalServerError)
+		return
+	}
+
+	database.DB.First(&org, "id = ?", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func validateCapacity(capacity *int) error {
+	if capacity != nil && *capacity < 0 {
+		return fmt.Errorf("capacity cannot be negative")
+	}
+	return nil
+}
+
+// locationOccupancy counts the vehicles based at a location and heading to it. Vehicles in
+// transit are counted at their destination rather than where they left.
+func locationOccupancy(db *gorm.DB, location *models.Location) (*models.LocationOccupancy, error) {
+	occupancy := &models.LocationOccupancy{LocationID: location.ID, Capacity: location.Capacity}
+
+	var counts []struct {
+		Status models.VehicleStatus
+		Count  int64
+	}
+	if err := db.Model(&models.Vehicle{}).Select("status, COUNT(*) AS count").
+		Where("location_id = ? AND status <> ?", location.ID, models.VehicleStatusInTransit).
+		Group("status").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		occupancy.Assigned += count.Count
+		if count.Status == models.VehicleStatusRented {
+			occupancy.Rented += count.Count
+		}
+	}
+	occupancy.OnLot = occupancy.Assigned - occupancy.Rented
+
+	if err := db.Model(&models.VehicleTransfer{}).
+		Where("to_location_id = ? AND status IN ?", location.ID, []models.TransferStatus{models.TransferStatusRequested, models.TransferStatusInTransit}).
+		Count(&occupancy.Inbound).Error; err != nil {
+		return nil, err
+	}
+	occupancy.Projected = occupancy.Assigned + occupancy.Inbound
+
+	if location.Capacity != nil {
+		available := int64(*location.Capacity) - occupancy.Projected
+		if available < 0 {
+			available = 0
+		}
+		occupancy.Available = &available
+		occupancy.OverCapacity = occupancy.Projected > int64(*location.Capacity)
+	}
+	return occupancy, nil
+}
+
+// capacityWarning describes how far adding vehicles would take a location past its capacity,
+// or returns an empty string if they fit
+func capacityWarning(db *gorm.DB, location *models.Location, adding int) (string, error) {
+	if location.Capacity == nil {
+		return "", nil
+	}
+	occupancy, err := locationOccupancy(db, location)
+	if err != nil {
+		return "", err
+	}
+	if occupancy.Projected+int64(adding) <= int64(*location.Capacity) {
+		return "", nil
+	}
+	return fmt.Sprintf("%s would hold %d vehicles, over its capacity of %d",
+		location.Name, occupancy.Projected+int64(adding), *location.Capacity), nil
+}
+
+// checkLocationCapacity makes room for vehicles arriving at a location. Over capacity it writes
+// a 409 unless allowOver is set, in which case it adds a Warning header and lets the caller go on.
+func checkLocationCapacity(w http.ResponseWriter, location *models.Location, adding int, allowOver bool) bool {
+	warning, err := capacityWarning(database.DB, location, adding)
+	if err != nil {
+		http.Error(w, "Failed to check location capacity", http.StatusInternalServerError)
+		return false
+	}
+	if warning == "" {
+		return true
+	}
+	if !allowOver {
+		http.Error(w, warning+"; set allow_over_capacity to proceed anyway", http.StatusConflict)
+		return false
+	}
+	w.Header().Set("Warning", fmt.Sprintf("199 - %q", warning))
+	return true
+}
+
+// releaseParkingSpace frees the space a vehicle is parked in, if any
+func releaseParkingSpace(tx *gorm.DB, vehicleID string) error {
+	return tx.Model(&models.ParkingSpace{}).Where("vehicle_id = ?", vehicleID).
+		Updates(map[string]interface{}{"vehicle_id": nil, "assigned_at": nil}).Error
+}
+
+// findParkingSpace loads the parking space named in the URL, writing a 404 if it is missing
+func findParkingSpace(w http.ResponseWriter, r *http.Request) (*models.ParkingSpace, bool) {
+	var space models.ParkingSpace
+	if err := database.DB.First(&space, "id = ? AND location_id = ?", chi.URLParam(r, "spaceId"), chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Parking space not found", http.StatusNotFound)
+		return nil, false
+	}
+	return &space, true
+}
+
+// GetLocationOccupancy reports how full a location's lot is, along with its parking spaces
+func GetLocationOccupancy(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	occupancy, err := locationOccupancy(database.DB, &location)
+	if err != nil {
+		http.Error(w, "Failed to count vehicles", http.StatusInternalServerError)
+		return
+	}
+
+	if err := database.DB.Where("location_id = ?", id).Order("name").Find(&occupancy.ParkingSpaces).Error; err != nil {
+		http.Error(w, "Failed to fetch parking spaces", http.StatusInternalServerError)
+		return
+	}
+	occupancy.Spaces = len(occupancy.ParkingSpaces)
+	for _, space := range occupancy.ParkingSpaces {
+		if space.VehicleID != nil {
+			occupancy.OccupiedSpaces++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(occupancy)
+}
+
+func GetParkingSpaces(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	var spaces []models.ParkingSpace
+	if err := database.DB.Where("location_id = ?", id).Order("name").Find(&spaces).Error; err != nil {
+		http.Error(w, "Failed to fetch parking spaces", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spaces)
+}
+
+// parkingSpaceNameTaken reports whether another space at the location already uses the name
+func parkingSpaceNameTaken(locationID, name, excludeID string) (bool, error) {
+	query := database.DB.Model(&models.ParkingSpace{}).Where("location_id = ? AND name = ?", locationID, name)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func CreateParkingSpace(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateParkingSpaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	taken, err := parkingSpaceNameTaken(id, req.Name, "")
+	if err != nil {
+		http.Error(w, "Failed to create parking space", http.StatusInternalServerError)
+		return
+	}
+	if taken {
+		http.Error(w, "A parking space with this name already exists at the location", http.StatusConflict)
+		return
+	}
+
+	space := models.ParkingSpace{
+		LocationID: id,
+		Name:       req.Name,
+		Notes:      req.Notes,
+	}
+
+	if err := database.DB.Create(&space).Error; err != nil {
+		http.Error(w, "Failed to create parking space", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(space)
+}
+
+func UpdateParkingSpace(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateParkingSpaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	space, ok := findParkingSpace(w, r)
+	if !ok {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	taken, err := parkingSpaceNameTaken(space.LocationID, req.Name, space.ID)
+	if err != nil {
+		http.Error(w, "Failed to update parking space", http.StatusInternalServerError)
+		return
+	}
+	if taken {
+		http.Error(w, "A parking space with this name already exists at the location", http.StatusConflict)
+		return
+	}
+
+	space.Name = req.Name
+	space.Notes = req.Notes
+
+	if err := database.DB.Save(space).Error; err != nil {
+		http.Error(w, "Failed to update parking space", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(space)
+}
+
+func DeleteParkingSpace(w http.ResponseWriter, r *http.Request) {
+	space, ok := findParkingSpace(w, r)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(space).Error; err != nil {
+		http.Error(w, "Failed to delete parking space", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AssignParkingSpace parks a vehicle based at the location in the space, moving it out of any
+// other space it held. A space already holding another vehicle must be released first.
+func AssignParkingSpace(w http.ResponseWriter, r *http.Request) {
+	var req models.AssignParkingSpaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.VehicleID == "" {
+		http.Error(w, "vehicle_id is required", http.StatusBadRequest)
+		return
+	}
+
+	space, ok := findParkingSpace(w, r)
+	if !ok {
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", req.VehicleID).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusBadRequest)
+		return
+	}
+	if vehicle.LocationID != space.LocationID {
+		http.Error(w, "Vehicle is not based at this location", http.StatusBadRequest)
+		return
+	}
+	if vehicle.Status == models.VehicleStatusInTransit {
+		http.Error(w, "Vehicle is in transit and cannot be parked", http.StatusConflict)
+		return
+	}
+	if space.VehicleID != nil {
+		if *space.VehicleID == vehicle.ID {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(space)
+			return
+		}
+		http.Error(w, "Parking space is occupied by another vehicle", http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	space.VehicleID = &vehicle.ID
+	space.AssignedAt = &now
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := releaseParkingSpace(tx, vehicle.ID); err != nil {
+			return err
+		}
+		return tx.Save(space).Error
+	})
+	if err != nil {
+		http.Error(w, "Failed to assign parking space", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(space)
+}
+
+// ReleaseParkingSpace empties a parking space
+func ReleaseParkingSpace(w http.ResponseWriter, r *http.Request) {
+	space, ok := findParkingSpace(w, r)
+	if !ok {
+		return
+	}
+
+	space.VehicleID = nil
+	space.AssignedAt = nil
+
+	if err := database.DB.Save(space).Error; err != nil {
+		http.Error(w, "Failed to release parking space", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(space)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateVehicle_LocationCapacity(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Model(loc).Update("capacity", 1)
+
+	create := func(allowOver bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.CreateVehicleRequest{
+			LocationID: loc.ID, VIN: "2HGBH41JXMN109187", Make: "Toyota", Model: "Camry", Year: 2023,
+			AllowOverCapacity: allowOver,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/vehicles", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		CreateVehicle(w, req)
+		return w
+	}
+
+	if w := create(false); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a full location, got %d", http.StatusConflict, w.Code)
+	}
+
+	w := create(true)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Warning") == "" {
+		t.Error("Expected a Warning header when exceeding capacity")
+	}
+
+	occupancy, err := locationOccupancy(db, loc)
+	if err != nil {
+		t.Fatalf("Failed to count occupancy: %v", err)
+	}
+	if occupancy.Assigned != 2 || !occupancy.OverCapacity {
+		t.Errorf("Expected 2 vehicles over capacity, got %d (over capacity: %v)", occupancy.Assigned, occupancy.OverCapacity)
+	}
+}
+
+func TestAssignParkingSpace(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	first := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	second := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "2HGBH41JXMN109187", "Toyota", "Camry", 2023)
+
+	spaceA := models.ParkingSpace{LocationID: loc.ID, Name: "A1"}
+	spaceB := models.ParkingSpace{LocationID: loc.ID, Name: "A2"}
+	db.Create(&spaceA)
+	db.Create(&spaceB)
+
+	assign := func(spaceID, vehicleID string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.AssignParkingSpaceRequest{VehicleID: vehicleID})
+		req := httptest.NewRequest(http.MethodPost, "/api/locations/"+loc.ID+"/parking-spaces/"+spaceID+"/assign", bytes.NewBuffer(body))
+		req = withURLParams(req, "id", loc.ID, "spaceId", spaceID)
+		w := httptest.NewRecorder()
+		AssignParkingSpace(w, req)
+		return w
+	}
+
+	if w := assign(spaceA.ID, first.ID); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// An occupied space cannot take a second vehicle
+	if w := assign(spaceA.ID, second.ID); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for an occupied space, got %d", http.StatusConflict, w.Code)
+	}
+
+	// Moving the vehicle frees its old space
+	if w := assign(spaceB.ID, first.ID); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	db.First(&spaceA, "id = ?", spaceA.ID)
+	if spaceA.VehicleID != nil {
+		t.Error("Expected the vehicle's previous space to be released")
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fleetpass/internal/jsonpatch"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// patchError carries the HTTP status to return when a patch cannot be applied
+type patchError struct {
+	status  int
+	message string
+}
+
+func (e *patchError) Error() string {
+	return e.message
+}
+
+// applyPatchRequest applies the PATCH body in r to current and decodes the result into patched.
+// Merge patches (RFC 7396) are accepted as application/merge-patch+json or application/json, and
+// JSON Patch (RFC 6902) as application/json-patch+json. Only keys listed in allowed may change;
+// the keys whose values actually changed are returned so callers can validate and update just those.
+func applyPatchRequest(r *http.Request, current, patched interface{}, allowed []string) ([]string, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || len(body) == 0 {
+		return nil, &patchError{http.StatusBadRequest, "Invalid request body"}
+	}
+
+	original, err := json.Marshal(current)
+	if err != nil {
+		return nil, &patchError{http.StatusInternalServerError, "Failed to encode resource"}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var result []byte
+	switch mediaType {
+	case jsonpatch.MergePatchContentType, "application/json", "":
+		result, err = jsonpatch.MergePatch(original, body)
+	case jsonpatch.JSONPatchContentType:
+		var ops []jsonpatch.Operation
+		ops, err = jsonpatch.DecodeOperations(body)
+		if err == nil {
+			result, err = jsonpatch.ApplyPatch(original, ops)
+		}
+	default:
+		return nil, &patchError{http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported patch content type: %s", mediaType)}
+	}
+	if err != nil {
+		return nil, &patchError{http.StatusUnprocessableEntity, err.Error()}
+	}
+
+	var before, after map[string]interface{}
+	json.Unmarshal(original, &before)
+	if err := json.Unmarshal(result, &after); err != nil {
+		return nil, &patchError{http.StatusUnprocessableEntity, "Patched document must be a JSON object"}
+	}
+
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, key := range allowed {
+		allowedSet[key] = true
+	}
+
+	// Reject changes to anything outside the allowed set, including removed or added keys
+	var changed []string
+	seen := make(map[string]bool)
+	for _, doc := range []map[string]interface{}{before, after} {
+		for key := range doc {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if reflect.DeepEqual(before[key], after[key]) {
+				continue
+			}
+			if !allowedSet[key] {
+				return nil, &patchError{http.StatusUnprocessableEntity, fmt.Sprintf("Field %s cannot be modified", key)}
+			}
+			changed = append(changed, key)
+		}
+	}
+
+	sort.Strings(changed)
+
+	if err := json.Unmarshal(result, patched); err != nil {
+		return nil, &patchError{http.StatusUnprocessableEntity, fmt.Sprintf("Invalid field value: %v", err)}
+	}
+
+	return changed, nil
+}
+
+// writePatchError writes err using the status carried by a patchError
+func writePatchError(w http.ResponseWriter, err error) {
+	if pe, ok := err.(*patchError); ok {
+		http.Error(w, pe.message, pe.status)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/payments"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+var paymentGateway payments.PaymentGateway = payments.NewFakeGateway()
+
+// InitPaymentGateway sets the gateway payments are taken through
+func InitPaymentGateway(g payments.PaymentGateway) {
+	paymentGateway = g
+}
+
+// writeGatewayError maps a gateway failure to a response
+func writeGatewayError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, payments.ErrInvalidAmount):
+		http.Error(w, "Amount is not available on this payment", http.StatusBadRequest)
+	case errors.Is(err, payments.ErrInvalidState), errors.Is(err, payments.ErrNotFound):
+		http.Error(w, "Payment cannot be changed in its current state", http.StatusConflict)
+	default:
+		http.Error(w, "Payment gateway error", http.StatusBadGateway)
+	}
+}
+
+// addPaymentEntry appends a gateway operation to the rental's ledger
+func addPaymentEntry(tx *gorm.DB, payment *models.Payment, entryType models.PaymentEntryType, result payments.Result, reason string, userID *string) error {
+	return tx.Create(&models.PaymentEntry{
+		PaymentID:            payment.ID,
+		RentalID:             payment.RentalID,
+		Type:                 entryType,
+		Amount:               models.NewMoney(result.Amount, payment.Currency),
+		GatewayTransactionID: result.TransactionID,
+		Reason:               reason,
+		CreatedBy:            userID,
+	}).Error
+}
+
+// capturePayment takes amount (all of it if zero) from an authorized payment
+func capturePayment(ctx context.Context, payment *models.Payment, amount float64, userID *string) error {
+	if amount == 0 {
+		amount = payment.Amount.Float()
+	}
+	result, err := paymentGateway.Capture(ctx, *payment.GatewayReference, amount)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	payment.Status = models.PaymentStatusCaptured
+	payment.CapturedAmount = models.NewMoney(result.Amount, payment.Currency)
+	payment.CapturedAt = &now
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(payment).Error; err != nil {
+			return err
+		}
+		return addPaymentEntry(tx, payment, models.PaymentEntryCapture, result, "", userID)
+	})
+}
+
+// voidPayment releases an authorized payment's hold
+func voidPayment(ctx context.Context, payment *models.Payment, reason string, userID *string) error {
+	result, err := paymentGateway.Void(ctx, *payment.GatewayReference)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	payment.Status = models.PaymentStatusVoided
+	payment.VoidedAt = &now
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(payment).Error; err != nil {
+			return err
+		}
+		return addPaymentEntry(tx, payment, models.PaymentEntryVoid, result, reason, userID)
+	})
+}
+
+// refundPayment gives back amount (everything outstanding if zero) of a captured payment
+func refundPayment(ctx context.Context, payment *models.Payment, amount float64, reason string, userID *string) error {
+	if amount == 0 {
+		amount = payment.CapturedAmount.Sub(payment.RefundedAmount).Float()
+	}
+	result, err := paymentGateway.Refund(ctx, *payment.GatewayReference, amount)
+	if err != nil {
+		return err
+	}
+
+	payment.RefundedAmount = payment.RefundedAmount.Add(models.NewMoney(result.Amount, payment.Currency))
+	payment.Status = models.PaymentStatusPartiallyRefunded
+	if payment.RefundedAmount.Amount >= payment.CapturedAmount.Amount {
+		payment.Status = models.PaymentStatusRefunded
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(payment).Error; err != nil {
+			return err
+		}
+		return addPaymentEntry(tx, payment, models.PaymentEntryRefund, result, reason, userID)
+	})
+}
+
+// releaseRentalHolds voids the rental's payments of the given kinds that still hold funds.
+// Every hold is attempted; the first failure is returned.
+func releaseRentalHolds(ctx context.Context, rentalID string, kinds []models.PaymentKind, reason string, userID *string) error {
+	var held []models.Payment
+	if err := database.DB.Where("rental_id = ? AND status = ? AND kind IN ?", rentalID, models.PaymentStatusAuthorized, kinds).
+		Find(&held).Error; err != nil {
+		return err
+	}
+
+	var firstErr error
+	for i := range held {
+		if err := voidPayment(ctx, &held[i], reason, userID); err != nil {
+			log.Printf("Failed to release payment %s on rental %s: %v", held[i].ID, rentalID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// findPayment loads the payment named in the URL, writing a 404 if it is missing
+func findPayment(w http.ResponseWriter, r *http.Request) (*models.Payment, bool) {
+	var payment models.Payment
+	if err := database.DB.First(&payment, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Payment not found", http.StatusNotFound)
+		return nil, false
+	}
+	return &payment, true
+}
+
+// GetRentalPayments returns a rental's payments, its ledger of gateway operations and totals
+func GetRentalPayments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+
+	ledger := models.RentalPayments{RentalID: id, Payments: []models.Payment{}, Entries: []models.PaymentEntry{}}
+	if err := database.DB.Where("rental_id = ?", id).Order("created_at").Find(&ledger.Payments).Error; err != nil {
+		http.Error(w, "Failed to fetch payments", http.StatusInternalServerError)
+		return
+	}
+	if err := database.DB.Where("rental_id = ?", id).Order("created_at").Find(&ledger.Entries).Error; err != nil {
+		http.Error(w, "Failed to fetch payments", http.StatusInternalServerError)
+		return
+	}
+
+	zero := models.Money{Currency: rental.Currency}
+	ledger.Captured, ledger.Refunded, ledger.Held = zero, zero, zero
+	for _, payment := range ledger.Payments {
+		ledger.Captured = ledger.Captured.Add(payment.CapturedAmount)
+		ledger.Refunded = ledger.Refunded.Add(payment.RefundedAmount)
+		if payment.Held() {
+			ledger.Held = ledger.Held.Add(payment.Amount)
+		}
+	}
+	ledger.Net = ledger.Captured.Sub(ledger.Refunded)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledger)
+}
+
+func GetPayment(w http.ResponseWriter, r *http.Request) {
+	payment, ok := findPayment(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// CreatePayment takes a payment on a rental. Charges are captured straight away unless
+// capture is false; deposits are held until check-in releases them. A declined card is
+// recorded as a failed payment and answered with 402. An Idempotency-Key header is passed to
+// the gateway and stored on the payment, so a retried request answers with the payment the
+// first one recorded instead of authorizing twice. An authorization that cannot be recorded is
+// voided again.
+func CreatePayment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreatePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Kind == "" {
+		req.Kind = models.PaymentKindCharge
+	}
+	if req.Kind != models.PaymentKindCharge && req.Kind != models.PaymentKindDeposit {
+		http.Error(w, fmt.Sprintf("invalid kind: %s", req.Kind), http.StatusBadRequest)
+		return
+	}
+	if req.PaymentMethod == "" {
+		http.Error(w, "payment_method is required", http.StatusBadRequest)
+		return
+	}
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if idempotencyKey != "" {
+		var existing models.Payment
+		err := database.DB.First(&existing, "idempotency_key = ?", idempotencyKey).Error
+		if err == nil {
+			writeRecordedPayment(w, &existing, rental.ID)
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Failed to fetch payments", http.StatusInternalServerError)
+			return
+		}
+	}
+	if rental.Status == models.RentalStatusCancelled {
+		http.Error(w, "Rental is cancelled", http.StatusConflict)
+		return
+	}
+	if req.Kind == models.PaymentKindDeposit && rental.Status == models.RentalStatusCompleted {
+		http.Error(w, "Deposits cannot be taken on a returned rental", http.StatusConflict)
+		return
+	}
+	// Payments are in the rental's currency so the ledger and invoice balance add up
+	req.Currency = strings.ToUpper(req.Currency)
+	if req.Currency == "" {
+		req.Currency = rental.Currency
+	}
+	if req.Currency != rental.Currency {
+		http.Error(w, fmt.Sprintf("currency must be the rental's currency, %s", rental.Currency), http.StatusBadRequest)
+		return
+	}
+	req.Amount = models.RoundMoney(req.Amount, req.Currency)
+	if req.Amount <= 0 {
+		http.Error(w, "amount must be positive", http.StatusBadRequest)
+		return
+	}
+
+	userID := currentUserID(r)
+	payment := models.Payment{
+		OrganizationID: rental.OrganizationID,
+		RentalID:       rental.ID,
+		Kind:           req.Kind,
+		Amount:         models.NewMoney(req.Amount, req.Currency),
+		Currency:       req.Currency,
+		Description:    req.Description,
+		Gateway:        paymentGateway.Name(),
+		PaymentMethod:  req.PaymentMethod,
+		CreatedBy:      userID,
+	}
+	if idempotencyKey != "" {
+		payment.IdempotencyKey = &idempotencyKey
+	}
+
+	result, err := paymentGateway.Authorize(r.Context(), payments.AuthorizeRequest{
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		PaymentMethod:  req.PaymentMethod,
+		Description:    req.Description,
+		IdempotencyKey: idempotencyKey,
+	})
+	if errors.Is(err, payments.ErrDeclined) {
+		payment.Status = models.PaymentStatusFailed
+		payment.FailureReason = result.DeclineReason
+		result.Amount = req.Amount
+		if err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&payment).Error; err != nil {
+				return err
+			}
+			return addPaymentEntry(tx, &payment, models.PaymentEntryDecline, result, result.DeclineReason, userID)
+		}); err != nil {
+			log.Printf("Failed to record declined payment on rental %s: %v", rental.ID, err)
+		}
+		http.Error(w, "Payment declined: "+result.DeclineReason, http.StatusPaymentRequired)
+		return
+	}
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	now := time.Now()
+	payment.Status = models.PaymentStatusAuthorized
+	payment.GatewayReference = &result.Reference
+	payment.AuthorizedAt = &now
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		return addPaymentEntry(tx, &payment, models.PaymentEntryAuthorization, result, "", userID)
+	}); err != nil {
+		// A concurrent retry already recorded this authorization; voiding it would release that payment
+		var existing models.Payment
+		if database.DB.First(&existing, "gateway_reference = ?", result.Reference).Error == nil {
+			writeRecordedPayment(w, &existing, rental.ID)
+			return
+		}
+		// An authorization with no payment on record would hold the customer's money unseen
+		if _, voidErr := paymentGateway.Void(r.Context(), result.Reference); voidErr != nil {
+			log.Printf("Failed to void unrecorded authorization %s on rental %s: %v", result.Reference, rental.ID, voidErr)
+		}
+		http.Error(w, "Failed to record payment", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Kind == models.PaymentKindCharge && (req.Capture == nil || *req.Capture) {
+		if err := capturePayment(r.Context(), &payment, 0, userID); err != nil {
+			writeGatewayError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payment)
+}
+
+// writeRecordedPayment answers a retried CreatePayment with the payment the first request
+// recorded: a declined one with 402 again, and one taken on another rental with 409
+func writeRecordedPayment(w http.ResponseWriter, payment *models.Payment, rentalID string) {
+	if payment.RentalID != rentalID {
+		http.Error(w, "Idempotency-Key was already used for another rental's payment", http.StatusConflict)
+		return
+	}
+	if payment.Status == models.PaymentStatusFailed {
+		http.Error(w, "Payment declined: "+payment.FailureReason, http.StatusPaymentRequired)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// CapturePayment takes some or all of an authorized payment
+func CapturePayment(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can capture payments", http.StatusForbidden)
+		return
+	}
+
+	var req models.CapturePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payment, ok := findPayment(w, r)
+	if !ok {
+		return
+	}
+
+	if !payment.Held() {
+		http.Error(w, fmt.Sprintf("Payment is %s and cannot be captured", payment.Status), http.StatusConflict)
+		return
+	}
+	amount := models.NewMoney(req.Amount, payment.Currency)
+	if req.Amount < 0 || amount.Amount > payment.Amount.Amount {
+		http.Error(w, "amount must be between zero and the authorized amount", http.StatusBadRequest)
+		return
+	}
+
+	if err := capturePayment(r.Context(), payment, amount.Float(), currentUserID(r)); err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// VoidPayment releases an authorized payment without taking any of it
+func VoidPayment(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can void payments", http.StatusForbidden)
+		return
+	}
+
+	payment, ok := findPayment(w, r)
+	if !ok {
+		return
+	}
+
+	if !payment.Held() {
+		http.Error(w, fmt.Sprintf("Payment is %s and cannot be voided", payment.Status), http.StatusConflict)
+		return
+	}
+
+	if err := voidPayment(r.Context(), payment, "", currentUserID(r)); err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// RefundPayment returns some or all of a captured payment
+func RefundPayment(w http.ResponseWriter, r *http.Request) {
+	if !currentUserIsStaff(r) {
+		http.Error(w, "Only staff can refund payments", http.StatusForbidden)
+		return
+	}
+
+	var req models.RefundPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payment, ok := findPayment(w, r)
+	if !ok {
+		return
+	}
+
+	if payment.Status != models.PaymentStatusCaptured && payment.Status != models.PaymentStatusPartiallyRefunded {
+		http.Error(w, fmt.Sprintf("Payment is %s and cannot be refunded", payment.Status), http.StatusConflict)
+		return
+	}
+	refundable := payment.CapturedAmount.Sub(payment.RefundedAmount)
+	amount := models.NewMoney(req.Amount, payment.Currency)
+	if req.Amount < 0 || amount.Amount > refundable.Amount {
+		http.Error(w, fmt.Sprintf("amount must be between zero and the %.*f not yet refunded", models.CurrencyDigits(payment.Currency), refundable.Float()), http.StatusBadRequest)
+		return
+	}
+
+	if err := refundPayment(r.Context(), payment, amount.Float(), req.Reason, currentUserID(r)); err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/payments"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRentalPayments_DepositHold(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+	InitPaymentGateway(payments.NewFakeGateway())
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	pickupAt := time.Now().UTC().AddDate(0, 0, 1)
+	rental := models.Rental{
+		OrganizationID:   org.ID,
+		VehicleID:        &vehicle.ID,
+		PickupLocationID: loc.ID,
+		ReturnLocationID: loc.ID,
+		Status:           models.RentalStatusReserved,
+		PickupAt:         pickupAt,
+		ReturnAt:         pickupAt.AddDate(0, 0, 3),
+	}
+	db.Create(&rental)
+
+	pay := func(req models.CreatePaymentRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPost, "/api/rentals/"+rental.ID+"/payments", bytes.NewBuffer(body))
+		httpReq = withURLParams(httpReq, "id", rental.ID)
+		w := httptest.NewRecorder()
+		CreatePayment(w, httpReq)
+		return w
+	}
+
+	// A declined card is refused and recorded as a failed payment
+	if w := pay(models.CreatePaymentRequest{Amount: 150, PaymentMethod: payments.FakeCardDeclined}); w.Code != http.StatusPaymentRequired {
+		t.Errorf("Expected status %d for a declined card, got %d", http.StatusPaymentRequired, w.Code)
+	}
+
+	if w := pay(models.CreatePaymentRequest{Amount: 150, PaymentMethod: "tok_visa"}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	w := pay(models.CreatePaymentRequest{Kind: models.PaymentKindDeposit, Amount: 300, PaymentMethod: "tok_visa"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var deposit models.Payment
+	json.NewDecoder(w.Body).Decode(&deposit)
+	if deposit.Status != models.PaymentStatusAuthorized {
+		t.Errorf("Expected the deposit to be held, got %s", deposit.Status)
+	}
+
+	// Only staff release holds by hand
+	req := withURLParams(httptest.NewRequest(http.MethodPost, "/api/payments/"+deposit.ID+"/void", nil), "id", deposit.ID)
+	w = httptest.NewRecorder()
+	VoidPayment(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d voiding without a staff role, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// Cancelling the reservation releases the hold
+	req = httptest.NewRequest(http.MethodPost, "/api/rentals/"+rental.ID+"/cancel", nil)
+	req = withURLParams(req, "id", rental.ID)
+	CancelRental(httptest.NewRecorder(), req)
+
+	db.First(&deposit, "id = ?", deposit.ID)
+	if deposit.Status != models.PaymentStatusVoided {
+		t.Errorf("Expected the deposit to be voided, got %s", deposit.Status)
+	}
+
+	req = withURLParams(httptest.NewRequest(http.MethodGet, "/api/rentals/"+rental.ID+"/payments", nil), "id", rental.ID)
+	w = httptest.NewRecorder()
+	GetRentalPayments(w, req)
+	var ledger models.RentalPayments
+	json.NewDecoder(w.Body).Decode(&ledger)
+	if ledger.Net.Amount != 15000 || ledger.Held.Amount != 0 {
+		t.Errorf("Expected 150.00 paid and nothing held, got %+v paid and %+v held", ledger.Net, ledger.Held)
+	}
+}
+
+func TestCreatePayment_IdempotentRetry(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+	InitPaymentGateway(payments.NewFakeGateway())
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	pickupAt := time.Now().UTC().AddDate(0, 0, 1)
+	rental := models.Rental{
+		OrganizationID:   org.ID,
+		VehicleID:        &vehicle.ID,
+		PickupLocationID: loc.ID,
+		ReturnLocationID: loc.ID,
+		Status:           models.RentalStatusReserved,
+		PickupAt:         pickupAt,
+		ReturnAt:         pickupAt.AddDate(0, 0, 3),
+	}
+	db.Create(&rental)
+
+	pay := func() models.Payment {
+		body := bytes.NewBufferString(`{"amount":150,"payment_method":"tok_visa"}`)
+		req := withURLParams(httptest.NewRequest(http.MethodPost, "/api/rentals/"+rental.ID+"/payments", body), "id", rental.ID)
+		req.Header.Set("Idempotency-Key", "charge-1")
+		w := httptest.NewRecorder()
+		CreatePayment(w, req)
+		if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+			t.Fatalf("Expected the payment to be taken, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var payment models.Payment
+		json.NewDecoder(w.Body).Decode(&payment)
+		return payment
+	}
+
+	first, retry := pay(), pay()
+	if retry.ID != first.ID {
+		t.Errorf("Expected the retry to return payment %s, got %s", first.ID, retry.ID)
+	}
+	var count int64
+	db.Model(&models.Payment{}).Where("rental_id = ?", rental.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 payment after a retry, got %d", count)
+	}
+}
@@ -0,0 +1,480 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func parseRuleDate(value *string, field string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be in YYYY-MM-DD format", field)
+	}
+	return &date, nil
+}
+
+// pricingRuleFromRequest copies a request onto a rule, parsing its dates
+func pricingRuleFromRequest(rule *models.PricingRule, req *models.CreatePricingRuleRequest) error {
+	startDate, err := parseRuleDate(req.StartDate, "start_date")
+	if err != nil {
+		return err
+	}
+	endDate, err := parseRuleDate(req.EndDate, "end_date")
+	if err != nil {
+		return err
+	}
+
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.Priority = req.Priority
+	rule.Adjustment = req.Adjustment
+	rule.Value = req.Value
+	if req.Currency != "" {
+		rule.Currency = req.Currency
+	}
+	rule.Exclusive = req.Exclusive
+	rule.StartDate = startDate
+	rule.EndDate = endDate
+	rule.DaysOfWeek = models.StringArray(req.DaysOfWeek)
+	rule.OnHolidays = req.OnHolidays
+	rule.MinLeadDays = req.MinLeadDays
+	rule.MaxLeadDays = req.MaxLeadDays
+	rule.MinRentalDays = req.MinRentalDays
+	rule.MaxRentalDays = req.MaxRentalDays
+	rule.LocationIDs = models.StringArray(req.LocationIDs)
+	rule.VehicleClassIDs = models.StringArray(req.VehicleClassIDs)
+	rule.BodyStyles = models.StringArray(req.BodyStyles)
+	return nil
+}
+
+func validateDayBounds(field string, min, max *int) error {
+	if (min != nil && *min < 0) || (max != nil && *max < 0) {
+		return fmt.Errorf("min_%s and max_%s cannot be negative", field, field)
+	}
+	if min != nil && max != nil && *min > *max {
+		return fmt.Errorf("min_%s cannot exceed max_%s", field, field)
+	}
+	return nil
+}
+
+func validatePricingRule(rule *models.PricingRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	valid := false
+	for _, adjustment := range models.PricingAdjustments {
+		valid = valid || rule.Adjustment == adjustment
+	}
+	if !valid {
+		return fmt.Errorf("invalid adjustment: %s", rule.Adjustment)
+	}
+	if rule.Adjustment == models.PricingAdjustmentRate && rule.Value < 0 {
+		return fmt.Errorf("a rate cannot be negative")
+	}
+	if rule.Adjustment == models.PricingAdjustmentPercent && rule.Value < -100 {
+		return fmt.Errorf("a percentage cannot take off more than 100")
+	}
+	if err := validateCurrency(&rule.Currency); err != nil {
+		return err
+	}
+	if rule.StartDate != nil && rule.EndDate != nil && rule.EndDate.Before(*rule.StartDate) {
+		return fmt.Errorf("end_date cannot be before start_date")
+	}
+	for _, day := range rule.DaysOfWeek {
+		if _, ok := models.Weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid day of week: %s", day)
+		}
+	}
+	if err := validateDayBounds("lead_days", rule.MinLeadDays, rule.MaxLeadDays); err != nil {
+		return err
+	}
+	if err := validateDayBounds("rental_days", rule.MinRentalDays, rule.MaxRentalDays); err != nil {
+		return err
+	}
+	for _, locationID := range rule.LocationIDs {
+		var location models.Location
+		if err := database.DB.First(&location, "id = ?", locationID).Error; err != nil {
+			return fmt.Errorf("location not found: %s", locationID)
+		}
+		if location.OrganizationID != rule.OrganizationID {
+			return fmt.Errorf("location %s belongs to a different organization", locationID)
+		}
+	}
+	for _, classID := range rule.VehicleClassIDs {
+		if err := checkVehicleClassID(rule.OrganizationID, &classID); err != nil {
+			return fmt.Errorf("%v: %s", err, classID)
+		}
+	}
+	return nil
+}
+
+// GetPricingRules lists pricing rules by priority, optionally filtered by organization_id
+func GetPricingRules(w http.ResponseWriter, r *http.Request) {
+	query := database.DB.Order("priority DESC, name")
+	if organizationID := r.URL.Query().Get("organization_id"); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+
+	var rules []models.PricingRule
+	if err := query.Find(&rules).Error; err != nil {
+		http.Error(w, "Failed to fetch pricing rules", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+func GetPricingRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var rule models.PricingRule
+	if err := database.DB.First(&rule, "id = ?", id).Error; err != nil {
+		http.Error(w, "Pricing rule not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func CreatePricingRule(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePricingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.OrganizationID == "" {
+		http.Error(w, "Organization ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var organization models.Organization
+	if err := database.DB.First(&organization, "id = ?", req.OrganizationID).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusBadRequest)
+		return
+	}
+
+	rule := models.PricingRule{OrganizationID: req.OrganizationID, Currency: organization.Currency, IsActive: true}
+	if err := pricingRuleFromRequest(&rule, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validatePricingRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DB.Create(&rule).Error; err != nil {
+		http.Error(w, "Failed to create pricing rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func UpdatePricingRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.UpdatePricingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var rule models.PricingRule
+	if err := database.DB.First(&rule, "id = ?", id).Error; err != nil {
+		http.Error(w, "Pricing rule not found", http.StatusNotFound)
+		return
+	}
+
+	// A rule stays with its organization
+	if err := pricingRuleFromRequest(&rule, &req.CreatePricingRuleRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rule.IsActive = req.IsActive
+	if err := validatePricingRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DB.Save(&rule).Error; err != nil {
+		http.Error(w, "Failed to update pricing rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func DeletePricingRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	result := database.DB.Delete(&models.PricingRule{}, "id = ?", id)
+	if result.Error != nil {
+		http.Error(w, "Failed to delete pricing rule", http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		http.Error(w, "Pricing rule not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// localDate is the calendar date of t at the location, as midnight UTC
+func localDate(t time.Time, zone *time.Location) time.Time {
+	local := t.In(zone)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// rentalPricing prices each day of a booking of the vehicle picked up at the location, under
+// the organization's active pricing rules
+func rentalPricing(vehicle *models.Vehicle, location *models.Location, bookedAt, pickupAt, returnAt time.Time) ([]models.PricedDay, error) {
+	var rules []models.PricingRule
+	if err := database.DB.Where("organization_id = ? AND is_active = ?", vehicle.OrganizationID, true).
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	zone := location.Zone()
+	firstDay := localDate(pickupAt, zone)
+	holidays, err := locationHolidays(database.DB, location.ID, firstDay)
+	if err != nil {
+		return nil, err
+	}
+	return priceDays(vehicle, location, rules, holidays, bookedAt, pickupAt, rentalDays(pickupAt, returnAt)), nil
+}
+
+// priceDays prices the given number of days from pickup, each a day of a booking that long
+func priceDays(vehicle *models.Vehicle, location *models.Location, rules []models.PricingRule, holidays []models.LocationHoliday, bookedAt, pickupAt time.Time, days int) []models.PricedDay {
+	zone := location.Zone()
+	firstDay := localDate(pickupAt, zone)
+	leadDays := int(firstDay.Sub(localDate(bookedAt, zone)).Hours() / 24)
+	if leadDays < 0 {
+		leadDays = 0
+	}
+
+	holiday := make(map[string]bool, len(holidays))
+	for _, h := range holidays {
+		holiday[h.Date.Format("2006-01-02")] = true
+	}
+
+	classID := ""
+	if vehicle.VehicleClassID != nil {
+		classID = *vehicle.VehicleClassID
+	}
+
+	// Rules adjust each day's share of the weekly and monthly rates the booking is charged at
+	bases := dailyBaseRates(vehicle, days)
+	priced := make([]models.PricedDay, 0, days)
+	for i := 0; i < days; i++ {
+		date := firstDay.AddDate(0, 0, i)
+		priced = append(priced, models.PriceDay(bases[i], rules, models.PricingDay{
+			Date:           date,
+			Holiday:        holiday[date.Format("2006-01-02")],
+			LeadDays:       leadDays,
+			RentalDays:     days,
+			LocationID:     location.ID,
+			VehicleClassID: classID,
+			BodyStyle:      vehicle.BodyStyle,
+			Currency:       location.Currency,
+		}))
+	}
+	return priced
+}
+
+// GetVehicleRateCalendar previews a vehicle's effective daily rate across a calendar month.
+// Each day is priced as the pickup day of a booking made today, or on booked_at, that lasts
+// rental_days (1 by default) from location_id (the vehicle's location by default). Rates are
+// in the location's currency, or converted into currency at the exchange rate on booked_at.
+func GetVehicleRateCalendar(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+	vehicle, err := vehicleRates(vehicle, nil)
+	if err != nil {
+		http.Error(w, "Failed to fetch vehicle class", http.StatusInternalServerError)
+		return
+	}
+
+	locationID := vehicle.LocationID
+	if value := r.URL.Query().Get("location_id"); value != "" {
+		locationID = value
+	}
+	var location models.Location
+	if err := database.DB.First(&location, "id = ? AND organization_id = ?", locationID, vehicle.OrganizationID).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusBadRequest)
+		return
+	}
+	zone := location.Zone()
+
+	now := time.Now().In(zone)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, zone)
+	if value := r.URL.Query().Get("month"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, zone)
+		if err != nil {
+			http.Error(w, "month must be in YYYY-MM format", http.StatusBadRequest)
+			return
+		}
+		month = parsed
+	}
+
+	bookedAt := now
+	if value := r.URL.Query().Get("booked_at"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, zone)
+		if err != nil {
+			http.Error(w, "booked_at must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		bookedAt = parsed
+	}
+
+	days := 1
+	if value := r.URL.Query().Get("rental_days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 365 {
+			http.Error(w, "rental_days must be between 1 and 365", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	currency, exchangeRate, ok := requestedExchangeRate(w, r, vehicle.OrganizationID, location.Currency, bookedAt)
+	if !ok {
+		return
+	}
+
+	var rules []models.PricingRule
+	if err := database.DB.Where("organization_id = ? AND is_active = ?", vehicle.OrganizationID, true).
+		Find(&rules).Error; err != nil {
+		http.Error(w, "Failed to fetch pricing rules", http.StatusInternalServerError)
+		return
+	}
+	holidays, err := locationHolidays(database.DB, location.ID, month)
+	if err != nil {
+		http.Error(w, "Failed to fetch holidays", http.StatusInternalServerError)
+		return
+	}
+
+	calendar := models.RateCalendar{
+		VehicleID:    vehicle.ID,
+		LocationID:   location.ID,
+		Month:        month.Format("2006-01"),
+		RentalDays:   days,
+		BookedAt:     bookedAt.Format("2006-01-02"),
+		Currency:     currency,
+		ExchangeRate: exchangeRate,
+		Days:         []models.PricedDay{},
+	}
+	for day := month; day.Month() == month.Month(); day = day.AddDate(0, 0, 1) {
+		priced := priceDays(&vehicle, &location, rules, holidays, bookedAt, day, days)[0]
+		priced.BaseRate = models.NewMoney(priced.BaseRate, location.Currency).Convert(currency, exchangeRate).Float()
+		priced.Rate = models.NewMoney(priced.Rate, location.Currency).Convert(currency, exchangeRate).Float()
+		calendar.Days = append(calendar.Days, priced)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calendar)
+}
+
+// GetVehicleQuote prices a booking of the vehicle from pickup_at to return_at (RFC 3339 times)
+// made now and picked up at pickup_location_id (the vehicle's location by default), with that
+// location's taxes and in its currency. With currency the total is also given converted at
+// today's exchange rate.
+func GetVehicleQuote(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	pickupAt, err := time.Parse(time.RFC3339, r.URL.Query().Get("pickup_at"))
+	if err != nil {
+		http.Error(w, "pickup_at must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+	returnAt, err := time.Parse(time.RFC3339, r.URL.Query().Get("return_at"))
+	if err != nil {
+		http.Error(w, "return_at must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+	if !returnAt.After(pickupAt) {
+		http.Error(w, "return_at must be after pickup_at", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+	vehicle, err = vehicleRates(vehicle, nil)
+	if err != nil {
+		http.Error(w, "Failed to fetch vehicle class", http.StatusInternalServerError)
+		return
+	}
+
+	locationID := vehicle.LocationID
+	if value := r.URL.Query().Get("pickup_location_id"); value != "" {
+		locationID = value
+	}
+	var pickup models.Location
+	if err := database.DB.First(&pickup, "id = ? AND organization_id = ?", locationID, vehicle.OrganizationID).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	currency, exchangeRate, ok := requestedExchangeRate(w, r, vehicle.OrganizationID, pickup.Currency, now)
+	if !ok {
+		return
+	}
+
+	days, err := rentalPricing(&vehicle, &pickup, now, pickupAt, returnAt)
+	if err != nil {
+		http.Error(w, "Failed to price booking", http.StatusInternalServerError)
+		return
+	}
+
+	quote := models.Quote{
+		VehicleID:        vehicle.ID,
+		PickupLocationID: pickup.ID,
+		PickupAt:         pickupAt.UTC(),
+		ReturnAt:         returnAt.UTC(),
+		Currency:         pickup.Currency,
+		Lines:            rentalQuote(vehicleDescription(&vehicle), pickup.Currency, &vehicle, days),
+	}
+	var subtotal float64
+	for _, line := range quote.Lines {
+		subtotal += line.Amount
+	}
+	var taxTotal float64
+	quote.Taxes, taxTotal = invoiceTaxes(quote.Lines, pickup.Taxes, pickup.Currency)
+	quote.Subtotal = models.NewMoney(subtotal, pickup.Currency)
+	quote.TaxTotal = models.NewMoney(taxTotal, pickup.Currency)
+	quote.Total = models.Money{Amount: quote.Subtotal.Amount + quote.TaxTotal.Amount, Currency: pickup.Currency}
+	if currency != pickup.Currency {
+		converted := quote.Total.Convert(currency, exchangeRate)
+		quote.ExchangeRate, quote.ConvertedTotal = exchangeRate, &converted
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fleetpass/internal/models"
+	"testing"
+	"time"
+)
+
+func TestPriceDays_RulesStackByPriority(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	summerStart := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	summerEnd := time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)
+
+	rules := []models.PricingRule{
+		{Name: "Summer", Priority: 10, Adjustment: models.PricingAdjustmentPercent, Value: 20, StartDate: &summerStart, EndDate: &summerEnd, IsActive: true},
+		{Name: "Weekend", Priority: 5, Adjustment: models.PricingAdjustmentAmount, Value: 10, DaysOfWeek: models.StringArray{"saturday", "sunday"}, IsActive: true},
+		{Name: "Holiday", Priority: 20, Adjustment: models.PricingAdjustmentRate, Value: 99, OnHolidays: true, Exclusive: true, IsActive: true},
+		{Name: "Long rental", Priority: 1, Adjustment: models.PricingAdjustmentPercent, Value: -10, MinRentalDays: intPtr(7), IsActive: true},
+		{Name: "Last minute", Priority: 1, Adjustment: models.PricingAdjustmentAmount, Value: 15, MaxLeadDays: intPtr(1), IsActive: true},
+		{Name: "SUVs", Priority: 1, Adjustment: models.PricingAdjustmentAmount, Value: 5, BodyStyles: models.StringArray{"SUV"}, IsActive: true},
+		{Name: "Retired", Adjustment: models.PricingAdjustmentRate, Value: 1, IsActive: false},
+	}
+	vehicle := &models.Vehicle{DailyRate: models.NewMoney(50, "USD"), BodyStyle: "Sedan"}
+	location := &models.Location{ID: "loc"}
+	holidays := []models.LocationHoliday{{Date: time.Date(2026, 7, 4, 0, 0, 0, 0, time.UTC)}}
+	bookedAt := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Thursday July 2 to Tuesday July 7: five days, booked two months out
+	pickupAt := time.Date(2026, 7, 2, 9, 0, 0, 0, time.UTC)
+	days := priceDays(vehicle, location, rules, holidays, bookedAt, pickupAt, 5)
+
+	want := []struct {
+		date string
+		rate float64
+	}{
+		{"2026-07-02", 60}, // summer
+		{"2026-07-03", 60}, // summer
+		{"2026-07-04", 99}, // the exclusive holiday rate wins
+		{"2026-07-05", 70}, // summer, then the weekend on top
+		{"2026-07-06", 60}, // summer
+	}
+	for i, day := range days {
+		if day.Date != want[i].date || day.Rate != want[i].rate {
+			t.Errorf("Day %d: expected %s at %.2f, got %s at %.2f (%v)", i, want[i].date, want[i].rate, day.Date, day.Rate, day.Rules)
+		}
+	}
+
+	// A week booked the day before pickup in May: the last minute fee, then the long rental
+	// discount, as rules of equal priority apply by name
+	days = priceDays(vehicle, location, rules, nil, time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 5, 11, 9, 0, 0, 0, time.UTC), 7)
+	if days[0].Rate != 58.5 || len(days[0].Rules) != 2 {
+		t.Errorf("Expected (50 + 15) - 10%% = 58.50 from two rules, got %.2f from %v", days[0].Rate, days[0].Rules)
+	}
+
+	// Amounts in another currency than the location's do not apply
+	days = priceDays(vehicle, &models.Location{ID: "loc", Currency: "CAD"}, rules, nil, bookedAt, time.Date(2026, 7, 4, 9, 0, 0, 0, time.UTC), 1)
+	if days[0].Rate != 60 {
+		t.Errorf("Expected only the summer percentage on a CAD Saturday, got %.2f from %v", days[0].Rate, days[0].Rules)
+	}
+}
+
+func TestRentalQuote_AdjustsPeriodRates(t *testing.T) {
+	vehicle := &models.Vehicle{Make: "Honda", Model: "Accord", Year: 2022, DailyRate: models.NewMoney(50, "USD"), WeeklyRate: models.NewMoney(280, "USD")}
+	summerStart := time.Date(2026, 7, 8, 0, 0, 0, 0, time.UTC)
+	rules := []models.PricingRule{
+		{Name: "Weekend", Adjustment: models.PricingAdjustmentAmount, Value: 10, DaysOfWeek: models.StringArray{"saturday", "sunday"}, IsActive: true},
+		{Name: "Summer", Adjustment: models.PricingAdjustmentPercent, Value: 25, StartDate: &summerStart, IsActive: true},
+	}
+
+	// Thursday July 2 for a week: still charged the weekly rate, each day's 40 share of it
+	// adjusted, 10 more on the weekend and a quarter more once summer starts on the 8th
+	pickupAt := time.Date(2026, 7, 2, 9, 0, 0, 0, time.UTC)
+	days := priceDays(vehicle, &models.Location{ID: "loc"}, rules, nil, pickupAt, pickupAt, 7)
+	lines := rentalQuote("2022 Honda Accord", "USD", vehicle, days)
+
+	if len(lines) != 3 || lines[0].Description != "2022 Honda Accord, weekly rate" || lines[0].Amount != 280 {
+		t.Fatalf("Expected the weekly rate and two adjustments, got %+v", lines)
+	}
+	if lines[1].Quantity != 2 || lines[1].UnitPrice != 10 || lines[2].Quantity != 1 || lines[2].UnitPrice != 10 {
+		t.Errorf("Expected 2 weekend days at 10 and 1 summer day at 10, got %+v", lines[1:])
+	}
+}
@@ -0,0 +1,459 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// bookingAvailability checks whether a booking can be picked up and returned at the given
+// locations and times: both locations must be open, though a return may go to a key drop
+// instead, which is flagged
+func bookingAvailability(db *gorm.DB, pickup, dropoff *models.Location, pickupAt, returnAt time.Time) (models.RentalAvailability, error) {
+	availability := models.RentalAvailability{
+		Reasons:  []string{},
+		PickupAt: pickupAt.In(pickup.Zone()),
+		ReturnAt: returnAt.In(dropoff.Zone()),
+	}
+	unavailable := func(format string, args ...interface{}) {
+		availability.Reasons = append(availability.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	if !returnAt.After(pickupAt) {
+		unavailable("return must be after pickup")
+	}
+
+	pickupHolidays, err := locationHolidays(db, pickup.ID, pickupAt.In(pickup.Zone()))
+	if err != nil {
+		return availability, err
+	}
+	if !pickup.OpenAt(pickupAt, pickupHolidays) {
+		unavailable("%s is closed at pickup time %s", pickup.Name, availability.PickupAt.Format("Mon Jan 2 15:04 MST"))
+	}
+
+	returnHolidays, err := locationHolidays(db, dropoff.ID, returnAt.In(dropoff.Zone()))
+	if err != nil {
+		return availability, err
+	}
+	if !dropoff.OpenAt(returnAt, returnHolidays) {
+		if dropoff.AllowsKeyDrop {
+			availability.AfterHoursReturn = true
+		} else {
+			unavailable("%s is closed at return time %s", dropoff.Name, availability.ReturnAt.Format("Mon Jan 2 15:04 MST"))
+		}
+	}
+	return availability, nil
+}
+
+// vehicleBooked reports whether a reservation or active rental holds the vehicle for part of
+// the period. The rental named by excludeID is ignored.
+func vehicleBooked(db *gorm.DB, vehicleID string, pickupAt, returnAt time.Time, excludeID string) (bool, error) {
+	query := db.Model(&models.Rental{}).
+		Where("vehicle_id = ? AND status IN ? AND pickup_at < ? AND return_at > ?",
+			vehicleID, models.RentalStatusesHoldingVehicle, returnAt, pickupAt)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var overlapping int64
+	err := query.Count(&overlapping).Error
+	return overlapping > 0, err
+}
+
+// rentalAvailability checks whether the vehicle can be booked: the booking's locations must
+// be open and the vehicle must be in service with no overlapping booking. The rental named by
+// excludeID is ignored when looking for overlaps. A booking passes its transaction as db so
+// the checks read behind its locks.
+func rentalAvailability(db *gorm.DB, vehicle *models.Vehicle, pickup, dropoff *models.Location, pickupAt, returnAt time.Time, excludeID string) (models.RentalAvailability, error) {
+	availability, err := bookingAvailability(db, pickup, dropoff, pickupAt, returnAt)
+	if err != nil {
+		return availability, err
+	}
+	unavailable := func(format string, args ...interface{}) {
+		availability.Reasons = append(availability.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	switch {
+	case vehicle.Status == models.VehicleStatusInactive:
+		unavailable("vehicle is inactive")
+	case vehicle.Status == models.VehicleStatusInTransit:
+		unavailable("vehicle is in transit")
+	case !vehicle.IsEligibleForService:
+		unavailable("vehicle is not eligible for service")
+	}
+	if vehicle.LocationID != pickup.ID {
+		unavailable("vehicle is not at the pickup location")
+	}
+	if err := checkVehicleRentable(db, vehicle.ID); err != nil {
+		if !errors.Is(err, errVehicleInMaintenance) {
+			return availability, err
+		}
+		unavailable("vehicle has open maintenance")
+	}
+
+	booked, err := vehicleBooked(db, vehicle.ID, pickupAt, returnAt, excludeID)
+	if err != nil {
+		return availability, err
+	}
+	if booked {
+		unavailable("vehicle is already booked for part of this period")
+	}
+	// A vehicle of a class also answers for the class's unassigned reservations
+	if vehicle.VehicleClassID != nil && len(availability.Reasons) == 0 {
+		var class models.VehicleClass
+		if err := db.First(&class, "id = ?", *vehicle.VehicleClassID).Error; err != nil {
+			return availability, err
+		}
+		classAvailable, err := classAvailability(db, &class, pickup.ID, pickupAt, returnAt)
+		if err != nil {
+			return availability, err
+		}
+		if classAvailable.Available < 1 {
+			unavailable("every %s vehicle is needed for reservations of the class", class.Code)
+		}
+	}
+
+	availability.Available = len(availability.Reasons) == 0
+	return availability, nil
+}
+
+// rentalLocations loads the pickup and return locations for a booking in the organization,
+// defaulting the pickup to defaultPickupID and the return to the pickup. Both must price in the
+// same currency.
+func rentalLocations(organizationID, defaultPickupID, pickupID, returnID string) (*models.Location, *models.Location, error) {
+	if pickupID == "" {
+		pickupID = defaultPickupID
+	}
+	if returnID == "" {
+		returnID = pickupID
+	}
+
+	var pickup, dropoff models.Location
+	if err := database.DB.First(&pickup, "id = ?", pickupID).Error; err != nil {
+		return nil, nil, fmt.Errorf("pickup location not found")
+	}
+	if err := database.DB.First(&dropoff, "id = ?", returnID).Error; err != nil {
+		return nil, nil, fmt.Errorf("return location not found")
+	}
+	if pickup.OrganizationID != organizationID || dropoff.OrganizationID != organizationID {
+		return nil, nil, fmt.Errorf("locations must belong to the vehicle's organization")
+	}
+	// A one-way return leaves the vehicle at the return location, priced in its currency
+	if err := checkSameCurrency(&pickup, &dropoff); err != nil {
+		return nil, nil, err
+	}
+	return &pickup, &dropoff, nil
+}
+
+// localizeRentals shows each rental's pickup and return times in its locations' time zones
+func localizeRentals(rentals []models.Rental) {
+	zones := make(map[string]*time.Location)
+	zone := func(locationID string) *time.Location {
+		if z, ok := zones[locationID]; ok {
+			return z
+		}
+		var location models.Location
+		z := time.UTC
+		if err := database.DB.Unscoped().First(&location, "id = ?", locationID).Error; err == nil {
+			z = location.Zone()
+		}
+		zones[locationID] = z
+		return z
+	}
+
+	for i := range rentals {
+		rentals[i].PickupAt = rentals[i].PickupAt.In(zone(rentals[i].PickupLocationID))
+		rentals[i].ReturnAt = rentals[i].ReturnAt.In(zone(rentals[i].ReturnLocationID))
+	}
+}
+
+// GetVehicleAvailability reports whether a vehicle can be booked. pickup_at and return_at are
+// RFC 3339 times; pickup_location_id and return_location_id default as for a new rental.
+func GetVehicleAvailability(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	pickupAt, err := time.Parse(time.RFC3339, r.URL.Query().Get("pickup_at"))
+	if err != nil {
+		http.Error(w, "pickup_at must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+	returnAt, err := time.Parse(time.RFC3339, r.URL.Query().Get("return_at"))
+	if err != nil {
+		http.Error(w, "return_at must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	pickup, dropoff, err := rentalLocations(vehicle.OrganizationID, vehicle.LocationID,
+		r.URL.Query().Get("pickup_location_id"), r.URL.Query().Get("return_location_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	availability, err := rentalAvailability(database.DB, &vehicle, pickup, dropoff, pickupAt, returnAt, "")
+	if err != nil {
+		http.Error(w, "Failed to check availability", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(availability)
+}
+
+// GetRentals lists rentals by pickup time, filtered by organization_id, location_id (pickup
+// or return), vehicle_id, vehicle_class_id, customer_id and status
+func GetRentals(w http.ResponseWriter, r *http.Request) {
+	query := database.DB.Order("pickup_at")
+	for _, column := range []string{"organization_id", "vehicle_id", "vehicle_class_id", "customer_id", "status"} {
+		if value := r.URL.Query().Get(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if locationID := r.URL.Query().Get("location_id"); locationID != "" {
+		query = query.Where("(pickup_location_id = ? OR return_location_id = ?)", locationID, locationID)
+	}
+
+	var rentals []models.Rental
+	if err := query.Find(&rentals).Error; err != nil {
+		http.Error(w, "Failed to fetch rentals", http.StatusInternalServerError)
+		return
+	}
+	localizeRentals(rentals)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rentals)
+}
+
+func GetRental(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+
+	rentals := []models.Rental{rental}
+	localizeRentals(rentals)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rentals[0])
+}
+
+// CreateRental reserves a vehicle, or any vehicle of a class at the pickup location, leaving
+// the vehicle to be assigned at checkout. Pickups outside the pickup location's hours are
+// rejected; returns outside the return location's hours are rejected unless it has a key drop,
+// in which case the rental is flagged as an after-hours return. The booking is priced when it
+// is made and invoiced at that price.
+func CreateRental(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRentalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if (req.VehicleID == "") == (req.VehicleClassID == "") || req.PickupAt.IsZero() || req.ReturnAt.IsZero() {
+		http.Error(w, "vehicle_id or vehicle_class_id, pickup_at and return_at are required", http.StatusBadRequest)
+		return
+	}
+	if req.PickupAt.Before(time.Now().Add(-time.Minute)) {
+		http.Error(w, "pickup_at cannot be in the past", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle *models.Vehicle
+	var class *models.VehicleClass
+	var organizationID, defaultPickupID string
+	if req.VehicleID != "" {
+		vehicle = &models.Vehicle{}
+		if err := database.DB.First(vehicle, "id = ?", req.VehicleID).Error; err != nil {
+			http.Error(w, "Vehicle not found", http.StatusBadRequest)
+			return
+		}
+		organizationID, defaultPickupID = vehicle.OrganizationID, vehicle.LocationID
+	} else {
+		class = &models.VehicleClass{}
+		if err := database.DB.First(class, "id = ? AND is_active = ?", req.VehicleClassID, true).Error; err != nil {
+			http.Error(w, "Vehicle class not found", http.StatusBadRequest)
+			return
+		}
+		if req.PickupLocationID == "" {
+			http.Error(w, "pickup_location_id is required when booking a vehicle class", http.StatusBadRequest)
+			return
+		}
+		organizationID = class.OrganizationID
+	}
+
+	pickup, dropoff, err := rentalLocations(organizationID, defaultPickupID, req.PickupLocationID, req.ReturnLocationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rental := models.Rental{
+		OrganizationID:   organizationID,
+		CustomerID:       req.CustomerID,
+		PickupLocationID: pickup.ID,
+		ReturnLocationID: dropoff.ID,
+		Status:           models.RentalStatusReserved,
+		PickupAt:         req.PickupAt.UTC(),
+		ReturnAt:         req.ReturnAt.UTC(),
+		Currency:         pickup.Currency,
+		Notes:            req.Notes,
+		CreatedBy:        currentUserID(r),
+	}
+	if vehicle != nil {
+		rental.VehicleID = &vehicle.ID
+	} else {
+		rental.VehicleClassID = &class.ID
+	}
+	// The rental is invoiced at the price it was booked at
+	rental.PricedLines, err = bookingLines(vehicle, class, pickup, time.Now(), rental.PickupAt, rental.ReturnAt)
+	if err != nil {
+		http.Error(w, "Failed to price rental", http.StatusInternalServerError)
+		return
+	}
+
+	// The customer's license is verified at pickup, but age and expiry are known now
+	if rental.CustomerID != nil {
+		problems, err := rentalDriverProblems(&rental, false)
+		if err != nil {
+			http.Error(w, "Failed to check driver", http.StatusInternalServerError)
+			return
+		}
+		if len(problems) > 0 {
+			http.Error(w, "Driver is not eligible: "+strings.Join(problems, "; "), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	// Lock the vehicle and its class so concurrent bookings cannot both pass the overlap and
+	// class availability checks
+	classID := rental.VehicleClassID
+	if vehicle != nil {
+		classID = vehicle.VehicleClassID
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if classID != nil {
+			if err := tx.Exec("SELECT id FROM vehicle_classes WHERE id = ? FOR UPDATE", *classID).Error; err != nil {
+				return err
+			}
+		}
+		var availability models.RentalAvailability
+		var err error
+		if vehicle != nil {
+			if err := tx.Exec("SELECT id FROM vehicles WHERE id = ? FOR UPDATE", vehicle.ID).Error; err != nil {
+				return err
+			}
+			availability, err = rentalAvailability(tx, vehicle, pickup, dropoff, rental.PickupAt, rental.ReturnAt, "")
+		} else {
+			availability, err = classRentalAvailability(tx, class, pickup, dropoff, rental.PickupAt, rental.ReturnAt)
+		}
+		if err != nil {
+			return err
+		}
+		if !availability.Available {
+			return &rentalUnavailableError{reasons: availability.Reasons}
+		}
+		rental.AfterHoursReturn = availability.AfterHoursReturn
+		return tx.Create(&rental).Error
+	})
+	var unavailable *rentalUnavailableError
+	if errors.As(err, &unavailable) {
+		what := "Vehicle"
+		if class != nil {
+			what = "Vehicle class"
+		}
+		http.Error(w, what+" is not available: "+strings.Join(unavailable.reasons, "; "), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create rental", http.StatusInternalServerError)
+		return
+	}
+
+	rentals := []models.Rental{rental}
+	localizeRentals(rentals)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rentals[0])
+}
+
+// rentalUnavailableError carries the reasons a booking was refused
+type rentalUnavailableError struct {
+	reasons []string
+}
+
+func (e *rentalUnavailableError) Error() string {
+	return fmt.Sprintf("vehicle is not available: %v", e.reasons)
+}
+
+// errRentalChanged is returned when a rental has left the status a change was checked against
+var errRentalChanged = errors.New("rental was changed by another request")
+
+// transitionRental saves the rental only if it is still in status from, so two requests that
+// both read it in that status cannot both move it on
+func transitionRental(tx *gorm.DB, rental *models.Rental, from models.RentalStatus) error {
+	result := tx.Model(rental).Where("status = ?", from).Select("*").Omit("created_at").Updates(rental)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errRentalChanged
+	}
+	return nil
+}
+
+// CancelRental cancels a reservation that has not been picked up and releases its payment holds
+func CancelRental(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var rental models.Rental
+	if err := database.DB.First(&rental, "id = ?", id).Error; err != nil {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+
+	if rental.Status != models.RentalStatusReserved {
+		http.Error(w, fmt.Sprintf("Rental is %s and cannot be cancelled", rental.Status), http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	rental.Status = models.RentalStatusCancelled
+	rental.CancelledAt = &now
+
+	err := transitionRental(database.DB, &rental, models.RentalStatusReserved)
+	if errors.Is(err, errRentalChanged) {
+		http.Error(w, "Rental was changed by another request; reload it and try again", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update rental", http.StatusInternalServerError)
+		return
+	}
+
+	// Nothing is owed on a cancelled reservation, so every hold on it is released
+	releaseRentalHolds(r.Context(), rental.ID, []models.PaymentKind{models.PaymentKindCharge, models.PaymentKindDeposit},
+		"rental cancelled", currentUserID(r))
+
+	rentals := []models.Rental{rental}
+	localizeRentals(rentals)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rentals[0])
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLocationOpenAt(t *testing.T) {
+	location := &models.Location{
+		TimeZone: "America/Los_Angeles",
+		OpeningHours: models.OpeningHours{
+			{Day: "monday", Opens: "08:00", Closes: "18:00"},
+			{Day: "saturday", Opens: "09:00", Closes: "13:00"},
+		},
+	}
+	holidays := []models.LocationHoliday{{Date: time.Date(2025, 12, 29, 0, 0, 0, 0, time.UTC)}}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		// 17:00 UTC is 09:00 in Los Angeles in winter
+		{"monday morning local", time.Date(2025, 12, 15, 17, 0, 0, 0, time.UTC), true},
+		// 03:00 UTC Tuesday is still 19:00 Monday local, after closing
+		{"monday evening local", time.Date(2025, 12, 16, 3, 0, 0, 0, time.UTC), false},
+		{"saturday afternoon", time.Date(2025, 12, 20, 22, 0, 0, 0, time.UTC), false},
+		{"sunday closed", time.Date(2025, 12, 21, 18, 0, 0, 0, time.UTC), false},
+		{"holiday monday", time.Date(2025, 12, 29, 18, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := location.OpenAt(tt.at, holidays); got != tt.want {
+				t.Errorf("Expected open=%v at %s, got %v", tt.want, tt.at.In(location.Zone()), got)
+			}
+		})
+	}
+
+	if err := (models.OpeningHours{{Day: "funday", Opens: "08:00", Closes: "18:00"}}).Validate(); err == nil {
+		t.Error("Expected an unknown day to be rejected")
+	}
+	if err := (models.OpeningHours{{Day: "monday", Opens: "18:00", Closes: "08:00"}}).Validate(); err == nil {
+		t.Error("Expected closing before opening to be rejected")
+	}
+}
+
+func TestCreateRental_BusinessHours(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	hours := models.OpeningHours{}
+	for day := range models.Weekdays {
+		hours = append(hours, models.OpeningPeriod{Day: day, Opens: "08:00", Closes: "18:00"})
+	}
+	db.Model(loc).Updates(map[string]interface{}{"time_zone": "UTC", "opening_hours": hours})
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	book := func(pickup, dropoff time.Time) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.CreateRentalRequest{VehicleID: vehicle.ID, PickupAt: pickup, ReturnAt: dropoff})
+		req := httptest.NewRequest(http.MethodPost, "/api/rentals", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		CreateRental(w, req)
+		return w
+	}
+
+	// Pickup at 06:00 is before opening
+	if w := book(tomorrow.Add(6*time.Hour), tomorrow.AddDate(0, 0, 2).Add(10*time.Hour)); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a pickup before opening, got %d", http.StatusConflict, w.Code)
+	}
+
+	// Return at 21:00 is after closing and there is no key drop
+	if w := book(tomorrow.Add(9*time.Hour), tomorrow.AddDate(0, 0, 2).Add(21*time.Hour)); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a return after closing, got %d", http.StatusConflict, w.Code)
+	}
+
+	// With a key drop the late return is accepted and flagged
+	db.Model(loc).Update("allows_key_drop", true)
+	w := book(tomorrow.Add(9*time.Hour), tomorrow.AddDate(0, 0, 2).Add(21*time.Hour))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var rental models.Rental
+	json.NewDecoder(w.Body).Decode(&rental)
+	if !rental.AfterHoursReturn {
+		t.Error("Expected the rental to be flagged as an after-hours return")
+	}
+
+	// The same vehicle cannot be booked twice for overlapping times
+	if w := book(tomorrow.AddDate(0, 0, 1).Add(9*time.Hour), tomorrow.AddDate(0, 0, 3).Add(10*time.Hour)); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for an overlapping booking, got %d", http.StatusConflict, w.Code)
+	}
+}
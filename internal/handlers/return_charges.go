@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fleetpass/internal/models"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func validateReturnPolicy(policy *models.ReturnPolicy) error {
+	if policy.LateGraceMinutes < 0 || policy.IncludedMilesPerDay < 0 {
+		return fmt.Errorf("late_grace_minutes and included_miles_per_day cannot be negative")
+	}
+	if policy.LateHourlyFee < 0 || policy.FuelPricePerGallon < 0 || policy.ChargePricePerKWh < 0 || policy.MileageOverageFee < 0 {
+		return fmt.Errorf("return policy fees and prices cannot be negative")
+	}
+	return nil
+}
+
+// convertReturnPolicy converts the organization's return policy fees and prices into the
+// currency of a rental at the exchange rate on the given date
+func convertReturnPolicy(db *gorm.DB, org *models.Organization, currency string, on time.Time) (models.ReturnPolicy, error) {
+	policy := org.ReturnPolicy
+	rate, err := findExchangeRate(db, org.ID, org.Currency, currency, on)
+	if err != nil {
+		return policy, err
+	}
+	for _, fee := range []*float64{&policy.LateHourlyFee, &policy.FuelPricePerGallon, &policy.ChargePricePerKWh, &policy.MileageOverageFee} {
+		*fee = models.NewMoney(*fee, org.Currency).Convert(currency, rate).Float()
+	}
+	return policy, nil
+}
+
+func isElectric(vehicle *models.Vehicle) bool {
+	return strings.EqualFold(vehicle.FuelType, "electric")
+}
+
+// bookedDailyRate is the daily rate in the rental's priced lines. A booking priced only by the
+// week or month has no daily rate line and gives its average price per day instead.
+func bookedDailyRate(rental *models.Rental) float64 {
+	var total float64
+	for _, line := range rental.PricedLines {
+		if line.Kind != "rental" {
+			continue
+		}
+		if strings.HasSuffix(line.Description, ", daily rate") {
+			return line.UnitPrice
+		}
+		total += line.Amount
+	}
+	return models.RoundMoney(total/float64(rentalDays(rental.PickupAt, rental.ReturnAt)), rental.Currency)
+}
+
+// returnCharges evaluates the organization's return policy for a rental being checked in:
+// a late fee once the return runs past the grace period, a refill for fuel or charge below
+// the pickup level and an overage for miles beyond the daily allowance. Fuel and mileage need
+// the checkout the vehicle left on; without one only lateness is charged.
+func returnCharges(policy models.ReturnPolicy, vehicle *models.Vehicle, rental *models.Rental, checkout, checkin *models.Inspection) []models.RentalCharge {
+	charges := []models.RentalCharge{}
+	add := func(kind models.RentalChargeKind, description string, quantity, unitPrice, amount float64) {
+		charges = append(charges, models.RentalCharge{
+			RentalID:     rental.ID,
+			Kind:         kind,
+			Description:  description,
+			Quantity:     quantity,
+			UnitPrice:    models.RoundMoney(unitPrice, rental.Currency),
+			Amount:       models.RoundMoney(amount, rental.Currency),
+			Taxable:      true,
+			CreatedBy:    checkin.InspectedBy,
+			InspectionID: &checkin.ID,
+		})
+	}
+
+	// Lateness counts from the booked return time once the grace period is used up, charged
+	// per started hour with each day capped at the daily rate the rental was booked at
+	late := checkin.InspectedAt.Sub(rental.ReturnAt)
+	if policy.LateHourlyFee > 0 && late.Minutes() > float64(policy.LateGraceMinutes) {
+		hours := int(math.Ceil(late.Hours()))
+		days, extra := hours/24, hours%24
+		dayFee := 24 * policy.LateHourlyFee
+		extraFee := float64(extra) * policy.LateHourlyFee
+		if dailyRate := bookedDailyRate(rental); dailyRate > 0 {
+			dayFee = math.Min(dayFee, dailyRate)
+			extraFee = math.Min(extraFee, dailyRate)
+		}
+		add(models.RentalChargeLateReturn, fmt.Sprintf("Late return, %d hours past %s", hours, rental.ReturnAt.Format("Jan 2 15:04")),
+			float64(hours), policy.LateHourlyFee, float64(days)*dayFee+extraFee)
+	}
+
+	if checkout == nil {
+		return charges
+	}
+
+	price, unit := policy.FuelPricePerGallon, "gal"
+	if isElectric(vehicle) {
+		price, unit = policy.ChargePricePerKWh, "kWh"
+	}
+	if used := checkout.FuelLevel - checkin.FuelLevel; used > 0 && price > 0 && vehicle.FuelCapacity > 0 {
+		quantity := math.Round(float64(used)/100*vehicle.FuelCapacity*100) / 100
+		add(models.RentalChargeFuel, fmt.Sprintf("Refill %.2f %s (%d%% at pickup, %d%% at return)", quantity, unit, checkout.FuelLevel, checkin.FuelLevel),
+			quantity, price, quantity*price)
+	}
+
+	if policy.IncludedMilesPerDay > 0 && policy.MileageOverageFee > 0 {
+		pickedUpAt := rental.PickupAt
+		if rental.PickedUpAt != nil {
+			pickedUpAt = *rental.PickedUpAt
+		}
+		days := rentalDays(pickedUpAt, checkin.InspectedAt)
+		allowance := days * policy.IncludedMilesPerDay
+		if over := checkin.Mileage - checkout.Mileage - allowance; over > 0 {
+			add(models.RentalChargeMileage, fmt.Sprintf("%d miles over the %d included for %d days", over, allowance, days),
+				float64(over), policy.MileageOverageFee, float64(over)*policy.MileageOverageFee)
+		}
+	}
+	return charges
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fleetpass/internal/models"
+	"testing"
+	"time"
+)
+
+func TestReturnCharges(t *testing.T) {
+	policy := models.ReturnPolicy{
+		LateGraceMinutes:    30,
+		LateHourlyFee:       15,
+		FuelPricePerGallon:  4.5,
+		ChargePricePerKWh:   0.4,
+		IncludedMilesPerDay: 100,
+		MileageOverageFee:   0.25,
+	}
+	// The vehicle's rate has gone up since the rental was booked at 50 a day
+	vehicle := &models.Vehicle{DailyRate: models.NewMoney(80, "USD"), FuelCapacity: 15, FuelType: "Gasoline"}
+	returnAt := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+	pickedUpAt := returnAt.AddDate(0, 0, -3)
+	rental := &models.Rental{ID: "rental", PickupAt: pickedUpAt, PickedUpAt: &pickedUpAt, ReturnAt: returnAt, Currency: "USD",
+		PricedLines: models.InvoiceLines{{Kind: "rental", Description: "2022 Honda Accord, daily rate", Quantity: 3, UnitPrice: 50, Amount: 150}}}
+	checkout := &models.Inspection{Mileage: 1000, FuelLevel: 100}
+
+	t.Run("late, low on fuel and over the mileage", func(t *testing.T) {
+		// 26h10m late is 27 started hours: a day capped at the daily rate and 3 hours
+		checkin := &models.Inspection{ID: "checkin", Mileage: 1650, FuelLevel: 75, InspectedAt: returnAt.Add(26*time.Hour + 10*time.Minute)}
+		charges := returnCharges(policy, vehicle, rental, checkout, checkin)
+
+		want := map[models.RentalChargeKind]float64{
+			models.RentalChargeLateReturn: 95,
+			models.RentalChargeFuel:       16.88, // 3.75 gal
+			models.RentalChargeMileage:    37.5,  // 5 days allow 500 of 650 miles
+		}
+		if len(charges) != len(want) {
+			t.Fatalf("Expected %d charges, got %+v", len(want), charges)
+		}
+		for _, charge := range charges {
+			if charge.Amount != want[charge.Kind] {
+				t.Errorf("Expected %s charge of %.2f, got %.2f", charge.Kind, want[charge.Kind], charge.Amount)
+			}
+			if charge.InspectionID == nil || *charge.InspectionID != "checkin" {
+				t.Errorf("Expected %s charge to name the checkin", charge.Kind)
+			}
+		}
+	})
+
+	t.Run("within grace and limits", func(t *testing.T) {
+		checkin := &models.Inspection{Mileage: 1200, FuelLevel: 100, InspectedAt: returnAt.Add(20 * time.Minute)}
+		if charges := returnCharges(policy, vehicle, rental, checkout, checkin); len(charges) != 0 {
+			t.Errorf("Expected no charges, got %+v", charges)
+		}
+	})
+
+	t.Run("electric vehicles pay for charge", func(t *testing.T) {
+		electric := &models.Vehicle{FuelCapacity: 80, FuelType: "Electric"}
+		checkin := &models.Inspection{Mileage: 1000, FuelLevel: 50, InspectedAt: returnAt}
+		charges := returnCharges(policy, electric, rental, checkout, checkin)
+		if len(charges) != 1 || charges[0].Quantity != 40 || charges[0].Amount != 16 {
+			t.Errorf("Expected 40 kWh for 16.00, got %+v", charges)
+		}
+	})
+
+	t.Run("a booking by the week caps lateness at its price per day", func(t *testing.T) {
+		weekly := *rental
+		weekly.PickupAt = returnAt.AddDate(0, 0, -7)
+		weekly.PricedLines = models.InvoiceLines{{Kind: "rental", Description: "2022 Honda Accord, weekly rate", Quantity: 1, UnitPrice: 280, Amount: 280}}
+		checkin := &models.Inspection{Mileage: 1000, FuelLevel: 100, InspectedAt: returnAt.Add(24 * time.Hour)}
+		charges := returnCharges(policy, vehicle, &weekly, nil, checkin)
+		if len(charges) != 1 || charges[0].Amount != 40 {
+			t.Errorf("Expected a late charge of 40.00 for a day at 280 a week, got %+v", charges)
+		}
+	})
+
+	t.Run("without a checkout only lateness is charged", func(t *testing.T) {
+		checkin := &models.Inspection{Mileage: 5000, FuelLevel: 0, InspectedAt: returnAt.Add(2 * time.Hour)}
+		charges := returnCharges(policy, vehicle, rental, nil, checkin)
+		if len(charges) != 1 || charges[0].Kind != models.RentalChargeLateReturn || charges[0].Amount != 30 {
+			t.Errorf("Expected a single late charge of 30.00, got %+v", charges)
+		}
+	})
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	// errTransferLocation is returned when a vehicle's location is changed outside a transfer
+	errTransferLocation = errors.New("vehicle location can only be changed through a transfer")
+	// errTransferStatus is returned when the in-transit status is set or cleared outside a transfer
+	errTransferStatus = errors.New("vehicle is in transit; receive or cancel its transfer instead")
+)
+
+// findTransfer loads the transfer named in the URL and its vehicle, writing an error if either is missing
+func findTransfer(w http.ResponseWriter, r *http.Request) (*models.VehicleTransfer, *models.Vehicle, bool) {
+	var transfer models.VehicleTransfer
+	if err := database.DB.First(&transfer, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Transfer not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", transfer.VehicleID).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return &transfer, &vehicle, true
+}
+
+// GetVehicleTransfers returns a vehicle's transfer history, newest first
+func GetVehicleTransfers(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	var transfers []models.VehicleTransfer
+	if err := database.DB.Where("vehicle_id = ?", id).Order("requested_at DESC").Find(&transfers).Error; err != nil {
+		http.Error(w, "Failed to fetch transfers", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+// GetLocationTransfers lists the transfers arriving at and leaving a location, newest first.
+// Only open transfers are listed unless status is given.
+func GetLocationTransfers(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	statuses := []models.TransferStatus{models.TransferStatusRequested, models.TransferStatusInTransit}
+	if status := r.URL.Query().Get("status"); status != "" {
+		statuses = []models.TransferStatus{models.TransferStatus(status)}
+	}
+
+	result := models.LocationTransfers{}
+	for _, list := range []struct {
+		column    string
+		transfers *[]models.VehicleTransfer
+	}{{"to_location_id", &result.Inbound}, {"from_location_id", &result.Outbound}} {
+		if err := database.DB.Where(list.column+" = ? AND status IN ?", id, statuses).
+			Order("requested_at DESC").Find(list.transfers).Error; err != nil {
+			http.Error(w, "Failed to fetch transfers", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func GetTransfer(w http.ResponseWriter, r *http.Request) {
+	transfer, _, ok := findTransfer(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// CreateTransfer requests a vehicle's move to another location of its organization that prices
+// in the same currency. A vehicle can only have one open transfer at a time.
+func CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.ToLocationID == "" {
+		http.Error(w, "to_location_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", req.ToLocationID).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusBadRequest)
+		return
+	}
+	if location.OrganizationID != vehicle.OrganizationID {
+		http.Error(w, "Location does not belong to the vehicle's organization", http.StatusBadRequest)
+		return
+	}
+	if location.ID == vehicle.LocationID {
+		http.Error(w, "Vehicle is already at this location", http.StatusBadRequest)
+		return
+	}
+	if !location.IsActive {
+		http.Error(w, "Location is not active", http.StatusBadRequest)
+		return
+	}
+	// The vehicle's rates are in its location's currency and cannot simply carry over
+	var from models.Location
+	if err := database.DB.First(&from, "id = ?", vehicle.LocationID).Error; err != nil {
+		http.Error(w, "Failed to create transfer", http.StatusInternalServerError)
+		return
+	}
+	if err := checkSameCurrency(&from, &location); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !checkLocationCapacity(w, &location, 1, req.AllowOverCapacity) {
+		return
+	}
+
+	var open int64
+	if err := database.DB.Model(&models.VehicleTransfer{}).
+		Where("vehicle_id = ? AND status IN ?", vehicle.ID, []models.TransferStatus{models.TransferStatusRequested, models.TransferStatusInTransit}).
+		Count(&open).Error; err != nil {
+		http.Error(w, "Failed to create transfer", http.StatusInternalServerError)
+		return
+	}
+	if open > 0 {
+		http.Error(w, "Vehicle already has an open transfer", http.StatusConflict)
+		return
+	}
+
+	transfer := models.VehicleTransfer{
+		OrganizationID: vehicle.OrganizationID,
+		VehicleID:      vehicle.ID,
+		FromLocationID: vehicle.LocationID,
+		ToLocationID:   location.ID,
+		Status:         models.TransferStatusRequested,
+		DriverName:     req.DriverName,
+		DriverPhone:    req.DriverPhone,
+		Notes:          req.Notes,
+		RequestedBy:    currentUserID(r),
+		RequestedAt:    time.Now(),
+	}
+
+	if err := database.DB.Create(&transfer).Error; err != nil {
+		http.Error(w, "Failed to create transfer", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// DepartTransfer sets a requested transfer on its way. The vehicle must be available; it is in
+// transit, and cannot be rented, until the transfer is received. The departure odometer is
+// logged as a reading.
+func DepartTransfer(w http.ResponseWriter, r *http.Request) {
+	var req models.DepartTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transfer, vehicle, ok := findTransfer(w, r)
+	if !ok {
+		return
+	}
+
+	if transfer.Status != models.TransferStatusRequested {
+		http.Error(w, fmt.Sprintf("Transfer is %s and cannot depart", transfer.Status), http.StatusConflict)
+		return
+	}
+	if vehicle.Status != models.VehicleStatusAvailable {
+		http.Error(w, fmt.Sprintf("Vehicle is %s and cannot be transferred", vehicle.Status), http.StatusConflict)
+		return
+	}
+	if req.DepartureOdometer < 0 {
+		http.Error(w, "departure_odometer cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	if req.DepartureOdometer == 0 {
+		req.DepartureOdometer = vehicle.Mileage
+	}
+	if req.DriverName != "" {
+		transfer.DriverName = req.DriverName
+		transfer.DriverPhone = req.DriverPhone
+	}
+	if transfer.DriverName == "" {
+		http.Error(w, "driver_name is required to depart", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferStatusInTransit
+	transfer.DepartureOdometer = &req.DepartureOdometer
+	transfer.DepartedAt = &now
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := recordOdometerReading(tx, vehicle, &models.OdometerReading{
+			Reading:    req.DepartureOdometer,
+			Source:     models.OdometerSourceTransfer,
+			ReadAt:     now,
+			RecordedBy: currentUserID(r),
+		}); err != nil {
+			return err
+		}
+		if err := tx.Model(vehicle).Update("status", models.VehicleStatusInTransit).Error; err != nil {
+			return err
+		}
+		// The vehicle leaves its parking space behind
+		if err := releaseParkingSpace(tx, vehicle.ID); err != nil {
+			return err
+		}
+		return tx.Save(transfer).Error
+	})
+	var invalid *odometerError
+	if errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), invalid.status())
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update transfer", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// ReceiveTransfer completes a transfer at its destination: the vehicle moves to the new
+// location and is available again, and the arrival odometer is logged as a reading.
+func ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	var req models.ReceiveTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transfer, vehicle, ok := findTransfer(w, r)
+	if !ok {
+		return
+	}
+
+	if transfer.Status != models.TransferStatusInTransit {
+		http.Error(w, fmt.Sprintf("Transfer is %s and cannot be received", transfer.Status), http.StatusConflict)
+		return
+	}
+	if req.ArrivalOdometer == 0 {
+		http.Error(w, "arrival_odometer is required", http.StatusBadRequest)
+		return
+	}
+	if transfer.DepartureOdometer != nil && req.ArrivalOdometer < *transfer.DepartureOdometer {
+		http.Error(w, "arrival_odometer cannot be lower than departure_odometer", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferStatusReceived
+	transfer.ArrivalOdometer = &req.ArrivalOdometer
+	transfer.ReceivedAt = &now
+	if req.Notes != "" {
+		transfer.Notes = req.Notes
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := recordOdometerReading(tx, vehicle, &models.OdometerReading{
+			Reading:    req.ArrivalOdometer,
+			Source:     models.OdometerSourceTransfer,
+			ReadAt:     now,
+			RecordedBy: currentUserID(r),
+		}); err != nil {
+			return err
+		}
+		if err := tx.Model(vehicle).Updates(map[string]interface{}{
+			"location_id": transfer.ToLocationID,
+			"status":      models.VehicleStatusAvailable,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Save(transfer).Error
+	})
+	var invalid *odometerError
+	if errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), invalid.status())
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update transfer", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// CancelTransfer withdraws a transfer that has not yet departed
+func CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	transfer, _, ok := findTransfer(w, r)
+	if !ok {
+		return
+	}
+
+	if transfer.Status != models.TransferStatusRequested {
+		http.Error(w, fmt.Sprintf("Transfer is %s and cannot be cancelled", transfer.Status), http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferStatusCancelled
+	transfer.CancelledAt = &now
+
+	if err := database.DB.Save(transfer).Error; err != nil {
+		http.Error(w, "Failed to update transfer", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransfer_Lifecycle(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	from := testutil.CreateTestLocation(t, db, org.ID, "Downtown", "San Francisco")
+	to := testutil.CreateTestLocation(t, db, org.ID, "Airport", "San Francisco")
+	otherOrg := testutil.CreateTestOrganization(t, db, "Other Org", "other-org")
+	elsewhere := testutil.CreateTestLocation(t, db, otherOrg.ID, "Elsewhere", "Oakland")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, from.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	post := func(handler http.HandlerFunc, id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		req = withURLParams(req, "id", id)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	// Locations of another organization are refused
+	if w := post(CreateTransfer, vehicle.ID, `{"to_location_id":"`+elsewhere.ID+`"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// So are locations that price in another currency
+	toronto := testutil.CreateTestLocation(t, db, org.ID, "Toronto", "Toronto")
+	db.Model(toronto).Update("currency", "CAD")
+	if w := post(CreateTransfer, vehicle.ID, `{"to_location_id":"`+toronto.ID+`"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a location in another currency, got %d", http.StatusBadRequest, w.Code)
+	}
+	// and a location with vehicles keeps its currency
+	req := httptest.NewRequest(http.MethodPatch, "/api/locations/"+from.ID, bytes.NewBufferString(`{"currency":"CAD"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req = withURLParams(req, "id", from.ID)
+	w := httptest.NewRecorder()
+	PatchLocation(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for changing the currency of a location with vehicles, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = post(CreateTransfer, vehicle.ID, `{"to_location_id":"`+to.ID+`","driver_name":"Sam Lee"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var transfer models.VehicleTransfer
+	json.NewDecoder(w.Body).Decode(&transfer)
+
+	// Only one open transfer per vehicle
+	if w := post(CreateTransfer, vehicle.ID, `{"to_location_id":"`+to.ID+`"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	if w := post(DepartTransfer, transfer.ID, `{"departure_odometer":15000}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.Status != models.VehicleStatusInTransit {
+		t.Errorf("Expected vehicle in transit, got %s", vehicle.Status)
+	}
+	// A vehicle on the road cannot be booked at the location it left
+	pickupAt := time.Now().Add(24 * time.Hour)
+	availability, err := rentalAvailability(db, vehicle, from, from, pickupAt, pickupAt.Add(48*time.Hour), "")
+	if err != nil {
+		t.Fatalf("Failed to check availability: %v", err)
+	}
+	if !strings.Contains(strings.Join(availability.Reasons, "; "), "vehicle is in transit") {
+		t.Errorf("Expected a vehicle in transit to be unavailable, got reasons %v", availability.Reasons)
+	}
+
+	if w := post(ReceiveTransfer, transfer.ID, `{"arrival_odometer":14000}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an arrival below departure, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := post(ReceiveTransfer, transfer.ID, `{"arrival_odometer":15030}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.LocationID != to.ID || vehicle.Status != models.VehicleStatusAvailable || vehicle.Mileage != 15030 {
+		t.Errorf("Expected an available vehicle at the airport with 15030 mi, got %s at %s with %d mi",
+			vehicle.Status, vehicle.LocationID, vehicle.Mileage)
+	}
+}
+
+func TestPatchVehicle_LocationRequiresTransfer(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	from := testutil.CreateTestLocation(t, db, org.ID, "Downtown", "San Francisco")
+	to := testutil.CreateTestLocation(t, db, org.ID, "Airport", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, from.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/vehicles/"+vehicle.ID, bytes.NewBufferString(`{"location_id":"`+to.ID+`"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req = withURLParams(req, "id", vehicle.ID)
+	w := httptest.NewRecorder()
+	PatchVehicle(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// TrashResponse lists soft-deleted records awaiting restore or purge
+type TrashResponse struct {
+	Organizations []models.Organization `json:"organizations"`
+	Locations     []models.Location     `json:"locations"`
+	Vehicles      []models.Vehicle      `json:"vehicles"`
+}
+
+// GetTrash returns soft-deleted organizations, locations and vehicles.
+// Results can be narrowed with the organization_id and type (organizations|locations|vehicles) query parameters.
+func GetTrash(w http.ResponseWriter, r *http.Request) {
+	organizationID := r.URL.Query().Get("organization_id")
+	entityType := r.URL.Query().Get("type")
+
+	switch entityType {
+	case "", "organizations", "locations", "vehicles":
+	default:
+		http.Error(w, "type must be one of organizations, locations or vehicles", http.StatusBadRequest)
+		return
+	}
+
+	trashed := func(column string) *gorm.DB {
+		query := database.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC")
+		if organizationID != "" {
+			query = query.Where(column+" = ?", organizationID)
+		}
+		return query
+	}
+
+	response := TrashResponse{
+		Organizations: []models.Organization{},
+		Locations:     []models.Location{},
+		Vehicles:      []models.Vehicle{},
+	}
+
+	if entityType == "" || entityType == "organizations" {
+		if err := trashed("id").Find(&response.Organizations).Error; err != nil {
+			http.Error(w, "Failed to fetch trash", http.StatusInternalServerError)
+			return
+		}
+	}
+	if entityType == "" || entityType == "locations" {
+		if err := trashed("organization_id").Find(&response.Locations).Error; err != nil {
+			http.Error(w, "Failed to fetch trash", http.StatusInternalServerError)
+			return
+		}
+	}
+	if entityType == "" || entityType == "vehicles" {
+		if err := trashed("organization_id").Find(&response.Vehicles).Error; err != nil {
+			http.Error(w, "Failed to fetch trash", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// restoreRecords clears the soft-delete markers on the rows matched by query
+func restoreRecords(query *gorm.DB) error {
+	return query.Unscoped().Where("deleted_at IS NOT NULL").Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": nil,
+	}).Error
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDeleteOrganization_SoftDeletesFleet(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/organizations/"+org.ID, nil)
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", org.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	DeleteOrganization(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	// Default queries no longer see the fleet
+	var count int64
+	db.Model(&models.Vehicle{}).Where("id = ?", vehicle.ID).Count(&count)
+	if count != 0 {
+		t.Error("Expected vehicle to be hidden after organization delete")
+	}
+
+	// But the rows are still there
+	db.Unscoped().Model(&models.Vehicle{}).Where("id = ?", vehicle.ID).Count(&count)
+	if count != 1 {
+		t.Error("Expected vehicle row to be kept for restore")
+	}
+
+	// Trash lists all three records
+	req = httptest.NewRequest(http.MethodGet, "/api/trash?organization_id="+org.ID, nil)
+	w = httptest.NewRecorder()
+
+	GetTrash(w, req)
+
+	var trash TrashResponse
+	if err := json.NewDecoder(w.Body).Decode(&trash); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(trash.Organizations) != 1 || len(trash.Locations) != 1 || len(trash.Vehicles) != 1 {
+		t.Errorf("Expected 1 organization, location and vehicle in trash, got %d/%d/%d",
+			len(trash.Organizations), len(trash.Locations), len(trash.Vehicles))
+	}
+}
+
+func TestRestoreOrganization_RestoresFleet(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", org.ID)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/organizations/"+org.ID, nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	DeleteOrganization(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/organizations/"+org.ID+"/restore", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	RestoreOrganization(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var restored models.Vehicle
+	if err := db.First(&restored, "id = ?", vehicle.ID).Error; err != nil {
+		t.Errorf("Expected vehicle to be restored with its organization: %v", err)
+	}
+}
+
+func TestRestoreVehicle_DeletedLocation(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	db.Delete(vehicle)
+	db.Delete(loc)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/restore", nil)
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", vehicle.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	RestoreVehicle(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
@@ -2,17 +2,66 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fleetpass/internal/database"
 	"fleetpass/internal/models"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
+// vehicleListQuery builds the vehicle query for the list filters shared by GetVehicles and
+// ExportVehicles: organization_id, location_id, status, condition, make, model (case-insensitive),
+// year_min, year_max and q, a case-insensitive search over VIN, make, model, stock number and plate.
+func vehicleListQuery(r *http.Request) (*gorm.DB, error) {
+	params := r.URL.Query()
+	query := database.DB.Model(&models.Vehicle{}).Order("created_at DESC")
+
+	for _, column := range []string{"organization_id", "location_id", "status", "condition"} {
+		if value := params.Get(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	for _, column := range []string{"make", "model"} {
+		if value := params.Get(column); value != "" {
+			query = query.Where("LOWER("+column+") = LOWER(?)", value)
+		}
+	}
+
+	for param, operator := range map[string]string{"year_min": ">=", "year_max": "<="} {
+		if value := params.Get(param); value != "" {
+			year, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a whole number", param)
+			}
+			query = query.Where("year "+operator+" ?", year)
+		}
+	}
+
+	if q := strings.TrimSpace(params.Get("q")); q != "" {
+		pattern := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(vin) LIKE ? OR LOWER(make) LIKE ? OR LOWER(model) LIKE ? OR LOWER(stock_number) LIKE ? OR LOWER(license_plate) LIKE ?",
+			pattern, pattern, pattern, pattern, pattern)
+	}
+
+	return query, nil
+}
+
 func GetVehicles(w http.ResponseWriter, r *http.Request) {
 	var vehicles []models.Vehicle
 
-	if err := database.DB.Order("created_at DESC").Find(&vehicles).Error; err != nil {
+	query, err := vehicleListQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := query.Find(&vehicles).Error; err != nil {
 		http.Error(w, "Failed to fetch vehicles", http.StatusInternalServerError)
 		return
 	}
@@ -53,6 +102,23 @@ func CreateVehicle(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Location not found", http.StatusBadRequest)
 		return
 	}
+	if err := checkVehicleClassID(location.OrganizationID, req.VehicleClassID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// A VIN stays taken while its vehicle is in the trash
+	var existing models.Vehicle
+	if err := database.DB.Unscoped().Where("UPPER(vin) = ?", strings.ToUpper(req.VIN)).First(&existing).Error; err == nil {
+		if existing.DeletedAt.Valid {
+			http.Error(w, fmt.Sprintf("VIN %s belongs to a deleted vehicle; restore it first", req.VIN), http.StatusConflict)
+		} else {
+			http.Error(w, fmt.Sprintf("VIN %s already exists", req.VIN), http.StatusConflict)
+		}
+		return
+	}
+	if !checkLocationCapacity(w, &location, 1, req.AllowOverCapacity) {
+		return
+	}
 
 	vehicle := models.Vehicle{
 		OrganizationID:       location.OrganizationID,
@@ -80,14 +146,38 @@ func CreateVehicle(w http.ResponseWriter, r *http.Request) {
 		Doors:                req.Doors,
 		StockNumber:          req.StockNumber,
 		Description:          req.Description,
-		DailyRate:            req.DailyRate,
-		WeeklyRate:           req.WeeklyRate,
-		MonthlyRate:          req.MonthlyRate,
+		DailyRate:            models.NewMoney(req.DailyRate, location.Currency),
+		WeeklyRate:           models.NewMoney(req.WeeklyRate, location.Currency),
+		MonthlyRate:          models.NewMoney(req.MonthlyRate, location.Currency),
 		Features:             models.StringArray(req.Features),
 		Images:               models.StringArray(req.Images),
 	}
+	vehicle.HasWarranty = req.HasWarranty
+	vehicle.WarrantyExpirationDate = req.WarrantyExpirationDate
+	vehicle.WarrantyType = req.WarrantyType
+	vehicle.WarrantyDetails = req.WarrantyDetails
+	vehicle.FuelCapacity = req.FuelCapacity
+	vehicle.VehicleClassID = req.VehicleClassID
 
-	if err := database.DB.Create(&vehicle).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vehicle).Error; err != nil {
+			return err
+		}
+		// The starting mileage opens the odometer log
+		if vehicle.Mileage > 0 {
+			return tx.Create(&models.OdometerReading{
+				OrganizationID: vehicle.OrganizationID,
+				VehicleID:      vehicle.ID,
+				Reading:        vehicle.Mileage,
+				Source:         models.OdometerSourceManual,
+				Kind:           models.OdometerReadingKindReading,
+				ReadAt:         vehicle.CreatedAt,
+				RecordedBy:     currentUserID(r),
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
 		http.Error(w, "Failed to create vehicle", http.StatusInternalServerError)
 		return
 	}
@@ -112,8 +202,51 @@ func UpdateVehicle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Moves between locations go through transfers, which also own the in-transit status
+	if req.LocationID != "" && req.LocationID != vehicle.LocationID {
+		http.Error(w, errTransferLocation.Error(), http.StatusConflict)
+		return
+	}
+	if (vehicle.Status == models.VehicleStatusInTransit) != (req.Status == models.VehicleStatusInTransit) {
+		http.Error(w, errTransferStatus.Error(), http.StatusConflict)
+		return
+	}
+
+	// Vehicles with open maintenance cannot be rented or made available; closing the last open
+	// record is what puts them back in service
+	if (req.Status == models.VehicleStatusRented || req.Status == models.VehicleStatusAvailable) && req.Status != vehicle.Status {
+		if err := checkVehicleRentable(database.DB, vehicle.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+	}
+
+	// Eligibility set by hand replaces any suspension for lapsed documents, which must be
+	// renewed before the vehicle can go back in service
+	if req.IsEligibleForService != vehicle.IsEligibleForService {
+		if req.IsEligibleForService {
+			if err := checkServiceEligible(vehicle.ID); errors.Is(err, errLapsedDocuments) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			} else if err != nil {
+				http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
+				return
+			}
+		}
+		vehicle.DocumentSuspended = false
+	}
+
+	if err := checkVehicleClassID(vehicle.OrganizationID, req.VehicleClassID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	currency, err := locationCurrency(vehicle.LocationID)
+	if err != nil {
+		http.Error(w, "Failed to fetch location", http.StatusInternalServerError)
+		return
+	}
+
 	// Update fields
-	vehicle.LocationID = req.LocationID
 	vehicle.Make = req.Make
 	vehicle.Model = req.Model
 	vehicle.Year = req.Year
@@ -121,8 +254,11 @@ func UpdateVehicle(w http.ResponseWriter, r *http.Request) {
 	vehicle.ColorExterior = req.ColorExterior
 	vehicle.ColorInterior = req.ColorInterior
 	vehicle.Condition = req.Condition
-	vehicle.Mileage = req.Mileage
 	vehicle.LicensePlate = req.LicensePlate
+	// A status set by hand is no longer one a later import may undo
+	if req.Status != vehicle.Status {
+		vehicle.ImportDeactivated = false
+	}
 	vehicle.Status = req.Status
 	vehicle.IsEligibleForService = req.IsEligibleForService
 	vehicle.BodyStyle = req.BodyStyle
@@ -136,13 +272,43 @@ func UpdateVehicle(w http.ResponseWriter, r *http.Request) {
 	vehicle.Doors = req.Doors
 	vehicle.StockNumber = req.StockNumber
 	vehicle.Description = req.Description
-	vehicle.DailyRate = req.DailyRate
-	vehicle.WeeklyRate = req.WeeklyRate
-	vehicle.MonthlyRate = req.MonthlyRate
+	vehicle.DailyRate = models.NewMoney(req.DailyRate, currency)
+	vehicle.WeeklyRate = models.NewMoney(req.WeeklyRate, currency)
+	vehicle.MonthlyRate = models.NewMoney(req.MonthlyRate, currency)
 	vehicle.Features = models.StringArray(req.Features)
 	vehicle.Images = models.StringArray(req.Images)
+	vehicle.HasWarranty = req.HasWarranty
+	vehicle.WarrantyExpirationDate = req.WarrantyExpirationDate
+	vehicle.WarrantyType = req.WarrantyType
+	vehicle.WarrantyDetails = req.WarrantyDetails
+	vehicle.FuelCapacity = req.FuelCapacity
+	vehicle.VehicleClassID = req.VehicleClassID
+
+	// Mileage is derived from the odometer log, so a change is recorded as a manual reading
+	var reading *models.OdometerReading
+	if req.Mileage != vehicle.Mileage {
+		reading = &models.OdometerReading{
+			Reading:    req.Mileage,
+			Source:     models.OdometerSourceManual,
+			RecordedBy: currentUserID(r),
+		}
+	}
 
-	if err := database.DB.Save(&vehicle).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&vehicle).Error; err != nil {
+			return err
+		}
+		if reading != nil {
+			return recordOdometerReading(tx, &vehicle, reading)
+		}
+		return nil
+	})
+	var invalid *odometerError
+	if errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), invalid.status())
+		return
+	}
+	if err != nil {
 		http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
 		return
 	}
@@ -151,10 +317,193 @@ func UpdateVehicle(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(vehicle)
 }
 
+// vehicleColumns maps changed vehicle fields to the columns that store them. Rates are kept
+// as an amount and a currency column each.
+func vehicleColumns(fields []string) []string {
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		switch field {
+		case "daily_rate", "weekly_rate", "monthly_rate":
+			columns = append(columns, field+"_amount", field+"_currency")
+		default:
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
+
+// vehiclePatchFields lists the vehicle fields that may be changed through PATCH
+var vehiclePatchFields = []string{
+	"location_id", "make", "model", "year", "trim", "color_exterior", "color_interior",
+	"condition", "mileage", "license_plate", "status", "is_eligible_for_service",
+	"body_style", "transmission", "drivetrain", "fuel_type", "engine", "mpg_city",
+	"mpg_highway", "seats", "doors", "stock_number", "description", "daily_rate",
+	"weekly_rate", "monthly_rate", "features", "images", "has_warranty",
+	"warranty_expiration_date", "warranty_type", "warranty_details", "fuel_capacity",
+	"vehicle_class_id",
+}
+
+// PatchVehicle applies a JSON Merge Patch or JSON Patch to a vehicle, updating only changed columns
+func PatchVehicle(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	var patched models.Vehicle
+	changed, err := applyPatchRequest(r, vehicle, &patched, vehiclePatchFields)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	if len(changed) > 0 {
+		if err := validateVehiclePatch(&vehicle, &patched, changed); err != nil {
+			status := http.StatusUnprocessableEntity
+			if errors.Is(err, errVehicleInMaintenance) || errors.Is(err, errTransferLocation) || errors.Is(err, errTransferStatus) ||
+				errors.Is(err, errLapsedDocuments) {
+				status = http.StatusConflict
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		// Mileage is derived from the odometer log, so a change is recorded as a manual reading
+		columns := []string{"updated_at"}
+		var reading *models.OdometerReading
+		for _, field := range changed {
+			if field == "mileage" {
+				reading = &models.OdometerReading{
+					Reading:    patched.Mileage,
+					Source:     models.OdometerSourceManual,
+					RecordedBy: currentUserID(r),
+				}
+				continue
+			}
+			columns = append(columns, field)
+			if field == "is_eligible_for_service" {
+				patched.DocumentSuspended = false
+				columns = append(columns, "document_suspended")
+			}
+			if field == "status" {
+				patched.ImportDeactivated = false
+				columns = append(columns, "import_deactivated")
+			}
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&vehicle).Select(vehicleColumns(columns)).Updates(&patched).Error; err != nil {
+				return err
+			}
+			if reading != nil {
+				return recordOdometerReading(tx, &vehicle, reading)
+			}
+			return nil
+		})
+		var invalid *odometerError
+		if errors.As(err, &invalid) {
+			http.Error(w, invalid.Error(), invalid.status())
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
+			return
+		}
+
+		if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+			http.Error(w, "Vehicle not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicle)
+}
+
+// validateVehiclePatch checks the changed fields of a patched vehicle
+func validateVehiclePatch(current, patched *models.Vehicle, changed []string) error {
+	for _, field := range changed {
+		switch field {
+		case "location_id":
+			return errTransferLocation
+		case "make", "model":
+			if strings.TrimSpace(patched.Make) == "" || strings.TrimSpace(patched.Model) == "" {
+				return fmt.Errorf("%s cannot be empty", field)
+			}
+		case "year":
+			if patched.Year < 1900 || patched.Year > 2100 {
+				return fmt.Errorf("invalid year: %d", patched.Year)
+			}
+		case "condition":
+			switch patched.Condition {
+			case models.VehicleConditionNew, models.VehicleConditionUsed, models.VehicleConditionCertifiedPreOwned:
+			default:
+				return fmt.Errorf("invalid condition: %s", patched.Condition)
+			}
+		case "status":
+			if current.Status == models.VehicleStatusInTransit {
+				return errTransferStatus
+			}
+			switch patched.Status {
+			case models.VehicleStatusAvailable, models.VehicleStatusRented, models.VehicleStatusMaintenance, models.VehicleStatusInactive:
+			default:
+				return fmt.Errorf("invalid status: %s", patched.Status)
+			}
+			// Closing its last open record is what puts a vehicle back in service
+			if patched.Status == models.VehicleStatusRented || patched.Status == models.VehicleStatusAvailable {
+				if err := checkVehicleRentable(database.DB, current.ID); err != nil {
+					return err
+				}
+			}
+		case "is_eligible_for_service":
+			if patched.IsEligibleForService {
+				if err := checkServiceEligible(current.ID); err != nil {
+					return err
+				}
+			}
+		case "mileage", "mpg_city", "mpg_highway", "seats", "doors":
+			if patched.Mileage < 0 || patched.MPGCity < 0 || patched.MPGHighway < 0 || patched.Seats < 0 || patched.Doors < 0 {
+				return fmt.Errorf("%s cannot be negative", field)
+			}
+		case "daily_rate", "weekly_rate", "monthly_rate":
+			currency, err := locationCurrency(current.LocationID)
+			if err != nil {
+				return err
+			}
+			for _, rate := range []*models.Money{&patched.DailyRate, &patched.WeeklyRate, &patched.MonthlyRate} {
+				if rate.Amount < 0 {
+					return fmt.Errorf("%s cannot be negative", field)
+				}
+				if rate.Currency == "" {
+					rate.Currency = currency
+				}
+				if rate.Currency != currency {
+					return fmt.Errorf("%s must be in the location's currency, %s", field, currency)
+				}
+			}
+		case "fuel_capacity":
+			if patched.FuelCapacity < 0 {
+				return fmt.Errorf("fuel_capacity cannot be negative")
+			}
+		case "vehicle_class_id":
+			if err := checkVehicleClassID(current.OrganizationID, patched.VehicleClassID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func DeleteVehicle(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	result := database.DB.Delete(&models.Vehicle{}, "id = ?", id)
+	result := database.DB.Model(&models.Vehicle{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"deleted_at": time.Now(),
+		"deleted_by": currentUserID(r),
+	})
 	if result.Error != nil {
 		http.Error(w, "Failed to delete vehicle", http.StatusInternalServerError)
 		return
@@ -165,5 +514,36 @@ func DeleteVehicle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A deleted vehicle no longer holds its parking space
+	releaseParkingSpace(database.DB, id)
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreVehicle moves a soft-deleted vehicle out of the trash
+func RestoreVehicle(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&vehicle).Error; err != nil {
+		http.Error(w, "Deleted vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	// The vehicle's location must be live before the vehicle can come back
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", vehicle.LocationID).Error; err != nil {
+		http.Error(w, "Vehicle's location is deleted; restore it first", http.StatusConflict)
+		return
+	}
+
+	if err := restoreRecords(database.DB.Model(&models.Vehicle{}).Where("id = ?", id)); err != nil {
+		http.Error(w, "Failed to restore vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	database.DB.First(&vehicle, "id = ?", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicle)
+}
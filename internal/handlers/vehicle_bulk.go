@@ -1,30 +1,452 @@
 package handlers
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fleetpass/internal/database"
 	"fleetpass/internal/models"
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Bulk upload modes
+const (
+	BulkUploadModePartial = "partial" // insert valid rows, report the rest (default)
+	BulkUploadModeDryRun  = "dry_run" // validate only, write nothing
+	BulkUploadModeAtomic  = "atomic"  // insert every row or none
+)
+
+// Bulk upload conflict strategies for VINs that already exist in the organization
+const (
+	OnConflictError  = "error"  // reject the row (default)
+	OnConflictSkip   = "skip"   // leave the existing vehicle untouched
+	OnConflictUpdate = "update" // update the existing vehicle from the row
+)
+
+// Row outcomes reported by bulk upload
+const (
+	RowActionCreated   = "created"
+	RowActionUpdated   = "updated"
+	RowActionUnchanged = "unchanged"
+	RowActionSkipped   = "skipped"
+	RowActionRejected  = "rejected"
 )
 
+// Row error codes reported by bulk upload
+const (
+	RowErrorRequired  = "required"
+	RowErrorInvalid   = "invalid"
+	RowErrorDuplicate = "duplicate"
+	RowErrorParse     = "parse_error"
+	RowErrorDatabase  = "database_error"
+)
+
+// importBatchSize is the number of rows validated and written together
+const importBatchSize = 500
+
+// importUpdatableColumns are the vehicle columns an on_conflict=update upload may change.
+// Only those present in the file's header are touched.
+var importUpdatableColumns = []string{
+	"make", "model", "year", "trim", "color_exterior", "color_interior", "condition",
+	"mileage", "license_plate", "body_style", "transmission", "drivetrain", "fuel_type",
+	"engine", "mpg_city", "mpg_highway", "seats", "doors", "stock_number", "description",
+	"daily_rate", "weekly_rate", "monthly_rate", "features", "has_warranty",
+	"warranty_expiration_date", "warranty_type", "warranty_details",
+}
+
 type BulkUploadRequest struct {
 	OrganizationID string `json:"organization_id"`
 	LocationID     string `json:"location_id"`
 }
 
+// RowError describes a single problem with an uploaded row
+type RowError = models.ImportRowError
+
+// RowResult records what happened to a single uploaded row
+type RowResult struct {
+	Row       int      `json:"row"`
+	VIN       string   `json:"vin"`
+	Action    string   `json:"action"`
+	VehicleID string   `json:"vehicle_id,omitempty"`
+	Changed   []string `json:"changed,omitempty"`
+}
+
 type BulkUploadResult struct {
-	Success   int      `json:"success"`
-	Failed    int      `json:"failed"`
-	Total     int      `json:"total"`
-	Errors    []string `json:"errors,omitempty"`
-	VehicleIDs []string `json:"vehicle_ids,omitempty"`
+	Mode           string      `json:"mode"`
+	OnConflict     string      `json:"on_conflict"`
+	Success        int         `json:"success"`
+	Failed         int         `json:"failed"`
+	Total          int         `json:"total"`
+	Created        int         `json:"created"`
+	Updated        int         `json:"updated"`
+	Unchanged      int         `json:"unchanged"`
+	Skipped        int         `json:"skipped"`
+	Deactivated    int         `json:"deactivated"`
+	Errors         []string    `json:"errors,omitempty"`
+	Warnings       []string    `json:"warnings,omitempty"`
+	Report         []RowError  `json:"report,omitempty"`
+	Rows           []RowResult `json:"rows,omitempty"`
+	VehicleIDs     []string    `json:"vehicle_ids,omitempty"`
+	DeactivatedIDs []string    `json:"deactivated_ids,omitempty"`
+	// VINs missing from the file whose vehicles were left alone by deactivate_missing
+	DeactivationSkipped []string `json:"deactivation_skipped,omitempty"`
+}
+
+func newBulkUploadResult(mode, onConflict string) *BulkUploadResult {
+	return &BulkUploadResult{
+		Mode:           mode,
+		OnConflict:     onConflict,
+		Errors:         []string{},
+		Report:         []RowError{},
+		Rows:           []RowResult{},
+		VehicleIDs:     []string{},
+		DeactivatedIDs: []string{},
+	}
+}
+
+// tally records a finished row. Valid rows only count as successes once committed.
+func (res *BulkUploadResult) tally(row *importRow, committed bool) {
+	res.Total++
+	defer func() { res.Failed = res.Total - res.Success }()
+
+	if len(row.errors) > 0 {
+		for _, rowErr := range row.errors {
+			res.Report = append(res.Report, rowErr)
+			res.Errors = append(res.Errors, formatRowError(rowErr))
+		}
+		res.Rows = append(res.Rows, RowResult{Row: row.line, VIN: row.vin, Action: RowActionRejected})
+		return
+	}
+	if !committed {
+		return
+	}
+
+	res.Success++
+	rowResult := RowResult{Row: row.line, VIN: row.vin, Action: row.action, Changed: row.changed}
+	switch row.action {
+	case RowActionCreated:
+		res.Created++
+	case RowActionUpdated:
+		res.Updated++
+	case RowActionUnchanged:
+		res.Unchanged++
+	case RowActionSkipped:
+		res.Skipped++
+	}
+	if res.Mode != BulkUploadModeDryRun || row.existing != nil {
+		rowResult.VehicleID = row.vehicleID()
+		if row.action == RowActionCreated {
+			res.VehicleIDs = append(res.VehicleIDs, rowResult.VehicleID)
+		}
+	}
+	res.Rows = append(res.Rows, rowResult)
+}
+
+// importRow is a parsed data row together with any validation errors and its planned action
+type importRow struct {
+	line     int
+	record   []string
+	vin      string
+	vehicle  *models.Vehicle
+	existing *models.Vehicle
+	action   string
+	changed  []string
+	errors   []RowError
+}
+
+func (row *importRow) reject(column, code, message string) {
+	row.errors = append(row.errors, RowError{Row: row.line, Column: column, Code: code, Message: message})
+}
+
+func (row *importRow) vehicleID() string {
+	if row.existing != nil {
+		return row.existing.ID
+	}
+	if row.vehicle != nil {
+		return row.vehicle.ID
+	}
+	return ""
+}
+
+// importSession validates and writes the rows of one uploaded file. It carries the state that
+// spans batches: the VINs seen so far and every VIN present for deactivate_missing.
+type importSession struct {
+	organizationID    string
+	locationID        string
+	currency          string
+	mode              string
+	onConflict        string
+	deactivateMissing bool
+	headers           []string
+	headerMap         map[string]int
+	updateColumns     []string
+	seen              map[string]int
+	present           []string
+}
+
+// validateImportOptions checks the mode and on_conflict values, applying their defaults
+func validateImportOptions(mode, onConflict *string) error {
+	if *mode == "" {
+		*mode = BulkUploadModePartial
+	}
+	if *mode != BulkUploadModePartial && *mode != BulkUploadModeDryRun && *mode != BulkUploadModeAtomic {
+		return fmt.Errorf("mode must be one of partial, dry_run or atomic")
+	}
+
+	if *onConflict == "" {
+		*onConflict = OnConflictError
+	}
+	if *onConflict != OnConflictError && *onConflict != OnConflictSkip && *onConflict != OnConflictUpdate {
+		return fmt.Errorf("on_conflict must be one of error, skip or update")
+	}
+	return nil
+}
+
+// newImportSession prepares a session for a file with the given header row
+func newImportSession(headers []string, organizationID, locationID, mode, onConflict string, deactivateMissing bool) (*importSession, error) {
+	// Map headers to indices
+	headerMap := make(map[string]int)
+	for i, header := range headers {
+		headerMap[strings.TrimSpace(strings.ToLower(header))] = i
+	}
+
+	// Validate required headers
+	requiredHeaders := []string{"vin", "make", "model", "year"}
+	for _, required := range requiredHeaders {
+		if _, exists := headerMap[required]; !exists {
+			return nil, fmt.Errorf("Missing required header: %s", required)
+		}
+	}
+
+	// Only columns supplied in the file are considered when updating existing vehicles
+	var updateColumns []string
+	for _, column := range importUpdatableColumns {
+		if _, exists := headerMap[column]; exists {
+			updateColumns = append(updateColumns, column)
+		}
+	}
+
+	return &importSession{
+		organizationID:    organizationID,
+		locationID:        locationID,
+		mode:              mode,
+		onConflict:        onConflict,
+		deactivateMissing: deactivateMissing,
+		headers:           headers,
+		headerMap:         headerMap,
+		updateColumns:     updateColumns,
+		seen:              make(map[string]int),
+	}, nil
+}
+
+// parseRow validates a single data row read from the file
+func (s *importSession) parseRow(line int, record []string, readErr error) *importRow {
+	row := &importRow{line: line, record: record}
+
+	if idx := s.headerMap["vin"]; idx < len(record) {
+		row.vin = strings.TrimSpace(record[idx])
+		if row.vin != "" {
+			s.present = append(s.present, strings.ToUpper(row.vin))
+		}
+	}
+
+	if readErr != nil {
+		message := "Failed to parse row"
+		var recordErr importRecordError
+		if errors.As(readErr, &recordErr) {
+			message = recordErr.Error()
+		}
+		row.reject("", RowErrorParse, message)
+		return row
+	}
+
+	vehicle, fieldErrors := parseVehicleFromCSV(record, s.headerMap, s.organizationID, s.locationID, s.currency)
+	for _, fe := range fieldErrors {
+		row.reject(fe.Column, fe.Code, fe.Message)
+	}
+	row.vehicle = vehicle
+	return row
+}
+
+// plan decides whether each valid row creates, updates, skips or is rejected.
+// VINs are matched case-insensitively; a VIN repeated within the file, owned by another
+// organization or sitting in the trash is always rejected. With deactivate_missing set, updated
+// vehicles that an earlier upload marked inactive become available again; vehicles staff made
+// inactive stay inactive.
+func (s *importSession) plan(rows []*importRow) {
+	var vins []string
+	for _, row := range rows {
+		if row.vehicle == nil || len(row.errors) > 0 {
+			continue
+		}
+		vin := strings.ToUpper(row.vehicle.VIN)
+		if first, exists := s.seen[vin]; exists {
+			row.reject("vin", RowErrorDuplicate, fmt.Sprintf("VIN %s duplicates row %d", row.vehicle.VIN, first))
+			continue
+		}
+		s.seen[vin] = row.line
+		vins = append(vins, vin)
+	}
+
+	existingByVIN := make(map[string]*models.Vehicle)
+	if len(vins) > 0 {
+		var existing []models.Vehicle
+		database.DB.Unscoped().Where("UPPER(vin) IN ?", vins).Find(&existing)
+		for i := range existing {
+			existingByVIN[strings.ToUpper(existing[i].VIN)] = &existing[i]
+		}
+	}
+
+	for _, row := range rows {
+		if row.vehicle == nil || len(row.errors) > 0 {
+			continue
+		}
+
+		existing, exists := existingByVIN[strings.ToUpper(row.vehicle.VIN)]
+		switch {
+		case !exists:
+			row.action = RowActionCreated
+		case existing.OrganizationID != s.organizationID:
+			row.reject("vin", RowErrorDuplicate, fmt.Sprintf("VIN %s belongs to another organization", row.vehicle.VIN))
+		case existing.DeletedAt.Valid:
+			row.reject("vin", RowErrorDuplicate, fmt.Sprintf("VIN %s belongs to a deleted vehicle; restore it first", row.vehicle.VIN))
+		case s.onConflict == OnConflictSkip:
+			row.existing = existing
+			row.action = RowActionSkipped
+		case s.onConflict == OnConflictUpdate:
+			row.existing = existing
+			row.changed = changedColumns(existing, row.vehicle, s.updateColumns)
+			if row.vehicle.Mileage < existing.Mileage && containsString(row.changed, "mileage") {
+				row.reject("mileage", RowErrorInvalid, fmt.Sprintf("mileage %d is lower than the current odometer reading of %d", row.vehicle.Mileage, existing.Mileage))
+				continue
+			}
+			if s.deactivateMissing && existing.Status == models.VehicleStatusInactive && existing.ImportDeactivated {
+				row.changed = append(row.changed, "status")
+			}
+			if len(row.changed) > 0 {
+				row.action = RowActionUpdated
+			} else {
+				row.action = RowActionUnchanged
+			}
+		default:
+			row.reject("vin", RowErrorDuplicate, fmt.Sprintf("VIN %s already exists", row.vehicle.VIN))
+		}
+	}
+}
+
+// write stores the planned rows. The batch is first attempted as a whole with batched inserts;
+// if that fails it is retried row by row so the offending rows can be reported. Each attempt
+// runs in its own (nested) transaction, so inside an atomic upload only the retry is rolled back.
+func (s *importSession) write(tx *gorm.DB, rows []*importRow) {
+	var creates []*models.Vehicle
+	var updates []*importRow
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			continue
+		}
+		switch row.action {
+		case RowActionCreated:
+			creates = append(creates, row.vehicle)
+		case RowActionUpdated:
+			updates = append(updates, row)
+		}
+	}
+	if len(creates) == 0 && len(updates) == 0 {
+		return
+	}
+
+	err := tx.Transaction(func(batchTx *gorm.DB) error {
+		if len(creates) > 0 {
+			if err := batchTx.CreateInBatches(creates, importBatchSize).Error; err != nil {
+				return err
+			}
+			if readings := importOdometerReadings(creates); len(readings) > 0 {
+				if err := batchTx.CreateInBatches(readings, importBatchSize).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for _, row := range updates {
+			if err := applyImportRow(batchTx, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return
+	}
+
+	for _, row := range rows {
+		if len(row.errors) > 0 || (row.action != RowActionCreated && row.action != RowActionUpdated) {
+			continue
+		}
+		if row.vehicle != nil {
+			row.vehicle.ID = ""
+		}
+		if err := tx.Transaction(func(rowTx *gorm.DB) error { return applyImportRow(rowTx, row) }); err != nil {
+			row.reject("", RowErrorDatabase, err.Error())
+		}
+	}
+}
+
+// deactivate marks the location's available vehicles whose VIN does not appear anywhere in the
+// file as inactive and returns their IDs. Vehicles that are rented, in maintenance or in transit
+// belong to those workflows and are left alone; their VINs are returned as skipped. With
+// preview set nothing is written.
+func (s *importSession) deactivate(tx *gorm.DB, preview bool) ([]string, []string, error) {
+	missing := func() *gorm.DB {
+		query := tx.Model(&models.Vehicle{}).
+			Where("organization_id = ? AND location_id = ?", s.organizationID, s.locationID)
+		if len(s.present) > 0 {
+			query = query.Where("UPPER(vin) NOT IN ?", s.present)
+		}
+		return query
+	}
+
+	ids := []string{}
+	if err := missing().Where("status = ?", models.VehicleStatusAvailable).Pluck("id", &ids).Error; err != nil {
+		return nil, nil, err
+	}
+	skipped := []string{}
+	if err := missing().Where("status NOT IN ?", []models.VehicleStatus{models.VehicleStatusAvailable, models.VehicleStatusInactive}).
+		Order("vin").Pluck("vin", &skipped).Error; err != nil {
+		return nil, nil, err
+	}
+	if preview || len(ids) == 0 {
+		return ids, skipped, nil
+	}
+
+	err := tx.Model(&models.Vehicle{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": models.VehicleStatusInactive, "import_deactivated": true}).Error
+	return ids, skipped, err
 }
 
+// deactivationWarning explains the missing vehicles deactivate left alone
+func deactivationWarning(skipped []string) string {
+	return fmt.Sprintf("%d vehicles missing from the file were not deactivated because they are rented, in maintenance or in transit: %s",
+		len(skipped), strings.Join(skipped, ", "))
+}
+
+// BulkUploadVehicles imports vehicles from a CSV, XLSX or JSON Lines file. The format is taken
+// from the format form value, the file extension or the file contents; sheet picks an XLSX
+// worksheet other than the first. profile_id applies a saved import profile that maps the
+// file's headers to vehicle fields.
+// The mode form value selects partial (default), dry_run or atomic behaviour; report=csv returns
+// the rejected rows as a CSV with an errors column instead of the JSON summary. on_conflict
+// (error, skip or update) decides what happens to VINs already in the organization, and
+// deactivate_missing=true marks the location's available vehicles that are absent from the file as
+// inactive, listing the absent ones other workflows hold in deactivation_skipped.
+// Large files should go through POST /api/import-jobs instead.
 func BulkUploadVehicles(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form
 	err := r.ParseMultipartForm(10 << 20) // 10 MB max
@@ -33,6 +455,15 @@ func BulkUploadVehicles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mode := r.FormValue("mode")
+	onConflict := r.FormValue("on_conflict")
+	if err := validateImportOptions(&mode, &onConflict); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deactivateMissing := r.FormValue("deactivate_missing") == "true"
+
 	// Get organization and location IDs
 	organizationID := r.FormValue("organization_id")
 	locationID := r.FormValue("location_id")
@@ -49,89 +480,291 @@ func BulkUploadVehicles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the CSV file
-	file, _, err := r.FormFile("file")
+	// Resolve the column mapping profile, if any
+	var profile *models.ImportProfile
+	if profileID := r.FormValue("profile_id"); profileID != "" {
+		if profile, err = loadImportProfile(profileID, organizationID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Get the uploaded file
+	file, fileHeader, err := r.FormFile("file")
 	if err != nil {
 		http.Error(w, "Failed to read file", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
 
-	// Parse CSV
-	reader := csv.NewReader(file)
+	buffered := bufio.NewReader(file)
+	head, _ := buffered.Peek(512)
+	format, err := detectImportFormat(r.FormValue("format"), fileHeader.Filename, head)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Read header
-	headers, err := reader.Read()
+	source, err := openImportSource(buffered, format, r.FormValue("sheet"), profile)
 	if err != nil {
-		http.Error(w, "Failed to read CSV headers", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	defer source.Close()
+	headers := source.Headers()
 
-	// Map headers to indices
-	headerMap := make(map[string]int)
-	for i, header := range headers {
-		headerMap[strings.TrimSpace(strings.ToLower(header))] = i
+	session, err := newImportSession(headers, organizationID, locationID, mode, onConflict, deactivateMissing)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	session.currency = location.Currency
 
-	// Validate required headers
-	requiredHeaders := []string{"vin", "make", "model", "year"}
-	for _, required := range requiredHeaders {
-		if _, exists := headerMap[required]; !exists {
-			http.Error(w, fmt.Sprintf("Missing required header: %s", required), http.StatusBadRequest)
-			return
+	// Validate every row before writing anything
+	var rows []*importRow
+	line := 1 // header is row 1
+	for {
+		record, err := source.Next()
+		if err == io.EOF {
+			break
 		}
+		line++
+		rows = append(rows, session.parseRow(line, record, err))
 	}
 
-	result := BulkUploadResult{
-		Errors:     []string{},
-		VehicleIDs: []string{},
+	session.plan(rows)
+
+	var invalid, creating int
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			invalid++
+		} else if row.action == RowActionCreated {
+			creating++
+		}
 	}
 
-	rowNum := 1 // Start at 1 (header is row 0)
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
+	// Uploads are never refused for a full lot, but the result says when one overfills it
+	capacity, err := capacityWarning(database.DB, &location, creating)
+	if err != nil {
+		http.Error(w, "Failed to check location capacity", http.StatusInternalServerError)
+		return
+	}
+
+	var deactivatedIDs, skippedVINs []string
+	status := http.StatusCreated
+	switch mode {
+	case BulkUploadModeDryRun:
+		status = http.StatusOK
+		if deactivateMissing {
+			ids, skipped, err := session.deactivate(database.DB, true)
+			if err != nil {
+				http.Error(w, "Failed to deactivate missing vehicles", http.StatusInternalServerError)
+				return
+			}
+			deactivatedIDs, skippedVINs = ids, skipped
+		}
+
+	case BulkUploadModeAtomic:
+		if invalid > 0 {
+			status = http.StatusUnprocessableEntity
 			break
 		}
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			session.write(tx, rows)
+			for _, row := range rows {
+				if len(row.errors) > 0 {
+					return errImportRejected
+				}
+			}
+			if deactivateMissing {
+				ids, skipped, err := session.deactivate(tx, false)
+				if err != nil {
+					return err
+				}
+				deactivatedIDs, skippedVINs = ids, skipped
+			}
+			return nil
+		})
 		if err != nil {
-			result.Failed++
-			result.Errors = append(result.Errors, fmt.Sprintf("Row %d: Failed to parse CSV row", rowNum))
-			rowNum++
-			continue
+			deactivatedIDs, skippedVINs = nil, nil
+			status = http.StatusUnprocessableEntity
 		}
 
-		rowNum++
-		result.Total++
+	default:
+		session.write(database.DB, rows)
+		if deactivateMissing {
+			ids, skipped, err := session.deactivate(database.DB, false)
+			if err != nil {
+				http.Error(w, "Failed to deactivate missing vehicles", http.StatusInternalServerError)
+				return
+			}
+			deactivatedIDs, skippedVINs = ids, skipped
+		}
+	}
 
-		// Parse vehicle from CSV row
-		vehicle, err := parseVehicleFromCSV(record, headerMap, organizationID, locationID)
-		if err != nil {
-			result.Failed++
-			result.Errors = append(result.Errors, fmt.Sprintf("Row %d: %s", rowNum, err.Error()))
-			continue
+	result := newBulkUploadResult(mode, onConflict)
+	var rejected []*importRow
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			rejected = append(rejected, row)
 		}
+		// Atomic uploads that failed commit nothing, so no row counts as a success
+		result.tally(row, mode != BulkUploadModeAtomic || status == http.StatusCreated)
+	}
+	if deactivatedIDs != nil {
+		result.DeactivatedIDs = deactivatedIDs
+	}
+	result.Deactivated = len(result.DeactivatedIDs)
+	if len(skippedVINs) > 0 {
+		result.DeactivationSkipped = skippedVINs
+		result.Warnings = append(result.Warnings, deactivationWarning(skippedVINs))
+	}
+	if capacity != "" && result.Created > 0 {
+		result.Warnings = append(result.Warnings, capacity)
+	}
+
+	if mode == BulkUploadModePartial && len(rejected) > 0 {
+		status = http.StatusPartialContent
+	}
+
+	if r.FormValue("report") == "csv" {
+		writeRejectedRowsCSV(w, status, headers, rejectedRecords(rejected))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// errImportRejected aborts an atomic import transaction when a row could not be written
+var errImportRejected = errors.New("import contains rejected rows")
 
-		// Create vehicle in database
-		if err := database.DB.Create(&vehicle).Error; err != nil {
-			result.Failed++
-			result.Errors = append(result.Errors, fmt.Sprintf("Row %d (VIN: %s): %s", rowNum, vehicle.VIN, err.Error()))
+// changedColumns compares the given columns of two vehicles by their JSON representation
+func changedColumns(before, after *models.Vehicle, columns []string) []string {
+	var beforeDoc, afterDoc map[string]interface{}
+	beforeJSON, _ := json.Marshal(before)
+	afterJSON, _ := json.Marshal(after)
+	json.Unmarshal(beforeJSON, &beforeDoc)
+	json.Unmarshal(afterJSON, &afterDoc)
+
+	var changed []string
+	for _, column := range columns {
+		if !reflect.DeepEqual(beforeDoc[column], afterDoc[column]) {
+			changed = append(changed, column)
+		}
+	}
+	return changed
+}
+
+// applyImportRow writes a planned row, touching only the changed columns of updated vehicles
+func applyImportRow(tx *gorm.DB, row *importRow) error {
+	switch row.action {
+	case RowActionCreated:
+		if err := tx.Create(row.vehicle).Error; err != nil {
+			return err
+		}
+		if readings := importOdometerReadings([]*models.Vehicle{row.vehicle}); len(readings) > 0 {
+			return tx.Create(readings).Error
+		}
+	case RowActionUpdated:
+		columns := append(vehicleColumns(row.changed), "updated_at")
+		// Reactivating clears the mark a deactivating import left
+		if containsString(row.changed, "status") {
+			columns = append(columns, "import_deactivated")
+		}
+		if err := tx.Model(row.existing).Select(columns).Updates(row.vehicle).Error; err != nil {
+			return err
+		}
+		if containsString(row.changed, "mileage") {
+			updated := *row.existing
+			updated.Mileage = row.vehicle.Mileage
+			if readings := importOdometerReadings([]*models.Vehicle{&updated}); len(readings) > 0 {
+				return tx.Create(readings).Error
+			}
+		}
+	}
+	return nil
+}
+
+// importOdometerReadings logs the imported mileage of each vehicle in its odometer log. Imports
+// are checked for going backwards when planned but are not screened for anomalies.
+func importOdometerReadings(vehicles []*models.Vehicle) []*models.OdometerReading {
+	var readings []*models.OdometerReading
+	now := time.Now()
+	for _, vehicle := range vehicles {
+		if vehicle.Mileage <= 0 {
 			continue
 		}
+		readings = append(readings, &models.OdometerReading{
+			OrganizationID: vehicle.OrganizationID,
+			VehicleID:      vehicle.ID,
+			Reading:        vehicle.Mileage,
+			Source:         models.OdometerSourceImport,
+			Kind:           models.OdometerReadingKindReading,
+			ReadAt:         now,
+		})
+	}
+	return readings
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
 
-		result.Success++
-		result.VehicleIDs = append(result.VehicleIDs, vehicle.ID)
+// rejectedRecords converts rejected rows into their original values plus error messages
+func rejectedRecords(rows []*importRow) []models.ImportRejectedRow {
+	rejected := make([]models.ImportRejectedRow, 0, len(rows))
+	for _, row := range rows {
+		messages := make([]string, len(row.errors))
+		for i, rowErr := range row.errors {
+			if rowErr.Column != "" {
+				messages[i] = rowErr.Column + ": " + rowErr.Message
+			} else {
+				messages[i] = rowErr.Message
+			}
+		}
+		rejected = append(rejected, models.ImportRejectedRow{Row: row.line, Record: row.record, Errors: messages})
 	}
+	return rejected
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if result.Failed > 0 {
-		w.WriteHeader(http.StatusPartialContent)
-	} else {
-		w.WriteHeader(http.StatusCreated)
+func formatRowError(rowErr RowError) string {
+	if rowErr.Column != "" {
+		return fmt.Sprintf("Row %d (%s): %s", rowErr.Row, rowErr.Column, rowErr.Message)
 	}
-	json.NewEncoder(w).Encode(result)
+	return fmt.Sprintf("Row %d: %s", rowErr.Row, rowErr.Message)
 }
 
-func parseVehicleFromCSV(record []string, headerMap map[string]int, organizationID, locationID string) (*models.Vehicle, error) {
+// writeRejectedRowsCSV writes the rejected rows in their original layout plus an errors column,
+// so they can be corrected and uploaded again
+func writeRejectedRowsCSV(w http.ResponseWriter, status int, headers []string, rejected []models.ImportRejectedRow) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="rejected_rows.csv"`)
+	w.WriteHeader(status)
+
+	writer := csv.NewWriter(w)
+	writer.Write(append(append([]string{}, headers...), "errors"))
+
+	for _, row := range rejected {
+		record := make([]string, len(headers))
+		copy(record, row.Record)
+		writer.Write(append(record, strings.Join(row.Errors, "; ")))
+	}
+	writer.Flush()
+}
+
+func parseVehicleFromCSV(record []string, headerMap map[string]int, organizationID, locationID, currency string) (*models.Vehicle, []RowError) {
+	var errs []RowError
+	reject := func(column, code, message string) {
+		errs = append(errs, RowError{Column: column, Code: code, Message: message})
+	}
+
 	getValue := func(key string) string {
 		if idx, exists := headerMap[key]; exists && idx < len(record) {
 			return strings.TrimSpace(record[idx])
@@ -144,7 +777,10 @@ func parseVehicleFromCSV(record []string, headerMap map[string]int, organization
 		if val == "" {
 			return 0
 		}
-		intVal, _ := strconv.Atoi(val)
+		intVal, err := strconv.Atoi(val)
+		if err != nil || intVal < 0 {
+			reject(key, RowErrorInvalid, fmt.Sprintf("must be a non-negative whole number, got %q", val))
+		}
 		return intVal
 	}
 
@@ -153,7 +789,10 @@ func parseVehicleFromCSV(record []string, headerMap map[string]int, organization
 		if val == "" {
 			return 0
 		}
-		floatVal, _ := strconv.ParseFloat(val, 64)
+		floatVal, err := strconv.ParseFloat(val, 64)
+		if err != nil || floatVal < 0 {
+			reject(key, RowErrorInvalid, fmt.Sprintf("must be a non-negative number, got %q", val))
+		}
 		return floatVal
 	}
 
@@ -163,13 +802,19 @@ func parseVehicleFromCSV(record []string, headerMap map[string]int, organization
 	model := getValue("model")
 	yearStr := getValue("year")
 
-	if vin == "" || make == "" || model == "" || yearStr == "" {
-		return nil, fmt.Errorf("missing required fields (vin, make, model, year)")
+	for _, field := range []struct{ column, value string }{{"vin", vin}, {"make", make}, {"model", model}, {"year", yearStr}} {
+		if field.value == "" {
+			reject(field.column, RowErrorRequired, "is required")
+		}
+	}
+
+	if vin != "" && len(vin) > 17 {
+		reject("vin", RowErrorInvalid, fmt.Sprintf("must be at most 17 characters, got %d", len(vin)))
 	}
 
 	year, err := strconv.Atoi(yearStr)
-	if err != nil || year < 1900 || year > 2100 {
-		return nil, fmt.Errorf("invalid year: %s", yearStr)
+	if yearStr != "" && (err != nil || year < 1900 || year > 2100) {
+		reject("year", RowErrorInvalid, fmt.Sprintf("invalid year: %s", yearStr))
 	}
 
 	// Parse condition
@@ -181,7 +826,7 @@ func parseVehicleFromCSV(record []string, headerMap map[string]int, organization
 	}
 
 	// Parse features (pipe-separated)
-	var features []string
+	features := []string{}
 	featuresStr := getValue("features")
 	if featuresStr != "" {
 		features = strings.Split(featuresStr, "|")
@@ -190,6 +835,30 @@ func parseVehicleFromCSV(record []string, headerMap map[string]int, organization
 		}
 	}
 
+	// Parse warranty expiration (YYYY-MM-DD)
+	var warrantyExpiration *time.Time
+	if val := getValue("warranty_expiration_date"); val != "" {
+		parsed, err := time.Parse("2006-01-02", val)
+		if err != nil {
+			reject("warranty_expiration_date", RowErrorInvalid, fmt.Sprintf("must be a date in YYYY-MM-DD format, got %q", val))
+		} else {
+			warrantyExpiration = &parsed
+		}
+	}
+
+	// A warranty is implied by its expiration or type unless has_warranty says otherwise
+	hasWarranty := warrantyExpiration != nil || getValue("warranty_type") != ""
+	if val := getValue("has_warranty"); val != "" {
+		switch strings.ToLower(val) {
+		case "true", "yes", "y", "1":
+			hasWarranty = true
+		case "false", "no", "n", "0":
+			hasWarranty = false
+		default:
+			reject("has_warranty", RowErrorInvalid, fmt.Sprintf("must be true or false, got %q", val))
+		}
+	}
+
 	vehicle := &models.Vehicle{
 		OrganizationID:       organizationID,
 		LocationID:           locationID,
@@ -216,12 +885,19 @@ func parseVehicleFromCSV(record []string, headerMap map[string]int, organization
 		Doors:                getIntValue("doors"),
 		StockNumber:          getValue("stock_number"),
 		Description:          getValue("description"),
-		DailyRate:            getFloatValue("daily_rate"),
-		WeeklyRate:           getFloatValue("weekly_rate"),
-		MonthlyRate:          getFloatValue("monthly_rate"),
+		DailyRate:            models.NewMoney(getFloatValue("daily_rate"), currency),
+		WeeklyRate:           models.NewMoney(getFloatValue("weekly_rate"), currency),
+		MonthlyRate:          models.NewMoney(getFloatValue("monthly_rate"), currency),
 		Features:             models.StringArray(features),
 		Images:               models.StringArray([]string{}),
 	}
+	vehicle.HasWarranty = hasWarranty
+	vehicle.WarrantyExpirationDate = warrantyExpiration
+	vehicle.WarrantyType = getValue("warranty_type")
+	vehicle.WarrantyDetails = getValue("warranty_details")
 
+	if len(errs) > 0 {
+		return nil, errs
+	}
 	return vehicle, nil
 }
@@ -208,3 +208,330 @@ func TestBulkUploadVehicles_InvalidLocation(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
 	}
 }
+
+// newBulkUploadRequest builds a multipart bulk upload request with the given form fields and file content
+func newBulkUploadRequest(t *testing.T, fields map[string]string, filename, content string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write([]byte(content))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/vehicles/bulk-upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestBulkUploadVehicles_DryRun(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+
+	csvContent := `vin,make,model,year,mileage
+1HGBH41JXMN109186,Honda,Accord,2022,15000
+1FTFW1ET8EFA12345,,F-150,1800,abc
+1HGBH41JXMN109186,Honda,Civic,2021,100`
+
+	req := newBulkUploadRequest(t, map[string]string{
+		"organization_id": org.ID,
+		"location_id":     loc.ID,
+		"mode":            BulkUploadModeDryRun,
+	}, "vehicles.csv", csvContent)
+	w := httptest.NewRecorder()
+
+	BulkUploadVehicles(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var result BulkUploadResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if result.Success != 1 || result.Failed != 2 {
+		t.Errorf("Expected 1 valid and 2 rejected rows, got %d/%d", result.Success, result.Failed)
+	}
+
+	// Row 3 has three problems: missing make, bad year and bad mileage
+	codes := map[string]string{}
+	for _, rowErr := range result.Report {
+		if rowErr.Row == 3 {
+			codes[rowErr.Column] = rowErr.Code
+		}
+	}
+	if codes["make"] != RowErrorRequired || codes["year"] != RowErrorInvalid || codes["mileage"] != RowErrorInvalid {
+		t.Errorf("Unexpected report for row 3: %v", result.Report)
+	}
+
+	// Row 4 repeats row 2's VIN
+	var duplicate bool
+	for _, rowErr := range result.Report {
+		if rowErr.Row == 4 && rowErr.Code == RowErrorDuplicate {
+			duplicate = true
+		}
+	}
+	if !duplicate {
+		t.Errorf("Expected duplicate VIN error for row 4, got %v", result.Report)
+	}
+
+	// Nothing is written in a dry run
+	var count int64
+	db.Model(&models.Vehicle{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no vehicles in database after dry run, got %d", count)
+	}
+}
+
+func TestBulkUploadVehicles_AtomicRollsBack(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+
+	csvContent := `vin,make,model,year
+1HGBH41JXMN109186,Honda,Accord,2022
+1FTFW1ET8EFA12345,Ford,F-150,not-a-year`
+
+	req := newBulkUploadRequest(t, map[string]string{
+		"organization_id": org.ID,
+		"location_id":     loc.ID,
+		"mode":            BulkUploadModeAtomic,
+	}, "vehicles.csv", csvContent)
+	w := httptest.NewRecorder()
+
+	BulkUploadVehicles(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	var count int64
+	db.Model(&models.Vehicle{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected atomic upload to write nothing, got %d vehicles", count)
+	}
+}
+
+func TestBulkUploadVehicles_RejectedRowsCSV(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+
+	csvContent := `vin,make,model,year
+1HGBH41JXMN109186,Honda,Accord,2022
+1FTFW1ET8EFA12345,Ford,,2023`
+
+	req := newBulkUploadRequest(t, map[string]string{
+		"organization_id": org.ID,
+		"location_id":     loc.ID,
+		"mode":            BulkUploadModeDryRun,
+		"report":          "csv",
+	}, "vehicles.csv", csvContent)
+	w := httptest.NewRecorder()
+
+	BulkUploadVehicles(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Expected text/csv content type, got %s", ct)
+	}
+
+	expected := "vin,make,model,year,errors\n1FTFW1ET8EFA12345,Ford,,2023,model: is required\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected rejected rows CSV %q, got %q", expected, w.Body.String())
+	}
+}
+
+func TestParseVehicleFromCSV_ReportsEveryColumn(t *testing.T) {
+	headerMap := map[string]int{"vin": 0, "make": 1, "model": 2, "year": 3, "daily_rate": 4}
+
+	vehicle, errs := parseVehicleFromCSV([]string{"", "Honda", "", "20x", "-5"}, headerMap, "org", "loc", "USD")
+	if vehicle != nil {
+		t.Error("Expected no vehicle for an invalid row")
+	}
+
+	columns := map[string]bool{}
+	for _, rowErr := range errs {
+		columns[rowErr.Column] = true
+	}
+	for _, column := range []string{"vin", "model", "year", "daily_rate"} {
+		if !columns[column] {
+			t.Errorf("Expected an error for column %s, got %v", column, errs)
+		}
+	}
+}
+
+func TestBulkUploadVehicles_UpsertByVIN(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	accord := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1FTFW1ET8EFA12345", "Ford", "F-150", 2023)
+	sold := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "5YJ3E1EA7KF317000", "Tesla", "Model 3", 2019)
+	moving := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "WBA3A5C50CF256651", "BMW", "328i", 2020)
+	db.Model(moving).Update("status", models.VehicleStatusInTransit)
+	retired := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "2HGFC2F59KH542853", "Honda", "Civic", 2019)
+	db.Model(retired).Update("status", models.VehicleStatusInactive)
+
+	// Accord gets new mileage, the F-150 and the Civic, which staff retired, are unchanged, the
+	// Camry is new and the Tesla and the BMW, which is being transferred, are missing
+	csvContent := `vin,make,model,year,mileage
+1HGBH41JXMN109186,Honda,Accord,2022,30000
+1FTFW1ET8EFA12345,Ford,F-150,2023,0
+2HGFC2F59KH542853,Honda,Civic,2019,0
+4T1BF1FK5CU500000,Toyota,Camry,2021,12000`
+
+	req := newBulkUploadRequest(t, map[string]string{
+		"organization_id":    org.ID,
+		"location_id":        loc.ID,
+		"on_conflict":        OnConflictUpdate,
+		"deactivate_missing": "true",
+	}, "vehicles.csv", csvContent)
+	w := httptest.NewRecorder()
+
+	BulkUploadVehicles(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var result BulkUploadResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if result.Created != 1 || result.Updated != 1 || result.Unchanged != 2 || result.Deactivated != 1 {
+		t.Errorf("Expected 1 created, 1 updated, 2 unchanged and 1 deactivated, got %d/%d/%d/%d",
+			result.Created, result.Updated, result.Unchanged, result.Deactivated)
+	}
+
+	var updated models.Vehicle
+	db.First(&updated, "id = ?", accord.ID)
+	if updated.Mileage != 30000 {
+		t.Errorf("Expected Accord mileage 30000, got %d", updated.Mileage)
+	}
+
+	var missing models.Vehicle
+	db.First(&missing, "id = ?", sold.ID)
+	if missing.Status != models.VehicleStatusInactive {
+		t.Errorf("Expected missing vehicle to be inactive, got %s", missing.Status)
+	}
+
+	db.First(&missing, "id = ?", moving.ID)
+	if missing.Status != models.VehicleStatusInTransit {
+		t.Errorf("Expected the vehicle in transit to be left alone, got %s", missing.Status)
+	}
+	if len(result.DeactivationSkipped) != 1 || result.DeactivationSkipped[0] != moving.VIN {
+		t.Errorf("Expected the vehicle in transit to be reported as skipped, got %v", result.DeactivationSkipped)
+	}
+
+	// When the Tesla is back in the file the import brings it back, but not the retired Civic
+	req = newBulkUploadRequest(t, map[string]string{
+		"organization_id":    org.ID,
+		"location_id":        loc.ID,
+		"on_conflict":        OnConflictUpdate,
+		"deactivate_missing": "true",
+	}, "vehicles.csv", csvContent+"\n5YJ3E1EA7KF317000,Tesla,Model 3,2019,0")
+	w = httptest.NewRecorder()
+	BulkUploadVehicles(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	db.First(&missing, "id = ?", sold.ID)
+	if missing.Status != models.VehicleStatusAvailable || missing.ImportDeactivated {
+		t.Errorf("Expected the vehicle the import deactivated to be available again, got %s", missing.Status)
+	}
+	db.First(&missing, "id = ?", retired.ID)
+	if missing.Status != models.VehicleStatusInactive {
+		t.Errorf("Expected the vehicle staff deactivated to stay inactive, got %s", missing.Status)
+	}
+}
+
+func TestBulkUploadVehicles_OnConflictSkip(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	csvContent := `vin,make,model,year
+1HGBH41JXMN109186,Honda,Civic,2020`
+
+	req := newBulkUploadRequest(t, map[string]string{
+		"organization_id": org.ID,
+		"location_id":     loc.ID,
+		"on_conflict":     OnConflictSkip,
+	}, "vehicles.csv", csvContent)
+	w := httptest.NewRecorder()
+
+	BulkUploadVehicles(w, req)
+
+	var result BulkUploadResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if result.Skipped != 1 || result.Failed != 0 {
+		t.Errorf("Expected 1 skipped and 0 failed, got %d/%d", result.Skipped, result.Failed)
+	}
+
+	var vehicle models.Vehicle
+	db.First(&vehicle, "vin = ?", "1HGBH41JXMN109186")
+	if vehicle.Model != "Accord" {
+		t.Errorf("Expected skipped vehicle to keep model Accord, got %s", vehicle.Model)
+	}
+}
+
+func TestParseVehicleFromCSV_Warranty(t *testing.T) {
+	headerMap := map[string]int{"vin": 0, "make": 1, "model": 2, "year": 3, "warranty_expiration_date": 4, "warranty_type": 5, "has_warranty": 6}
+
+	vehicle, errs := parseVehicleFromCSV([]string{"1HGBH41JXMN109186", "Honda", "Accord", "2022", "2027-06-30", "Factory", ""}, headerMap, "org", "loc", "USD")
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	if !vehicle.HasWarranty || vehicle.WarrantyType != "Factory" || vehicle.WarrantyExpirationDate == nil {
+		t.Errorf("Expected a factory warranty implied by its expiration, got %+v", vehicle)
+	}
+
+	vehicle, _ = parseVehicleFromCSV([]string{"1HGBH41JXMN109186", "Honda", "Accord", "2022", "2027-06-30", "Factory", "no"}, headerMap, "org", "loc", "USD")
+	if vehicle.HasWarranty {
+		t.Error("Expected has_warranty=no to override the implied warranty")
+	}
+
+	_, errs = parseVehicleFromCSV([]string{"1HGBH41JXMN109186", "Honda", "Accord", "2022", "", "", "maybe"}, headerMap, "org", "loc", "USD")
+	if len(errs) != 1 || errs[0].Column != "has_warranty" {
+		t.Errorf("Expected a has_warranty error, got %v", errs)
+	}
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func validateVehicleClass(class *models.VehicleClass) error {
+	if class.Code == "" || class.Description == "" {
+		return fmt.Errorf("code and description are required")
+	}
+	if len(class.Code) > 10 {
+		return fmt.Errorf("code cannot be longer than 10 characters")
+	}
+	if class.DailyRate < 0 || class.WeeklyRate < 0 || class.MonthlyRate < 0 || class.Seats < 0 {
+		return fmt.Errorf("rates and seats cannot be negative")
+	}
+
+	query := database.DB.Model(&models.VehicleClass{}).Where("organization_id = ? AND code = ?", class.OrganizationID, class.Code)
+	if class.ID != "" {
+		query = query.Where("id <> ?", class.ID)
+	}
+	var count int64
+	query.Count(&count)
+	if count > 0 {
+		return fmt.Errorf("code %s is already in use", class.Code)
+	}
+	return nil
+}
+
+// checkVehicleClassID checks a class a vehicle is assigned to belongs to its organization
+func checkVehicleClassID(organizationID string, classID *string) error {
+	if classID == nil {
+		return nil
+	}
+	var class models.VehicleClass
+	if err := database.DB.First(&class, "id = ?", *classID).Error; err != nil {
+		return fmt.Errorf("vehicle class not found")
+	}
+	if class.OrganizationID != organizationID {
+		return fmt.Errorf("vehicle class belongs to a different organization")
+	}
+	return nil
+}
+
+// classAvailability counts the vehicles of a class at a pickup location that are free for a
+// period: vehicles in service there, neither in maintenance nor in transit and with no open
+// maintenance record, less those booked and the class's unassigned reservations
+func classAvailability(db *gorm.DB, class *models.VehicleClass, locationID string, pickupAt, returnAt time.Time) (models.ClassAvailability, error) {
+	availability := models.ClassAvailability{VehicleClass: *class}
+
+	var vehicleIDs []string
+	if err := db.Model(&models.Vehicle{}).
+		Where("vehicle_class_id = ? AND location_id = ? AND status NOT IN ? AND is_eligible_for_service = ?",
+			class.ID, locationID, []models.VehicleStatus{models.VehicleStatusInactive, models.VehicleStatusMaintenance, models.VehicleStatusInTransit}, true).
+		Where("NOT EXISTS (SELECT 1 FROM maintenance_records WHERE maintenance_records.vehicle_id = vehicles.id AND maintenance_records.closed_at IS NULL)").
+		Pluck("id", &vehicleIDs).Error; err != nil {
+		return availability, err
+	}
+
+	overlapping := func() *gorm.DB {
+		return db.Model(&models.Rental{}).Where("status IN ? AND pickup_at < ? AND return_at > ?",
+			models.RentalStatusesHoldingVehicle, returnAt, pickupAt)
+	}
+	var assigned, unassigned int64
+	if len(vehicleIDs) > 0 {
+		if err := overlapping().Where("vehicle_id IN ?", vehicleIDs).
+			Distinct("vehicle_id").Count(&assigned).Error; err != nil {
+			return availability, err
+		}
+	}
+	if err := overlapping().Where("vehicle_id IS NULL AND vehicle_class_id = ? AND pickup_location_id = ?", class.ID, locationID).
+		Count(&unassigned).Error; err != nil {
+		return availability, err
+	}
+
+	availability.Total = len(vehicleIDs)
+	availability.Booked = int(assigned + unassigned)
+	availability.Available = availability.Total - availability.Booked
+	if availability.Available < 0 {
+		availability.Available = 0
+	}
+	return availability, nil
+}
+
+// classRentalAvailability checks whether a class can be reserved: the booking's locations must
+// be open and a vehicle of the class must be free at the pickup location
+func classRentalAvailability(db *gorm.DB, class *models.VehicleClass, pickup, dropoff *models.Location, pickupAt, returnAt time.Time) (models.RentalAvailability, error) {
+	availability, err := bookingAvailability(db, pickup, dropoff, pickupAt, returnAt)
+	if err != nil {
+		return availability, err
+	}
+
+	counts, err := classAvailability(db, class, pickup.ID, pickupAt, returnAt)
+	if err != nil {
+		return availability, err
+	}
+	if counts.Available < 1 {
+		availability.Reasons = append(availability.Reasons, fmt.Sprintf("no %s vehicles are free at %s for this period", class.Code, pickup.Name))
+	}
+	availability.Available = len(availability.Reasons) == 0
+	return availability, nil
+}
+
+// vehicleRates returns the vehicle with the rates it rents at: those of the class it was
+// reserved as, or of its own class when it has no rates of its own. Class rates are taken in
+// the currency of the vehicle's rates.
+func vehicleRates(vehicle models.Vehicle, reservedClassID *string) (models.Vehicle, error) {
+	classID := reservedClassID
+	if classID == nil && vehicle.DailyRate.Amount == 0 {
+		classID = vehicle.VehicleClassID
+	}
+	if classID == nil {
+		return vehicle, nil
+	}
+	vehicle.VehicleClassID = classID
+
+	var class models.VehicleClass
+	if err := database.DB.First(&class, "id = ?", *classID).Error; err != nil {
+		return vehicle, err
+	}
+	currency := vehicle.DailyRate.Currency
+	vehicle.DailyRate = models.NewMoney(class.DailyRate, currency)
+	vehicle.WeeklyRate = models.NewMoney(class.WeeklyRate, currency)
+	vehicle.MonthlyRate = models.NewMoney(class.MonthlyRate, currency)
+	return vehicle, nil
+}
+
+// GetVehicleClasses lists vehicle classes by code, optionally filtered by organization_id
+func GetVehicleClasses(w http.ResponseWriter, r *http.Request) {
+	query := database.DB.Order("code")
+	if organizationID := r.URL.Query().Get("organization_id"); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+
+	var classes []models.VehicleClass
+	if err := query.Find(&classes).Error; err != nil {
+		http.Error(w, "Failed to fetch vehicle classes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(classes)
+}
+
+func GetVehicleClass(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var class models.VehicleClass
+	if err := database.DB.First(&class, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle class not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(class)
+}
+
+func CreateVehicleClass(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateVehicleClassRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.OrganizationID == "" {
+		http.Error(w, "Organization ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var organization models.Organization
+	if err := database.DB.First(&organization, "id = ?", req.OrganizationID).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusBadRequest)
+		return
+	}
+
+	class := models.VehicleClass{
+		OrganizationID: req.OrganizationID,
+		Code:           strings.ToUpper(strings.TrimSpace(req.Code)),
+		Description:    strings.TrimSpace(req.Description),
+		DailyRate:      req.DailyRate,
+		WeeklyRate:     req.WeeklyRate,
+		MonthlyRate:    req.MonthlyRate,
+		Seats:          req.Seats,
+		ImageURL:       req.ImageURL,
+		IsActive:       true,
+	}
+
+	if err := validateVehicleClass(&class); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DB.Create(&class).Error; err != nil {
+		http.Error(w, "Failed to create vehicle class", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(class)
+}
+
+func UpdateVehicleClass(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.UpdateVehicleClassRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var class models.VehicleClass
+	if err := database.DB.First(&class, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle class not found", http.StatusNotFound)
+		return
+	}
+
+	// Update fields
+	class.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	class.Description = strings.TrimSpace(req.Description)
+	class.DailyRate = req.DailyRate
+	class.WeeklyRate = req.WeeklyRate
+	class.MonthlyRate = req.MonthlyRate
+	class.Seats = req.Seats
+	class.ImageURL = req.ImageURL
+	class.IsActive = req.IsActive
+
+	if err := validateVehicleClass(&class); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DB.Save(&class).Error; err != nil {
+		http.Error(w, "Failed to update vehicle class", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(class)
+}
+
+// DeleteVehicleClass deletes a class that no vehicle or open reservation uses
+func DeleteVehicleClass(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicles, reservations int64
+	database.DB.Model(&models.Vehicle{}).Where("vehicle_class_id = ?", id).Count(&vehicles)
+	database.DB.Model(&models.Rental{}).Where("vehicle_class_id = ? AND status IN ?", id, models.RentalStatusesHoldingVehicle).
+		Count(&reservations)
+	if vehicles > 0 || reservations > 0 {
+		http.Error(w, fmt.Sprintf("Vehicle class is used by %d vehicles and %d open rentals", vehicles, reservations), http.StatusConflict)
+		return
+	}
+
+	result := database.DB.Delete(&models.VehicleClass{}, "id = ?", id)
+	if result.Error != nil {
+		http.Error(w, "Failed to delete vehicle class", http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		http.Error(w, "Vehicle class not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLocationClassAvailability lists how many vehicles of each active class can be reserved
+// for pickup at the location between pickup_at and return_at (RFC 3339 times)
+func GetLocationClassAvailability(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	pickupAt, err := time.Parse(time.RFC3339, r.URL.Query().Get("pickup_at"))
+	if err != nil {
+		http.Error(w, "pickup_at must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+	returnAt, err := time.Parse(time.RFC3339, r.URL.Query().Get("return_at"))
+	if err != nil {
+		http.Error(w, "return_at must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+	if !returnAt.After(pickupAt) {
+		http.Error(w, "return_at must be after pickup_at", http.StatusBadRequest)
+		return
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, "id = ?", id).Error; err != nil {
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+
+	var classes []models.VehicleClass
+	if err := database.DB.Where("organization_id = ? AND is_active = ?", location.OrganizationID, true).
+		Order("code").Find(&classes).Error; err != nil {
+		http.Error(w, "Failed to fetch vehicle classes", http.StatusInternalServerError)
+		return
+	}
+
+	availability := []models.ClassAvailability{}
+	for i := range classes {
+		counts, err := classAvailability(database.DB, &classes[i], location.ID, pickupAt, returnAt)
+		if err != nil {
+			http.Error(w, "Failed to check availability", http.StatusInternalServerError)
+			return
+		}
+		availability = append(availability, counts)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(availability)
+}
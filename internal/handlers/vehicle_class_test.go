@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClassReservation_AssignedAtCheckout(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	class := models.VehicleClass{OrganizationID: org.ID, Code: "ICAR", Description: "Intermediate car", DailyRate: 60, IsActive: true}
+	db.Create(&class)
+	db.Model(vehicle).Update("vehicle_class_id", class.ID)
+
+	pickupAt := time.Now().UTC().Add(time.Hour).Truncate(time.Minute)
+	book := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.CreateRentalRequest{
+			VehicleClassID: class.ID, PickupLocationID: loc.ID, PickupAt: pickupAt, ReturnAt: pickupAt.AddDate(0, 0, 3),
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/rentals", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		CreateRental(w, req)
+		return w
+	}
+
+	w := book()
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var rental models.Rental
+	json.NewDecoder(w.Body).Decode(&rental)
+	if rental.VehicleID != nil {
+		t.Errorf("Expected a class reservation without a vehicle, got %s", *rental.VehicleID)
+	}
+
+	// The class's only vehicle is now spoken for
+	if w := book(); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a second reservation of a full class, got %d", http.StatusConflict, w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/locations/"+loc.ID+"/class-availability?pickup_at="+
+		pickupAt.Format(time.RFC3339)+"&return_at="+pickupAt.AddDate(0, 0, 1).Format(time.RFC3339), nil)
+	req = withURLParams(req, "id", loc.ID)
+	w = httptest.NewRecorder()
+	GetLocationClassAvailability(w, req)
+
+	var availability []models.ClassAvailability
+	json.NewDecoder(w.Body).Decode(&availability)
+	if len(availability) != 1 || availability[0].Total != 1 || availability[0].Available != 0 {
+		t.Errorf("Expected 1 vehicle with none available, got %+v", availability)
+	}
+
+	body := fmt.Sprintf(`{"type":"checkout","rental_id":%q,"mileage":12000,"fuel_level":100,"cleanliness":"clean"}`, rental.ID)
+	req = httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/inspections", bytes.NewBufferString(body))
+	req = withURLParams(req, "id", vehicle.ID)
+	w = httptest.NewRecorder()
+	CreateInspection(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	db.First(&rental, "id = ?", rental.ID)
+	if rental.VehicleID == nil || *rental.VehicleID != vehicle.ID {
+		t.Errorf("Expected the vehicle to be assigned to the reservation at checkout, got %v", rental.VehicleID)
+	}
+
+	// The vehicle has no rates of its own, so the rental is priced at the class's
+	invoice, err := buildInvoice(&rental)
+	if err != nil {
+		t.Fatalf("Failed to build invoice: %v", err)
+	}
+	if invoice.Subtotal.Amount != 18000 {
+		t.Errorf("Expected a subtotal of 180.00 for 3 days at the class rate, got %+v", invoice.Subtotal)
+	}
+}
+
+func TestClassReservation_HeldAgainstWalkIns(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	inShop := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "2HGFC2F59KH542853", "Honda", "Civic", 2021)
+
+	class := models.VehicleClass{OrganizationID: org.ID, Code: "ICAR", Description: "Intermediate car", DailyRate: 60, IsActive: true}
+	db.Create(&class)
+	db.Model(&models.Vehicle{}).Where("id IN ?", []string{vehicle.ID, inShop.ID}).Update("vehicle_class_id", class.ID)
+
+	// A vehicle with open maintenance is not counted toward the class
+	db.Create(&models.MaintenanceRecord{OrganizationID: org.ID, VehicleID: inShop.ID, Type: models.MaintenanceTypeBrakes, OpenedAt: time.Now()})
+	db.Model(inShop).Update("status", models.VehicleStatusMaintenance)
+
+	pickupAt := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Minute)
+	counts, err := classAvailability(db, &class, loc.ID, pickupAt, pickupAt.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to count availability: %v", err)
+	}
+	if counts.Total != 1 {
+		t.Errorf("Expected only the vehicle out of the shop to count, got %d", counts.Total)
+	}
+
+	body, _ := json.Marshal(models.CreateRentalRequest{
+		VehicleClassID: class.ID, PickupLocationID: loc.ID, PickupAt: pickupAt, ReturnAt: pickupAt.AddDate(0, 0, 1),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/rentals", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	CreateRental(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// The reservation holds the class's last vehicle, so it cannot leave as a walk-in
+	req = httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/inspections",
+		bytes.NewBufferString(`{"type":"checkout","mileage":12000,"fuel_level":100,"cleanliness":"clean"}`))
+	req = withURLParams(req, "id", vehicle.ID)
+	w = httptest.NewRecorder()
+	CreateInspection(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a walk-in taking a reserved class's vehicle, got %d", http.StatusConflict, w.Code)
+	}
+
+	// Back before the reservation starts, it can
+	returnAt := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+	req = httptest.NewRequest(http.MethodPost, "/api/vehicles/"+vehicle.ID+"/inspections",
+		bytes.NewBufferString(`{"type":"checkout","mileage":12000,"fuel_level":100,"cleanliness":"clean","return_at":"`+returnAt+`"}`))
+	req = withURLParams(req, "id", vehicle.ID)
+	w = httptest.NewRecorder()
+	CreateInspection(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
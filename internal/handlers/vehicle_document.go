@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fleetpass/internal/database"
+	"fleetpass/internal/jobs"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func validateVehicleDocument(document *models.VehicleDocument) error {
+	valid := false
+	for _, t := range models.VehicleDocumentTypes {
+		if document.Type == t {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid document type: %s", document.Type)
+	}
+	if document.IsMandatory && document.ExpiryDate == nil {
+		return fmt.Errorf("expiry_date is required for mandatory documents")
+	}
+	if document.IssueDate != nil && document.ExpiryDate != nil && document.ExpiryDate.Before(*document.IssueDate) {
+		return fmt.Errorf("expiry_date cannot be before issue_date")
+	}
+	return nil
+}
+
+// errLapsedDocuments is returned when a vehicle is put back in service with a lapsed mandatory document
+var errLapsedDocuments = errors.New("vehicle has lapsed mandatory documents")
+
+// checkServiceEligible refuses to make a vehicle eligible for service while any of its
+// mandatory documents has lapsed
+func checkServiceEligible(vehicleID string) error {
+	lapsed, err := jobs.LapsedMandatoryDocuments(database.DB, vehicleID, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(lapsed) > 0 {
+		return fmt.Errorf("%w: %s; renew them first", errLapsedDocuments, strings.Join(lapsed, ", "))
+	}
+	return nil
+}
+
+// findVehicleDocument loads the vehicle and document named in the URL, writing an error if either is missing
+func findVehicleDocument(w http.ResponseWriter, r *http.Request) (*models.Vehicle, *models.VehicleDocument, bool) {
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	var document models.VehicleDocument
+	if err := database.DB.First(&document, "id = ? AND vehicle_id = ?", chi.URLParam(r, "documentId"), vehicle.ID).Error; err != nil {
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return &vehicle, &document, true
+}
+
+// saveVehicleDocument writes the document and re-applies the vehicle's service eligibility
+func saveVehicleDocument(document *models.VehicleDocument) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(document).Error; err != nil {
+			return err
+		}
+		if _, err := jobs.ApplyDocumentEligibility(tx, document.VehicleID, time.Now()); err != nil {
+			return err
+		}
+		return tx.First(document, "id = ?", document.ID).Error
+	})
+}
+
+// GetVehicleDocuments returns a vehicle's documents, expiring soonest first
+func GetVehicleDocuments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	query := database.DB.Where("vehicle_id = ?", id)
+	if documentType := r.URL.Query().Get("type"); documentType != "" {
+		query = query.Where("type = ?", documentType)
+	}
+
+	var documents []models.VehicleDocument
+	if err := query.Order("expiry_date").Find(&documents).Error; err != nil {
+		http.Error(w, "Failed to fetch documents", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(documents)
+}
+
+func GetVehicleDocument(w http.ResponseWriter, r *http.Request) {
+	_, document, ok := findVehicleDocument(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(document)
+}
+
+// CreateVehicleDocument adds a document to a vehicle. Adding a mandatory document that has
+// already lapsed takes the vehicle out of service straight away.
+func CreateVehicleDocument(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateVehicleDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	document := models.VehicleDocument{
+		OrganizationID:   vehicle.OrganizationID,
+		VehicleID:        vehicle.ID,
+		Type:             req.Type,
+		Number:           req.Number,
+		IssuingAuthority: req.IssuingAuthority,
+		IssueDate:        req.IssueDate,
+		ExpiryDate:       req.ExpiryDate,
+		FileURL:          req.FileURL,
+		FileName:         req.FileName,
+		IsMandatory:      req.Type.MandatoryByDefault(),
+		Notes:            req.Notes,
+	}
+	if req.IsMandatory != nil {
+		document.IsMandatory = *req.IsMandatory
+	}
+
+	if err := validateVehicleDocument(&document); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := saveVehicleDocument(&document); err != nil {
+		http.Error(w, "Failed to create document", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(document)
+}
+
+// UpdateVehicleDocument replaces a document's details. Renewing a lapsed mandatory document
+// returns the vehicle to service if nothing else is holding it out.
+func UpdateVehicleDocument(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateVehicleDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_, document, ok := findVehicleDocument(w, r)
+	if !ok {
+		return
+	}
+
+	// Update fields
+	document.Type = req.Type
+	document.Number = req.Number
+	document.IssuingAuthority = req.IssuingAuthority
+	document.IssueDate = req.IssueDate
+	document.ExpiryDate = req.ExpiryDate
+	document.FileURL = req.FileURL
+	document.FileName = req.FileName
+	document.IsMandatory = req.IsMandatory
+	document.Notes = req.Notes
+
+	if err := validateVehicleDocument(document); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := saveVehicleDocument(document); err != nil {
+		http.Error(w, "Failed to update document", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(document)
+}
+
+func DeleteVehicleDocument(w http.ResponseWriter, r *http.Request) {
+	_, document, ok := findVehicleDocument(w, r)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(document).Error; err != nil {
+			return err
+		}
+		if document.SuspendedService {
+			return jobs.ReleaseDocumentSuspension(tx, document.VehicleID)
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, "Failed to delete document", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDocumentExpiryDashboard lists an organization's documents that have expired or expire
+// within days (default 30), soonest first, with counts for each and the number of vehicles
+// held out of service by a lapsed document. location_id and type narrow the list.
+func GetDocumentExpiryDashboard(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	days := 30
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			http.Error(w, "days must be a non-negative whole number", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	var organization models.Organization
+	if err := database.DB.First(&organization, "id = ?", id).Error; err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	}
+
+	vehicleQuery := database.DB.Where("organization_id = ?", id)
+	if locationID := r.URL.Query().Get("location_id"); locationID != "" {
+		vehicleQuery = vehicleQuery.Where("location_id = ?", locationID)
+	}
+	var vehicles []models.Vehicle
+	if err := vehicleQuery.Find(&vehicles).Error; err != nil {
+		http.Error(w, "Failed to fetch vehicles", http.StatusInternalServerError)
+		return
+	}
+	vehicleIDs := make([]string, len(vehicles))
+	byID := make(map[string]*models.Vehicle, len(vehicles))
+	for i := range vehicles {
+		vehicleIDs[i] = vehicles[i].ID
+		byID[vehicles[i].ID] = &vehicles[i]
+	}
+
+	now := time.Now()
+	dashboard := models.DocumentExpiryDashboard{
+		OrganizationID: id,
+		Days:           days,
+		Documents:      []models.ExpiringDocument{},
+	}
+
+	if len(vehicleIDs) > 0 {
+		query := database.DB.Where("vehicle_id IN ? AND expiry_date <= ?", vehicleIDs, now.AddDate(0, 0, days))
+		if documentType := r.URL.Query().Get("type"); documentType != "" {
+			query = query.Where("type = ?", documentType)
+		}
+
+		var documents []models.VehicleDocument
+		if err := query.Order("expiry_date").Find(&documents).Error; err != nil {
+			http.Error(w, "Failed to fetch documents", http.StatusInternalServerError)
+			return
+		}
+
+		suspended := make(map[string]bool)
+		for _, document := range documents {
+			vehicle := byID[document.VehicleID]
+			item := models.ExpiringDocument{
+				VehicleDocument: document,
+				LocationID:      vehicle.LocationID,
+				VIN:             vehicle.VIN,
+				Make:            vehicle.Make,
+				Model:           vehicle.Model,
+				Year:            vehicle.Year,
+				LicensePlate:    vehicle.LicensePlate,
+				Status:          document.Status(now, days),
+				DaysRemaining:   int(document.ExpiryDate.Sub(now).Hours() / 24),
+			}
+			if item.Status == models.VehicleDocumentStatusExpired {
+				dashboard.Expired++
+			} else {
+				dashboard.Expiring++
+			}
+			if document.SuspendedService {
+				suspended[document.VehicleID] = true
+			}
+			dashboard.Documents = append(dashboard.Documents, item)
+		}
+		dashboard.SuspendedVehicles = len(suspended)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboard)
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatXLSX   = "xlsx"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// exportFlushEvery is how many rows are written between flushes to the client
+const exportFlushEvery = 500
+
+// exportableColumns holds every JSON field of a vehicle
+var exportableColumns = func() map[string]bool {
+	columns := make(map[string]bool)
+	vehicleType := reflect.TypeOf(models.Vehicle{})
+	for i := 0; i < vehicleType.NumField(); i++ {
+		name := strings.Split(vehicleType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			columns[name] = true
+		}
+	}
+	return columns
+}()
+
+// ExportVehicles streams the vehicles matching the list filters as CSV (default), XLSX or NDJSON.
+// columns selects and orders the fields; by default the bulk upload columns are exported, so a
+// CSV export can be uploaded again unchanged.
+func ExportVehicles(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportFormatCSV
+	}
+	if format != ExportFormatCSV && format != ExportFormatXLSX && format != ExportFormatNDJSON {
+		http.Error(w, "format must be one of csv, xlsx or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	columns := importFields
+	if param := r.URL.Query().Get("columns"); param != "" {
+		columns = nil
+		for _, column := range strings.Split(param, ",") {
+			column = strings.TrimSpace(column)
+			if !exportableColumns[column] {
+				http.Error(w, fmt.Sprintf("Unknown column: %s", column), http.StatusBadRequest)
+				return
+			}
+			columns = append(columns, column)
+		}
+	}
+
+	query, err := vehicleListQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		http.Error(w, "Failed to fetch vehicles", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	// Vehicles are read one at a time so large fleets are never held in memory
+	next := func() (map[string]interface{}, error) {
+		if !rows.Next() {
+			return nil, rows.Err()
+		}
+		var vehicle models.Vehicle
+		if err := database.DB.ScanRows(rows, &vehicle); err != nil {
+			return nil, err
+		}
+		return exportValues(&vehicle)
+	}
+
+	filename := fmt.Sprintf("vehicles-%s.%s", time.Now().Format("20060102"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	switch format {
+	case ExportFormatXLSX:
+		// The workbook is only sent once complete, so failures can still be reported
+		err = exportXLSX(w, columns, next)
+		if err != nil && w.Header().Get("Content-Type") == "" {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "Failed to export vehicles", http.StatusInternalServerError)
+			return
+		}
+	case ExportFormatNDJSON:
+		err = exportNDJSON(w, columns, next)
+	default:
+		err = exportCSV(w, columns, next)
+	}
+
+	// Headers are already sent, so a failure can only be logged
+	if err != nil {
+		log.Printf("Vehicle export failed: %v", err)
+	}
+}
+
+// exportValues decodes a vehicle into its JSON fields, keeping numbers exact
+func exportValues(vehicle *models.Vehicle) (map[string]interface{}, error) {
+	data, err := json.Marshal(vehicle)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	// Dates and rates are exported in the format bulk upload expects, rates in major units of
+	// the location's currency
+	if vehicle.WarrantyExpirationDate != nil {
+		values["warranty_expiration_date"] = vehicle.WarrantyExpirationDate.Format("2006-01-02")
+	}
+	for column, rate := range map[string]models.Money{
+		"daily_rate": vehicle.DailyRate, "weekly_rate": vehicle.WeeklyRate, "monthly_rate": vehicle.MonthlyRate,
+	} {
+		values[column] = json.Number(strconv.FormatFloat(rate.Float(), 'f', -1, 64))
+	}
+	return values, nil
+}
+
+// exportCell formats a value as bulk upload reads it: lists joined with "|", null as empty
+func exportCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = exportCell(item)
+		}
+		return strings.Join(items, "|")
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+func flushResponse(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func exportCSV(w http.ResponseWriter, columns []string, next func() (map[string]interface{}, error)) error {
+	w.Header().Set("Content-Type", "text/csv")
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for count := 1; ; count++ {
+		values, err := next()
+		if err != nil || values == nil {
+			writer.Flush()
+			return err
+		}
+		for i, column := range columns {
+			record[i] = exportCell(values[column])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		if count%exportFlushEvery == 0 {
+			writer.Flush()
+			flushResponse(w)
+		}
+	}
+}
+
+func exportNDJSON(w http.ResponseWriter, columns []string, next func() (map[string]interface{}, error)) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	var line bytes.Buffer
+	for count := 1; ; count++ {
+		values, err := next()
+		if err != nil || values == nil {
+			return err
+		}
+
+		// Write fields in the requested order rather than sorted by key
+		line.Reset()
+		line.WriteByte('{')
+		for i, column := range columns {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			key, _ := json.Marshal(column)
+			value, err := json.Marshal(values[column])
+			if err != nil {
+				return err
+			}
+			line.Write(key)
+			line.WriteByte(':')
+			line.Write(value)
+		}
+		line.WriteString("}\n")
+
+		if _, err := w.Write(line.Bytes()); err != nil {
+			return err
+		}
+		if count%exportFlushEvery == 0 {
+			flushResponse(w)
+		}
+	}
+}
+
+// exportXLSX writes rows through excelize's stream writer, which spills to a temporary file
+// instead of building the sheet in memory
+func exportXLSX(w http.ResponseWriter, columns []string, next func() (map[string]interface{}, error)) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	for row := 2; ; row++ {
+		values, err := next()
+		if err != nil {
+			return err
+		}
+		if values == nil {
+			break
+		}
+
+		cells := make([]interface{}, len(columns))
+		for i, column := range columns {
+			cells[i] = xlsxCell(values[column])
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		if err := stream.SetRow(cell, cells); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	return file.Write(w)
+}
+
+// xlsxCell keeps numbers numeric in the workbook and formats everything else as CSV does
+func xlsxCell(value interface{}) interface{} {
+	if number, ok := value.(json.Number); ok {
+		if f, err := number.Float64(); err == nil {
+			return f
+		}
+	}
+	return exportCell(value)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fleetpass/internal/database"
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExportCSV_RoundTripsThroughBulkUpload(t *testing.T) {
+	warranty := time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC)
+	original := &models.Vehicle{
+		OrganizationID:         "org-id",
+		LocationID:             "loc-id",
+		VIN:                    "1HGBH41JXMN109186",
+		Make:                   "Honda",
+		Model:                  "Accord",
+		Year:                   2022,
+		Trim:                   "EX-L, Sport", // needs quoting
+		ColorExterior:          "Silver",
+		Condition:              models.VehicleConditionCertifiedPreOwned,
+		Mileage:                15000,
+		MPGCity:                30,
+		Description:            "Line one\nline two",
+		DailyRate:              models.NewMoney(45.5, "USD"),
+		WeeklyRate:             models.NewMoney(280, "USD"),
+		MonthlyRate:            models.NewMoney(0, "USD"),
+		Features:               models.StringArray{"Bluetooth", "Backup Camera"},
+		Images:                 models.StringArray{},
+		WarrantyExpirationDate: &warranty,
+		Status:                 models.VehicleStatusAvailable,
+		IsEligibleForService:   true,
+	}
+
+	values, err := exportValues(original)
+	if err != nil {
+		t.Fatalf("Failed to export vehicle: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(importFields)
+	record := make([]string, len(importFields))
+	for i, column := range importFields {
+		record[i] = exportCell(values[column])
+	}
+	writer.Write(record)
+	writer.Flush()
+
+	source, err := newImportSource(&buf, ImportFormatCSV, "")
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	session, err := newImportSession(source.Headers(), "org-id", "loc-id", BulkUploadModePartial, OnConflictError, false)
+	if err != nil {
+		t.Fatalf("Failed to start session: %v", err)
+	}
+	session.currency = "USD"
+
+	row, err := source.Next()
+	imported := session.parseRow(2, row, err)
+	if len(imported.errors) > 0 {
+		t.Fatalf("Expected exported row to import cleanly, got %v", imported.errors)
+	}
+
+	if changed := changedColumns(original, imported.vehicle, importUpdatableColumns); len(changed) > 0 {
+		t.Errorf("Expected no changes after round trip, got %v", changed)
+	}
+	if !reflect.DeepEqual(original.Features, imported.vehicle.Features) {
+		t.Errorf("Expected features %v, got %v", original.Features, imported.vehicle.Features)
+	}
+}
+
+func TestExportVehicles_Formats(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1FTFW1ET8EFA12345", "Ford", "F-150", 2023)
+
+	// Filters and column choice
+	req := httptest.NewRequest(http.MethodGet, "/api/vehicles/export?make=honda&columns=vin,make,year", nil)
+	w := httptest.NewRecorder()
+
+	ExportVehicles(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	expected := "vin,make,year\n1HGBH41JXMN109186,Honda,2022\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected CSV %q, got %q", expected, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/vehicles/export?format=ndjson&year_min=2023&columns=vin,features", nil)
+	w = httptest.NewRecorder()
+
+	ExportVehicles(w, req)
+
+	if got := strings.TrimSpace(w.Body.String()); got != `{"vin":"1FTFW1ET8EFA12345","features":[]}` {
+		t.Errorf("Unexpected NDJSON export: %s", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/vehicles/export?format=xlsx", nil)
+	w = httptest.NewRecorder()
+
+	ExportVehicles(w, req)
+
+	workbook, err := excelize.OpenReader(w.Body)
+	if err != nil {
+		t.Fatalf("Failed to open exported workbook: %v", err)
+	}
+	defer workbook.Close()
+
+	rows, _ := workbook.GetRows(workbook.GetSheetName(0))
+	if len(rows) != 3 {
+		t.Errorf("Expected header and 2 rows, got %d rows", len(rows))
+	}
+}
+
+func TestExportVehicles_UnknownColumn(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/vehicles/export?columns=vin,price", nil)
+	w := httptest.NewRecorder()
+
+	ExportVehicles(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
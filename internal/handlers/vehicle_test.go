@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fleetpass/internal/database"
+	"fleetpass/internal/jobs"
 	"fleetpass/internal/models"
 	"fleetpass/internal/testutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -137,6 +140,28 @@ func TestCreateVehicle(t *testing.T) {
 	}
 }
 
+func TestCreateVehicle_TrashedVIN(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Delete(vehicle)
+
+	body, _ := json.Marshal(models.CreateVehicleRequest{LocationID: loc.ID, VIN: "1hgbh41jxmn109186", Make: "Honda", Model: "Accord", Year: 2022})
+	req := httptest.NewRequest(http.MethodPost, "/api/vehicles", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	CreateVehicle(w, req)
+
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "restore it first") {
+		t.Errorf("Expected status %d asking to restore the vehicle, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+}
+
 func TestCreateVehicle_ValidationError(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	defer testutil.CleanupTestDB(t, db)
@@ -252,3 +277,137 @@ func TestDeleteVehicle(t *testing.T) {
 		t.Error("Vehicle was not deleted from database")
 	}
 }
+
+func TestPatchVehicle_MergePatch(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Model(vehicle).Updates(models.Vehicle{DailyRate: models.NewMoney(49.99, "USD")})
+
+	// Only mileage is supplied; daily_rate and status must be preserved
+	body := bytes.NewBufferString(`{"mileage": 20000}`)
+	req := httptest.NewRequest(http.MethodPatch, "/api/vehicles/"+vehicle.ID, body)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", vehicle.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	PatchVehicle(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var updated models.Vehicle
+	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if updated.Mileage != 20000 {
+		t.Errorf("Expected mileage 20000, got %d", updated.Mileage)
+	}
+
+	if updated.DailyRate.Amount != 4999 {
+		t.Errorf("Expected daily rate 49.99 to be preserved, got %+v", updated.DailyRate)
+	}
+
+	if updated.Status != models.VehicleStatusAvailable {
+		t.Errorf("Expected status %s to be preserved, got %s", models.VehicleStatusAvailable, updated.Status)
+	}
+}
+
+func TestPatchVehicle_ValidationError(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    int
+	}{
+		{"invalid status", "application/merge-patch+json", `{"status": "stolen"}`, http.StatusUnprocessableEntity},
+		{"read-only field", "application/merge-patch+json", `{"vin": "1FTFW1ET8EFA12345"}`, http.StatusUnprocessableEntity},
+		{"json patch invalid year", "application/json-patch+json", `[{"op": "replace", "path": "/year", "value": 1800}]`, http.StatusUnprocessableEntity},
+		{"unsupported content type", "text/plain", `mileage=1`, http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/vehicles/"+vehicle.ID, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", vehicle.ID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			PatchVehicle(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d. Body: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPatchVehicle_LapsedDocumentsKeepVehicleOut(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	database.DB = db
+
+	// Create test data
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	lapsed := time.Now().AddDate(0, 0, -1)
+	registration := models.VehicleDocument{
+		OrganizationID: org.ID, VehicleID: vehicle.ID,
+		Type: models.VehicleDocumentRegistration, ExpiryDate: &lapsed, IsMandatory: true,
+	}
+	db.Create(&registration)
+	if _, err := jobs.ApplyDocumentEligibility(db, vehicle.ID, time.Now()); err != nil {
+		t.Fatalf("Failed to apply eligibility: %v", err)
+	}
+
+	patch := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/vehicles/"+vehicle.ID, bytes.NewBufferString(`{"is_eligible_for_service": true}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req = withURLParams(req, "id", vehicle.ID)
+		w := httptest.NewRecorder()
+		PatchVehicle(w, req)
+		return w
+	}
+
+	if w := patch(); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d while the registration is lapsed, got %d", http.StatusConflict, w.Code)
+	}
+
+	db.Model(&registration).Update("expiry_date", time.Now().AddDate(1, 0, 0))
+	w := patch()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated models.Vehicle
+	json.NewDecoder(w.Body).Decode(&updated)
+	if !updated.IsEligibleForService || updated.DocumentSuspended {
+		t.Errorf("Expected the vehicle back in service by hand, got eligible %v, document suspended %v", updated.IsEligibleForService, updated.DocumentSuspended)
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fleetpass/internal/database"
+	"fleetpass/internal/jobs"
+	"fleetpass/internal/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func validateWarrantyCoverage(coverage *models.WarrantyCoverage) error {
+	valid := false
+	for _, t := range models.WarrantyCoverageTypes {
+		if coverage.Type == t {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid coverage type: %s", coverage.Type)
+	}
+	if coverage.ExpirationDate == nil && coverage.MileageLimit == nil {
+		return fmt.Errorf("expiration_date or mileage_limit is required")
+	}
+	if coverage.MileageLimit != nil && *coverage.MileageLimit <= 0 {
+		return fmt.Errorf("mileage_limit must be positive")
+	}
+	if coverage.StartDate != nil && coverage.ExpirationDate != nil && coverage.ExpirationDate.Before(*coverage.StartDate) {
+		return fmt.Errorf("expiration_date cannot be before start_date")
+	}
+	return nil
+}
+
+// findWarrantyCoverage loads the vehicle and coverage named in the URL, writing an error if either is missing
+func findWarrantyCoverage(w http.ResponseWriter, r *http.Request) (*models.Vehicle, *models.WarrantyCoverage, bool) {
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	var coverage models.WarrantyCoverage
+	if err := database.DB.First(&coverage, "id = ? AND vehicle_id = ?", chi.URLParam(r, "coverageId"), vehicle.ID).Error; err != nil {
+		http.Error(w, "Warranty coverage not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return &vehicle, &coverage, true
+}
+
+// GetWarrantyCoverages returns a vehicle's warranty coverages, ending soonest first
+func GetWarrantyCoverages(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	var coverages []models.WarrantyCoverage
+	if err := database.DB.Where("vehicle_id = ?", id).Order("expiration_date").Find(&coverages).Error; err != nil {
+		http.Error(w, "Failed to fetch warranty coverages", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(coverages)
+}
+
+func CreateWarrantyCoverage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.CreateWarrantyCoverageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var vehicle models.Vehicle
+	if err := database.DB.First(&vehicle, "id = ?", id).Error; err != nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	coverage := models.WarrantyCoverage{
+		OrganizationID: vehicle.OrganizationID,
+		VehicleID:      vehicle.ID,
+		Type:           req.Type,
+		Provider:       req.Provider,
+		PolicyNumber:   req.PolicyNumber,
+		StartDate:      req.StartDate,
+		ExpirationDate: req.ExpirationDate,
+		MileageLimit:   req.MileageLimit,
+		Details:        req.Details,
+	}
+
+	if err := validateWarrantyCoverage(&coverage); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DB.Create(&coverage).Error; err != nil {
+		http.Error(w, "Failed to create warranty coverage", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(coverage)
+}
+
+func UpdateWarrantyCoverage(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateWarrantyCoverageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_, coverage, ok := findWarrantyCoverage(w, r)
+	if !ok {
+		return
+	}
+
+	// Update fields
+	coverage.Type = req.Type
+	coverage.Provider = req.Provider
+	coverage.PolicyNumber = req.PolicyNumber
+	coverage.StartDate = req.StartDate
+	coverage.ExpirationDate = req.ExpirationDate
+	coverage.MileageLimit = req.MileageLimit
+	coverage.Details = req.Details
+
+	if err := validateWarrantyCoverage(coverage); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DB.Save(coverage).Error; err != nil {
+		http.Error(w, "Failed to update warranty coverage", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(coverage)
+}
+
+func DeleteWarrantyCoverage(w http.ResponseWriter, r *http.Request) {
+	_, coverage, ok := findWarrantyCoverage(w, r)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(coverage).Error; err != nil {
+		http.Error(w, "Failed to delete warranty coverage", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetExpiringWarranties lists warranty coverage still running but ending within days (default 30)
+// or miles (default 1000), filtered by organization_id or location_id
+func GetExpiringWarranties(w http.ResponseWriter, r *http.Request) {
+	days, miles := 30, 1000
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"days", &days}, {"miles", &miles}} {
+		if raw := r.URL.Query().Get(param.name); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 0 {
+				http.Error(w, fmt.Sprintf("%s must be a non-negative whole number", param.name), http.StatusBadRequest)
+				return
+			}
+			*param.value = parsed
+		}
+	}
+
+	query := database.DB.Where("status <> ?", models.VehicleStatusInactive)
+	if organizationID := r.URL.Query().Get("organization_id"); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+	if locationID := r.URL.Query().Get("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+
+	var vehicles []models.Vehicle
+	if err := query.Find(&vehicles).Error; err != nil {
+		http.Error(w, "Failed to fetch vehicles", http.StatusInternalServerError)
+		return
+	}
+
+	expiring, err := jobs.ExpiringWarranties(database.DB, vehicles, time.Now(), days, miles)
+	if err != nil {
+		http.Error(w, "Failed to fetch warranty coverages", http.StatusInternalServerError)
+		return
+	}
+	if expiring == nil {
+		expiring = []models.ExpiringWarranty{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(expiring)
+}
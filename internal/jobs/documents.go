@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"context"
+	"fleetpass/internal/models"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DocumentEligibilityJob returns a job that takes vehicles out of service once a mandatory
+// document lapses
+func DocumentEligibilityJob(db *gorm.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "document-eligibility",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			suspended, err := SuspendLapsedVehicles(db.WithContext(ctx), time.Now())
+			if err != nil {
+				return err
+			}
+			if suspended > 0 {
+				log.Printf("Took %d vehicles out of service for lapsed documents", suspended)
+			}
+			return nil
+		},
+	}
+}
+
+// SuspendLapsedVehicles applies document eligibility to every vehicle with a mandatory document
+// that has lapsed but not yet taken it out of service, and returns how many were suspended
+func SuspendLapsedVehicles(db *gorm.DB, now time.Time) (int, error) {
+	var vehicleIDs []string
+	if err := db.Model(&models.VehicleDocument{}).
+		Where("is_mandatory = ? AND suspended_service = ? AND expiry_date <= ?", true, false, now).
+		Distinct().Pluck("vehicle_id", &vehicleIDs).Error; err != nil {
+		return 0, fmt.Errorf("error loading lapsed documents: %w", err)
+	}
+
+	suspended := 0
+	for _, vehicleID := range vehicleIDs {
+		var changed bool
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			changed, err = ApplyDocumentEligibility(tx, vehicleID, now)
+			return err
+		})
+		if err != nil {
+			return suspended, err
+		}
+		if changed {
+			suspended++
+		}
+	}
+	return suspended, nil
+}
+
+// ApplyDocumentEligibility brings a vehicle's service eligibility in line with its mandatory
+// documents. A lapsed mandatory document makes the vehicle ineligible and is marked as the
+// reason; once a document holding the vehicle out is renewed or no longer mandatory, and
+// nothing else holds it out, the vehicle is made eligible again. A vehicle staff had already
+// taken out of service stays out: its documents are marked but the vehicle is not flagged as
+// suspended by them. It reports whether the vehicle was suspended.
+func ApplyDocumentEligibility(tx *gorm.DB, vehicleID string, now time.Time) (bool, error) {
+	var documents []models.VehicleDocument
+	if err := tx.Where("vehicle_id = ? AND (is_mandatory = ? OR suspended_service = ?)", vehicleID, true, true).
+		Find(&documents).Error; err != nil {
+		return false, fmt.Errorf("error loading vehicle documents: %w", err)
+	}
+
+	var lapsed, cleared []string
+	for i := range documents {
+		document := &documents[i]
+		switch {
+		case document.IsMandatory && document.Lapsed(now):
+			if !document.SuspendedService {
+				lapsed = append(lapsed, document.ID)
+			}
+		case document.SuspendedService:
+			cleared = append(cleared, document.ID)
+		}
+	}
+
+	if len(lapsed) > 0 {
+		if err := tx.Model(&models.VehicleDocument{}).Where("id IN ?", lapsed).
+			Update("suspended_service", true).Error; err != nil {
+			return false, err
+		}
+		result := tx.Model(&models.Vehicle{}).Where("id = ? AND is_eligible_for_service = ?", vehicleID, true).
+			Updates(map[string]interface{}{"is_eligible_for_service": false, "document_suspended": true})
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected > 0, nil
+	}
+
+	if len(cleared) > 0 {
+		if err := tx.Model(&models.VehicleDocument{}).Where("id IN ?", cleared).
+			Update("suspended_service", false).Error; err != nil {
+			return false, err
+		}
+		if err := ReleaseDocumentSuspension(tx, vehicleID); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// ReleaseDocumentSuspension makes a vehicle its documents suspended eligible for service again
+// once none of them is still holding it out
+func ReleaseDocumentSuspension(tx *gorm.DB, vehicleID string) error {
+	var suspending int64
+	if err := tx.Model(&models.VehicleDocument{}).
+		Where("vehicle_id = ? AND suspended_service = ?", vehicleID, true).
+		Count(&suspending).Error; err != nil {
+		return err
+	}
+	if suspending > 0 {
+		return nil
+	}
+	return tx.Model(&models.Vehicle{}).Where("id = ? AND document_suspended = ?", vehicleID, true).
+		Updates(map[string]interface{}{"is_eligible_for_service": true, "document_suspended": false}).Error
+}
+
+// LapsedMandatoryDocuments lists the types of a vehicle's mandatory documents that have lapsed
+func LapsedMandatoryDocuments(db *gorm.DB, vehicleID string, now time.Time) ([]string, error) {
+	var types []string
+	err := db.Model(&models.VehicleDocument{}).
+		Where("vehicle_id = ? AND is_mandatory = ? AND expiry_date <= ?", vehicleID, true, now).
+		Order("type").Pluck("type", &types).Error
+	return types, err
+}
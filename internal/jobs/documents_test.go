@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"testing"
+	"time"
+)
+
+func TestVehicleDocumentStatus(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	inDays := func(days int) *time.Time {
+		date := now.AddDate(0, 0, days)
+		return &date
+	}
+
+	tests := []struct {
+		name     string
+		document models.VehicleDocument
+		want     models.VehicleDocumentStatus
+	}{
+		{"no expiry", models.VehicleDocument{}, models.VehicleDocumentStatusValid},
+		{"expires later", models.VehicleDocument{ExpiryDate: inDays(90)}, models.VehicleDocumentStatusValid},
+		{"expires within window", models.VehicleDocument{ExpiryDate: inDays(10)}, models.VehicleDocumentStatusExpiring},
+		{"expires today", models.VehicleDocument{ExpiryDate: inDays(0)}, models.VehicleDocumentStatusExpired},
+		{"expired", models.VehicleDocument{ExpiryDate: inDays(-5)}, models.VehicleDocumentStatusExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.document.Status(now, 30); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSuspendLapsedVehicles(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	now := time.Now()
+	lapsed := now.AddDate(0, 0, -1)
+	registration := models.VehicleDocument{
+		OrganizationID: org.ID, VehicleID: vehicle.ID,
+		Type: models.VehicleDocumentRegistration, ExpiryDate: &lapsed, IsMandatory: true,
+	}
+	permit := models.VehicleDocument{
+		OrganizationID: org.ID, VehicleID: vehicle.ID,
+		Type: models.VehicleDocumentPermit, ExpiryDate: &lapsed,
+	}
+	db.Create(&registration)
+	db.Create(&permit)
+
+	suspended, err := SuspendLapsedVehicles(db, now)
+	if err != nil {
+		t.Fatalf("Failed to suspend vehicles: %v", err)
+	}
+	if suspended != 1 {
+		t.Errorf("Expected 1 vehicle suspended, got %d", suspended)
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if vehicle.IsEligibleForService {
+		t.Error("Expected the vehicle to be out of service after its registration lapsed")
+	}
+
+	// A second run leaves the already suspended vehicle alone
+	if suspended, _ := SuspendLapsedVehicles(db, now); suspended != 0 {
+		t.Errorf("Expected no further suspensions, got %d", suspended)
+	}
+
+	// Renewing the registration returns the vehicle to service
+	renewed := now.AddDate(1, 0, 0)
+	db.Model(&registration).Update("expiry_date", renewed)
+	if _, err := ApplyDocumentEligibility(db, vehicle.ID, now); err != nil {
+		t.Fatalf("Failed to apply eligibility: %v", err)
+	}
+
+	db.First(vehicle, "id = ?", vehicle.ID)
+	if !vehicle.IsEligibleForService || vehicle.DocumentSuspended {
+		t.Error("Expected the vehicle back in service after renewal")
+	}
+
+	// A vehicle staff took out of service stays out after its documents are renewed
+	parked := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "2HGFC2F59KH542853", "Honda", "Civic", 2021)
+	db.Model(parked).Update("is_eligible_for_service", false)
+	insurance := models.VehicleDocument{
+		OrganizationID: org.ID, VehicleID: parked.ID,
+		Type: models.VehicleDocumentInsurance, ExpiryDate: &lapsed, IsMandatory: true,
+	}
+	db.Create(&insurance)
+	if suspended, _ := SuspendLapsedVehicles(db, now); suspended != 0 {
+		t.Errorf("Expected the parked vehicle not to count as suspended, got %d", suspended)
+	}
+	db.Model(&insurance).Update("expiry_date", renewed)
+	if _, err := ApplyDocumentEligibility(db, parked.ID, now); err != nil {
+		t.Fatalf("Failed to apply eligibility: %v", err)
+	}
+	db.First(parked, "id = ?", parked.ID)
+	if parked.IsEligibleForService {
+		t.Error("Expected the vehicle taken out of service by hand to stay out")
+	}
+}
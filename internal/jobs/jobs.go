@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Job is a unit of background work run on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Config holds background job configuration
+type Config struct {
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	MaintenanceAlertInterval time.Duration
+
+	WarrantyExpiryInterval time.Duration
+	WarrantyExpiryDays     int
+	WarrantyExpiryMiles    int
+
+	DocumentEligibilityInterval time.Duration
+
+	ImportWorkers        int
+	ImportStorageDir     string
+	ImportMaxUploadBytes int64
+}
+
+// LoadConfigFromEnv loads job configuration from environment variables
+func LoadConfigFromEnv() *Config {
+	return &Config{
+		TrashRetention:     time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		TrashPurgeInterval: time.Duration(getEnvInt("TRASH_PURGE_INTERVAL_HOURS", 24)) * time.Hour,
+
+		MaintenanceAlertInterval: time.Duration(getEnvInt("MAINTENANCE_ALERT_INTERVAL_HOURS", 6)) * time.Hour,
+
+		WarrantyExpiryInterval: time.Duration(getEnvInt("WARRANTY_EXPIRY_INTERVAL_HOURS", 24)) * time.Hour,
+		WarrantyExpiryDays:     getEnvInt("WARRANTY_EXPIRY_DAYS", 30),
+		WarrantyExpiryMiles:    getEnvInt("WARRANTY_EXPIRY_MILES", 1000),
+
+		DocumentEligibilityInterval: time.Duration(getEnvInt("DOCUMENT_ELIGIBILITY_INTERVAL_HOURS", 6)) * time.Hour,
+
+		ImportWorkers:        getEnvInt("IMPORT_WORKERS", 2),
+		ImportStorageDir:     getEnv("IMPORT_STORAGE_DIR", filepath.Join(os.TempDir(), "fleetpass-imports")),
+		ImportMaxUploadBytes: int64(getEnvInt("IMPORT_MAX_UPLOAD_MB", 200)) << 20,
+	}
+}
+
+// Start runs each job once immediately and then on its interval until ctx is cancelled
+func Start(ctx context.Context, jobs ...Job) {
+	for _, job := range jobs {
+		if job.Interval <= 0 {
+			log.Printf("Job %s disabled (interval %s)", job.Name, job.Interval)
+			continue
+		}
+		go run(ctx, job)
+	}
+}
+
+func run(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil {
+			log.Printf("Job %s failed: %v", job.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("Warning: invalid value for %s: %q, using default %d", key, value, defaultValue)
+	}
+	return defaultValue
+}
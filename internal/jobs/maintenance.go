@@ -0,0 +1,247 @@
+package jobs
+
+import (
+	"context"
+	"fleetpass/internal/email"
+	"fleetpass/internal/models"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaintenanceAlertJob returns a job that emails each location when its vehicles become due soon
+// or overdue for preventive maintenance
+func MaintenanceAlertJob(db *gorm.DB, mailer email.Service, interval time.Duration) Job {
+	return Job{
+		Name:     "maintenance-alerts",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			sent, err := SendMaintenanceAlerts(db.WithContext(ctx), mailer, time.Now())
+			if err != nil {
+				return err
+			}
+			if sent > 0 {
+				log.Printf("Sent %d maintenance alerts", sent)
+			}
+			return nil
+		},
+	}
+}
+
+// MaintenanceSchedule computes where each vehicle stands against every plan that applies to it
+func MaintenanceSchedule(db *gorm.DB, vehicles []models.Vehicle, now time.Time) ([]models.MaintenanceDue, error) {
+	if len(vehicles) == 0 {
+		return nil, nil
+	}
+
+	organizationIDs := make([]string, 0)
+	vehicleIDs := make([]string, len(vehicles))
+	seen := make(map[string]bool)
+	for i, vehicle := range vehicles {
+		vehicleIDs[i] = vehicle.ID
+		if !seen[vehicle.OrganizationID] {
+			seen[vehicle.OrganizationID] = true
+			organizationIDs = append(organizationIDs, vehicle.OrganizationID)
+		}
+	}
+
+	var plans []models.MaintenancePlan
+	if err := db.Where("organization_id IN ? AND is_active = ?", organizationIDs, true).
+		Order("name").Find(&plans).Error; err != nil {
+		return nil, fmt.Errorf("error loading maintenance plans: %w", err)
+	}
+	if len(plans) == 0 {
+		return nil, nil
+	}
+
+	// The latest closed record of each type per vehicle starts the current interval
+	var records []models.MaintenanceRecord
+	if err := db.Select("DISTINCT ON (vehicle_id, type) *").
+		Where("vehicle_id IN ? AND closed_at IS NOT NULL", vehicleIDs).
+		Order("vehicle_id, type, closed_at DESC").
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("error loading maintenance records: %w", err)
+	}
+	lastService := make(map[string]*models.MaintenanceRecord, len(records))
+	for i := range records {
+		lastService[records[i].VehicleID+"|"+string(records[i].Type)] = &records[i]
+	}
+
+	// Vehicles never serviced under a plan count from their odometer when the plan started
+	var readings []models.OdometerReading
+	if err := db.Select("vehicle_id, reading, read_at").
+		Where("vehicle_id IN ? AND flagged = ?", vehicleIDs, false).
+		Order("vehicle_id, read_at").
+		Find(&readings).Error; err != nil {
+		return nil, fmt.Errorf("error loading odometer readings: %w", err)
+	}
+	odometer := make(map[string][]models.OdometerReading, len(vehicles))
+	for _, reading := range readings {
+		odometer[reading.VehicleID] = append(odometer[reading.VehicleID], reading)
+	}
+
+	var schedule []models.MaintenanceDue
+	for i := range vehicles {
+		vehicle := &vehicles[i]
+		for _, plan := range PlansForVehicle(plans, vehicle) {
+			startMileage := MileageAt(odometer[vehicle.ID], plan.StartedFor(vehicle), vehicle.Mileage)
+			schedule = append(schedule, plan.Due(vehicle, lastService[vehicle.ID+"|"+string(plan.Type)], startMileage, now))
+		}
+	}
+	return schedule, nil
+}
+
+// MileageAt returns the odometer at a time from readings in time order: the last reading
+// taken by then, or the first one taken after it. Without readings the odometer has not
+// moved from current.
+func MileageAt(readings []models.OdometerReading, at time.Time, current int) int {
+	if len(readings) == 0 {
+		return current
+	}
+	mileage := readings[0].Reading
+	for _, reading := range readings {
+		if reading.ReadAt.After(at) {
+			break
+		}
+		mileage = reading.Reading
+	}
+	return mileage
+}
+
+// PlansForVehicle returns the plans that apply to the vehicle. A plan attached to the vehicle
+// replaces make/model plans of the same type.
+func PlansForVehicle(plans []models.MaintenancePlan, vehicle *models.Vehicle) []*models.MaintenancePlan {
+	overridden := make(map[models.MaintenanceType]bool)
+	for i := range plans {
+		if plans[i].VehicleID != nil && plans[i].AppliesTo(vehicle) {
+			overridden[plans[i].Type] = true
+		}
+	}
+
+	var applicable []*models.MaintenancePlan
+	for i := range plans {
+		plan := &plans[i]
+		if !plan.AppliesTo(vehicle) || (plan.VehicleID == nil && overridden[plan.Type]) {
+			continue
+		}
+		applicable = append(applicable, plan)
+	}
+	return applicable
+}
+
+// SendMaintenanceAlerts emails every location whose vehicles crossed a due soon or overdue
+// threshold since the last run. Each threshold is alerted once per service interval.
+func SendMaintenanceAlerts(db *gorm.DB, mailer email.Service, now time.Time) (int, error) {
+	var locations []models.Location
+	if err := db.Where("is_active = ?", true).Find(&locations).Error; err != nil {
+		return 0, fmt.Errorf("error loading locations: %w", err)
+	}
+
+	sent := 0
+	for _, location := range locations {
+		count, err := sendLocationMaintenanceAlerts(db, mailer, &location, now)
+		if err != nil {
+			return sent, fmt.Errorf("location %s: %w", location.ID, err)
+		}
+		sent += count
+	}
+	return sent, nil
+}
+
+func sendLocationMaintenanceAlerts(db *gorm.DB, mailer email.Service, location *models.Location, now time.Time) (int, error) {
+	var vehicles []models.Vehicle
+	if err := db.Where("location_id = ? AND status <> ?", location.ID, models.VehicleStatusInactive).
+		Find(&vehicles).Error; err != nil {
+		return 0, fmt.Errorf("error loading vehicles: %w", err)
+	}
+
+	schedule, err := MaintenanceSchedule(db, vehicles, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var due []models.MaintenanceDue
+	for _, item := range schedule {
+		if item.Status != models.MaintenanceDueStatusOK {
+			due = append(due, item)
+		}
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	var previous []models.MaintenanceAlert
+	if err := db.Where("vehicle_id IN ?", vehicleIDs(due)).Find(&previous).Error; err != nil {
+		return 0, fmt.Errorf("error loading maintenance alerts: %w", err)
+	}
+	alerted := make(map[string]bool, len(previous))
+	for _, alert := range previous {
+		alerted[alertKey(alert.PlanID, alert.VehicleID, alert.LastServiceID, alert.Status)] = true
+	}
+
+	var alerts []models.MaintenanceAlert
+	var items []string
+	for _, item := range due {
+		if alerted[alertKey(item.PlanID, item.VehicleID, item.LastServiceID, item.Status)] {
+			continue
+		}
+		alerts = append(alerts, models.MaintenanceAlert{
+			PlanID:        item.PlanID,
+			VehicleID:     item.VehicleID,
+			LastServiceID: item.LastServiceID,
+			Status:        item.Status,
+			SentAt:        now,
+		})
+		items = append(items, describeMaintenanceDue(item))
+	}
+	if len(alerts) == 0 {
+		return 0, nil
+	}
+
+	if location.Email == "" {
+		log.Printf("Location %s has %d maintenance alerts but no email address", location.Name, len(alerts))
+	} else if err := mailer.SendMaintenanceDueEmail(location.Email, location.Name, items); err != nil {
+		return 0, fmt.Errorf("error sending maintenance alert: %w", err)
+	}
+
+	// Alerts are recorded even without an address so they are not retried on every run
+	if err := db.Create(&alerts).Error; err != nil {
+		return 0, fmt.Errorf("error saving maintenance alerts: %w", err)
+	}
+	return len(alerts), nil
+}
+
+func vehicleIDs(due []models.MaintenanceDue) []string {
+	ids := make([]string, len(due))
+	for i, item := range due {
+		ids[i] = item.VehicleID
+	}
+	return ids
+}
+
+func alertKey(planID, vehicleID string, lastServiceID *string, status models.MaintenanceDueStatus) string {
+	service := ""
+	if lastServiceID != nil {
+		service = *lastServiceID
+	}
+	return planID + "|" + vehicleID + "|" + service + "|" + string(status)
+}
+
+// describeMaintenanceDue formats a line of the alert email
+func describeMaintenanceDue(item models.MaintenanceDue) string {
+	status := "due soon"
+	if item.Status == models.MaintenanceDueStatusOverdue {
+		status = "OVERDUE"
+	}
+
+	line := fmt.Sprintf("%d %s %s (VIN %s): %s %s", item.Year, item.Make, item.Model, item.VIN, item.PlanName, status)
+	if item.NextDueMileage != nil {
+		line += fmt.Sprintf(", due at %d mi (now %d mi)", *item.NextDueMileage, item.Mileage)
+	}
+	if item.NextDueAt != nil {
+		line += fmt.Sprintf(", due by %s", item.NextDueAt.Format("2006-01-02"))
+	}
+	return line
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"testing"
+	"time"
+)
+
+func TestMaintenancePlanDue(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	serviced := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	last := &models.MaintenanceRecord{ID: "record-1", OdometerAtService: 20000, ClosedAt: &serviced}
+	plan := &models.MaintenancePlan{
+		Name: "Oil change", Type: models.MaintenanceTypeOilChange,
+		IntervalMiles: 5000, IntervalMonths: 6, DueSoonMiles: 500, DueSoonDays: 14,
+	}
+
+	tests := []struct {
+		name    string
+		mileage int
+		now     time.Time
+		want    models.MaintenanceDueStatus
+	}{
+		{"within both intervals", 22000, now, models.MaintenanceDueStatusOK},
+		{"close to mileage", 24600, now, models.MaintenanceDueStatusDueSoon},
+		{"past mileage", 25000, now, models.MaintenanceDueStatusOverdue},
+		{"close to date", 21000, serviced.AddDate(0, 6, -7), models.MaintenanceDueStatusDueSoon},
+		{"past date", 21000, serviced.AddDate(0, 6, 1), models.MaintenanceDueStatusOverdue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vehicle := &models.Vehicle{ID: "vehicle-1", Mileage: tt.mileage}
+			due := plan.Due(vehicle, last, 0, tt.now)
+			if due.Status != tt.want {
+				t.Errorf("Expected status %s, got %s", tt.want, due.Status)
+			}
+			if due.NextDueMileage == nil || *due.NextDueMileage != 25000 {
+				t.Errorf("Expected next due at 25000 mi, got %v", due.NextDueMileage)
+			}
+			if due.NextDueAt == nil || !due.NextDueAt.Equal(serviced.AddDate(0, 6, 0)) {
+				t.Errorf("Expected next due on %s, got %v", serviced.AddDate(0, 6, 0), due.NextDueAt)
+			}
+		})
+	}
+
+	// Without a service record the interval counts from the odometer when the plan started
+	readings := []models.OdometerReading{
+		{Reading: 40000, ReadAt: serviced.AddDate(0, -1, 0)},
+		{Reading: 41000, ReadAt: serviced.AddDate(0, 1, 0)},
+	}
+	vehicle := &models.Vehicle{Mileage: 44000, CreatedAt: serviced.AddDate(-1, 0, 0)}
+	started := *plan
+	started.CreatedAt = serviced
+	startMileage := MileageAt(readings, started.StartedFor(vehicle), vehicle.Mileage)
+	if startMileage != 40000 {
+		t.Errorf("Expected the plan to start at 40000 mi, got %d", startMileage)
+	}
+	due := started.Due(vehicle, nil, startMileage, serviced.AddDate(0, 2, 0))
+	if due.Status != models.MaintenanceDueStatusOK || due.NextDueMileage == nil || *due.NextDueMileage != 45000 || due.LastServiceID != nil {
+		t.Errorf("Expected a used vehicle to be due at 45000 mi, got %s at %v", due.Status, due.NextDueMileage)
+	}
+	if due := started.Due(vehicle, nil, startMileage, serviced.AddDate(0, 6, 0)); due.Status != models.MaintenanceDueStatusOverdue {
+		t.Errorf("Expected the vehicle to be overdue 6 months after the plan started, got %s", due.Status)
+	}
+
+	// Before its first reading a vehicle counts from that reading, and without any from its mileage
+	if got := MileageAt(readings, serviced.AddDate(-1, 0, 0), 44000); got != 40000 {
+		t.Errorf("Expected the first reading, got %d", got)
+	}
+	if got := MileageAt(nil, serviced, 44000); got != 44000 {
+		t.Errorf("Expected the current mileage without readings, got %d", got)
+	}
+}
+
+func TestPlansForVehicle(t *testing.T) {
+	vehicleID := "vehicle-1"
+	otherVehicleID := "vehicle-2"
+	year2020 := 2020
+	plans := []models.MaintenancePlan{
+		{ID: "all-oil", OrganizationID: "org", Type: models.MaintenanceTypeOilChange, IntervalMiles: 5000},
+		{ID: "honda-tires", OrganizationID: "org", Type: models.MaintenanceTypeTireRotation, Make: "honda", YearMin: &year2020, IntervalMiles: 7500},
+		{ID: "toyota-tires", OrganizationID: "org", Type: models.MaintenanceTypeTireRotation, Make: "Toyota", IntervalMiles: 7500},
+		{ID: "vehicle-oil", OrganizationID: "org", Type: models.MaintenanceTypeOilChange, VehicleID: &vehicleID, IntervalMiles: 3000},
+		{ID: "other-vehicle", OrganizationID: "org", Type: models.MaintenanceTypeBrakes, VehicleID: &otherVehicleID, IntervalMonths: 12},
+		{ID: "other-org", OrganizationID: "other", Type: models.MaintenanceTypeInspection, IntervalMonths: 12},
+	}
+
+	vehicle := &models.Vehicle{ID: vehicleID, OrganizationID: "org", Make: "Honda", Model: "Accord", Year: 2022}
+	var got []string
+	for _, plan := range PlansForVehicle(plans, vehicle) {
+		got = append(got, plan.ID)
+	}
+
+	want := []string{"honda-tires", "vehicle-oil"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Expected plans %v, got %v", want, got)
+	}
+}
+
+// recordingMailer captures maintenance alerts instead of sending them
+type recordingMailer struct {
+	alerts map[string][]string
+}
+
+func (m *recordingMailer) SendVerificationEmail(to, token string) error  { return nil }
+func (m *recordingMailer) SendPasswordResetEmail(to, token string) error { return nil }
+func (m *recordingMailer) SendWelcomeEmail(to, firstName string) error   { return nil }
+func (m *recordingMailer) SendMaintenanceDueEmail(to, locationName string, items []string) error {
+	m.alerts[to] = append(m.alerts[to], items...)
+	return nil
+}
+
+func (m *recordingMailer) SendWarrantyExpiryEmail(to, locationName string, items []string) error {
+	m.alerts[to] = append(m.alerts[to], items...)
+	return nil
+}
+
+func (m *recordingMailer) SendInvoiceEmail(to, organizationName, invoiceNumber string, pdf []byte) error {
+	return nil
+}
+
+func TestSendMaintenanceAlerts_OncePerThreshold(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	db.Model(loc).Update("email", "service@example.com")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+	db.Model(vehicle).Update("mileage", 4700)
+
+	plan := models.MaintenancePlan{
+		OrganizationID: org.ID, Name: "Oil change", Type: models.MaintenanceTypeOilChange,
+		IntervalMiles: 5000, DueSoonMiles: 500, DueSoonDays: 14, IsActive: true,
+	}
+	if err := db.Create(&plan).Error; err != nil {
+		t.Fatalf("Failed to create plan: %v", err)
+	}
+
+	mailer := &recordingMailer{alerts: map[string][]string{}}
+	now := time.Now()
+
+	// Due soon is alerted once, however often the job runs
+	for i := 0; i < 2; i++ {
+		if _, err := SendMaintenanceAlerts(db, mailer, now); err != nil {
+			t.Fatalf("SendMaintenanceAlerts failed: %v", err)
+		}
+	}
+	if got := len(mailer.alerts["service@example.com"]); got != 1 {
+		t.Fatalf("Expected 1 alert, got %d", got)
+	}
+
+	// Crossing into overdue alerts again
+	db.Model(vehicle).Update("mileage", 5100)
+	if sent, _ := SendMaintenanceAlerts(db, mailer, now); sent != 1 {
+		t.Errorf("Expected an overdue alert, got %d", sent)
+	}
+
+	// Servicing the vehicle starts a new interval
+	closed := now
+	db.Create(&models.MaintenanceRecord{
+		OrganizationID: org.ID, VehicleID: vehicle.ID, Type: models.MaintenanceTypeOilChange,
+		OdometerAtService: 5100, OpenedAt: now, ClosedAt: &closed, Attachments: models.StringArray{},
+	})
+	if sent, _ := SendMaintenanceAlerts(db, mailer, now); sent != 0 {
+		t.Errorf("Expected no alerts after service, got %d", sent)
+	}
+}
@@ -0,0 +1,205 @@
+package jobs
+
+import (
+	"context"
+	"fleetpass/internal/models"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashPurgeJob returns a job that hard-deletes records that have been in the trash longer than retention
+func TrashPurgeJob(db *gorm.DB, retention, interval time.Duration) Job {
+	return Job{
+		Name:     "trash-purge",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			purged, err := PurgeTrash(db.WithContext(ctx), time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			if purged > 0 {
+				log.Printf("Purged %d records deleted before the %s retention window", purged, retention)
+			}
+			return nil
+		},
+	}
+}
+
+// PurgeTrash permanently removes vehicles, locations and organizations soft-deleted before cutoff,
+// together with the rows that belong to them, in one transaction so nothing is left pointing at
+// a purged record. An organization takes everything of its own with it. A vehicle takes its
+// history with it; its rentals, which carry payments and invoices, are kept without it. A
+// location takes its parking spaces, holidays and import jobs with it, but one that rentals,
+// inspections or transfers still point at stays in the trash until its organization is purged.
+func PurgeTrash(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		trashed := func(model interface{}) ([]string, error) {
+			ids := []string{}
+			err := tx.Unscoped().Model(model).Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Pluck("id", &ids).Error
+			return ids, err
+		}
+
+		organizations, err := trashed(&models.Organization{})
+		if err != nil {
+			return err
+		}
+		if len(organizations) > 0 {
+			count, err := purgeOrganizations(tx, organizations)
+			if err != nil {
+				return err
+			}
+			purged += count
+		}
+
+		vehicles, err := trashed(&models.Vehicle{})
+		if err != nil {
+			return err
+		}
+		if len(vehicles) > 0 {
+			count, err := purgeVehicles(tx, vehicles)
+			if err != nil {
+				return err
+			}
+			purged += count
+		}
+
+		locations, err := trashed(&models.Location{})
+		if err != nil {
+			return err
+		}
+		if len(locations) > 0 {
+			count, err := purgeLocations(tx, locations)
+			if err != nil {
+				return err
+			}
+			purged += count
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// purgeRows hard-deletes the model's rows matching the query
+func purgeRows(tx *gorm.DB, model interface{}, query string, args ...interface{}) (int64, error) {
+	result := tx.Unscoped().Where(query, args...).Delete(model)
+	if result.Error != nil {
+		return 0, fmt.Errorf("error purging %T: %w", model, result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// purgeOrganizations deletes the organizations and every row of theirs, returning how many
+// organizations, locations and vehicles went. Their users stay, without an organization.
+func purgeOrganizations(tx *gorm.DB, ids []string) (int64, error) {
+	children := []struct {
+		model interface{}
+		query string
+	}{
+		{&models.RentalDriver{}, "rental_id IN (SELECT id FROM rentals WHERE organization_id IN ?)"},
+		{&models.RentalCharge{}, "rental_id IN (SELECT id FROM rentals WHERE organization_id IN ?)"},
+		{&models.PaymentEntry{}, "rental_id IN (SELECT id FROM rentals WHERE organization_id IN ?)"},
+		{&models.MaintenanceAlert{}, "plan_id IN (SELECT id FROM maintenance_plans WHERE organization_id IN ?)"},
+		{&models.LocationHoliday{}, "location_id IN (SELECT id FROM locations WHERE organization_id IN ?)"},
+		{&models.ParkingSpace{}, "location_id IN (SELECT id FROM locations WHERE organization_id IN ?)"},
+	}
+	for _, child := range children {
+		if _, err := purgeRows(tx, child.model, child.query, ids); err != nil {
+			return 0, err
+		}
+	}
+	for _, model := range []interface{}{
+		&models.Invoice{}, &models.Payment{}, &models.Rental{}, &models.Inspection{}, &models.MaintenanceRecord{},
+		&models.MaintenancePlan{}, &models.OdometerReading{}, &models.VehicleDocument{}, &models.WarrantyCoverage{},
+		&models.VehicleTransfer{}, &models.ImportJob{}, &models.ImportProfile{}, &models.PricingRule{},
+		&models.VehicleClass{}, &models.ExchangeRate{},
+	} {
+		if _, err := purgeRows(tx, model, "organization_id IN ?", ids); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Model(&models.User{}).Where("organization_id IN ?", ids).Update("organization_id", nil).Error; err != nil {
+		return 0, fmt.Errorf("error detaching users: %w", err)
+	}
+
+	var purged int64
+	for _, model := range []interface{}{&models.Vehicle{}, &models.Location{}, &models.Organization{}} {
+		query := "organization_id IN ?"
+		if _, ok := model.(*models.Organization); ok {
+			query = "id IN ?"
+		}
+		count, err := purgeRows(tx, model, query, ids)
+		if err != nil {
+			return 0, err
+		}
+		purged += count
+	}
+	return purged, nil
+}
+
+// purgeVehicles deletes the vehicles with their maintenance, odometer, inspection, document,
+// warranty and transfer history. Their rentals are kept without a vehicle, charges keep no
+// link to a purged inspection and parking spaces are freed.
+func purgeVehicles(tx *gorm.DB, ids []string) (int64, error) {
+	detach := []struct {
+		model   interface{}
+		query   string
+		updates map[string]interface{}
+	}{
+		{&models.Rental{}, "vehicle_id IN ?", map[string]interface{}{"vehicle_id": nil}},
+		{&models.ParkingSpace{}, "vehicle_id IN ?", map[string]interface{}{"vehicle_id": nil, "assigned_at": nil}},
+		{&models.RentalCharge{}, "inspection_id IN (SELECT id FROM inspections WHERE vehicle_id IN ?)", map[string]interface{}{"inspection_id": nil}},
+	}
+	for _, d := range detach {
+		if err := tx.Model(d.model).Where(d.query, ids).Updates(d.updates).Error; err != nil {
+			return 0, fmt.Errorf("error detaching %T: %w", d.model, err)
+		}
+	}
+	for _, model := range []interface{}{
+		&models.MaintenanceAlert{}, &models.MaintenancePlan{}, &models.MaintenanceRecord{}, &models.OdometerReading{},
+		&models.Inspection{}, &models.VehicleDocument{}, &models.WarrantyCoverage{}, &models.VehicleTransfer{},
+	} {
+		if _, err := purgeRows(tx, model, "vehicle_id IN ?", ids); err != nil {
+			return 0, err
+		}
+	}
+	return purgeRows(tx, &models.Vehicle{}, "id IN ?", ids)
+}
+
+// purgeLocations deletes the locations nothing else still points at, with their parking
+// spaces, holidays and import jobs
+func purgeLocations(tx *gorm.DB, ids []string) (int64, error) {
+	referenced := []string{}
+	err := tx.Raw(`SELECT pickup_location_id FROM rentals WHERE pickup_location_id IN @ids
+		UNION SELECT return_location_id FROM rentals WHERE return_location_id IN @ids
+		UNION SELECT location_id FROM inspections WHERE location_id IN @ids
+		UNION SELECT from_location_id FROM vehicle_transfers WHERE from_location_id IN @ids
+		UNION SELECT to_location_id FROM vehicle_transfers WHERE to_location_id IN @ids`,
+		map[string]interface{}{"ids": ids}).Scan(&referenced).Error
+	if err != nil {
+		return 0, fmt.Errorf("error finding referenced locations: %w", err)
+	}
+	skip := make(map[string]bool, len(referenced))
+	for _, id := range referenced {
+		skip[id] = true
+	}
+	purgeable := []string{}
+	for _, id := range ids {
+		if !skip[id] {
+			purgeable = append(purgeable, id)
+		}
+	}
+	if len(purgeable) == 0 {
+		return 0, nil
+	}
+
+	for _, model := range []interface{}{&models.ParkingSpace{}, &models.LocationHoliday{}, &models.ImportJob{}} {
+		if _, err := purgeRows(tx, model, "location_id IN ?", purgeable); err != nil {
+			return 0, err
+		}
+	}
+	return purgeRows(tx, &models.Location{}, "id IN ?", purgeable)
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"fleetpass/internal/models"
+	"fleetpass/internal/testutil"
+	"testing"
+	"time"
+)
+
+func TestPurgeTrash_RemovesDependents(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, db)
+
+	org := testutil.CreateTestOrganization(t, db, "Test Org", "test-org")
+	loc := testutil.CreateTestLocation(t, db, org.ID, "Test Location", "San Francisco")
+	closed := testutil.CreateTestLocation(t, db, org.ID, "Closed Location", "Oakland")
+	vehicle := testutil.CreateTestVehicle(t, db, org.ID, loc.ID, "1HGBH41JXMN109186", "Honda", "Accord", 2022)
+
+	now := time.Now()
+	db.Create(&models.OdometerReading{OrganizationID: org.ID, VehicleID: vehicle.ID, Reading: 12000, Source: models.OdometerSourceManual, ReadAt: now})
+	db.Create(&models.MaintenanceRecord{OrganizationID: org.ID, VehicleID: vehicle.ID, Type: models.MaintenanceTypeOilChange, OpenedAt: now, ClosedAt: &now})
+	space := models.ParkingSpace{LocationID: loc.ID, Name: "A1", VehicleID: &vehicle.ID}
+	db.Create(&space)
+	rental := models.Rental{
+		OrganizationID: org.ID, VehicleID: &vehicle.ID, PickupLocationID: loc.ID, ReturnLocationID: loc.ID,
+		Status: models.RentalStatusCompleted, PickupAt: now.AddDate(0, 0, -5), ReturnAt: now.AddDate(0, 0, -3),
+	}
+	db.Create(&rental)
+
+	longAgo := now.AddDate(0, 0, -60)
+	db.Model(vehicle).Update("deleted_at", longAgo)
+	db.Model(closed).Update("deleted_at", longAgo)
+
+	purged, err := PurgeTrash(db, now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("Failed to purge trash: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("Expected the vehicle and the closed location to be purged, got %d", purged)
+	}
+
+	var readings, records int64
+	db.Model(&models.OdometerReading{}).Where("vehicle_id = ?", vehicle.ID).Count(&readings)
+	db.Model(&models.MaintenanceRecord{}).Where("vehicle_id = ?", vehicle.ID).Count(&records)
+	if readings != 0 || records != 0 {
+		t.Errorf("Expected the vehicle's history to go with it, got %d readings and %d records", readings, records)
+	}
+
+	// The rental and its money stay, without the vehicle, and the space is free
+	db.First(&rental, "id = ?", rental.ID)
+	if rental.VehicleID != nil {
+		t.Errorf("Expected the rental to be kept without its vehicle, got %v", *rental.VehicleID)
+	}
+	db.First(&space, "id = ?", space.ID)
+	if space.VehicleID != nil {
+		t.Errorf("Expected the parking space to be freed, got %v", *space.VehicleID)
+	}
+}
@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"context"
+	"fleetpass/internal/email"
+	"fleetpass/internal/models"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WarrantyExpiryJob returns a job that emails each location a digest of the warranty coverage
+// ending within days or miles
+func WarrantyExpiryJob(db *gorm.DB, mailer email.Service, interval time.Duration, days, miles int) Job {
+	return Job{
+		Name:     "warranty-expiry",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			sent, err := SendWarrantyExpiryDigests(db.WithContext(ctx), mailer, time.Now(), days, miles)
+			if err != nil {
+				return err
+			}
+			if sent > 0 {
+				log.Printf("Sent warranty expiry digests to %d locations", sent)
+			}
+			return nil
+		},
+	}
+}
+
+// ExpiringWarranties lists the coverage on the given vehicles that is still running but ends
+// within days or miles, soonest first by date. A vehicle without coverage records is judged by
+// its own warranty expiration date.
+func ExpiringWarranties(db *gorm.DB, vehicles []models.Vehicle, now time.Time, days, miles int) ([]models.ExpiringWarranty, error) {
+	if len(vehicles) == 0 {
+		return nil, nil
+	}
+
+	vehicleIDs := make([]string, len(vehicles))
+	for i, vehicle := range vehicles {
+		vehicleIDs[i] = vehicle.ID
+	}
+
+	var coverages []models.WarrantyCoverage
+	if err := db.Where("vehicle_id IN ?", vehicleIDs).Order("expiration_date").Find(&coverages).Error; err != nil {
+		return nil, fmt.Errorf("error loading warranty coverages: %w", err)
+	}
+	byVehicle := make(map[string][]models.WarrantyCoverage)
+	for _, coverage := range coverages {
+		byVehicle[coverage.VehicleID] = append(byVehicle[coverage.VehicleID], coverage)
+	}
+
+	var expiring []models.ExpiringWarranty
+	for i := range vehicles {
+		vehicle := &vehicles[i]
+		vehicleCoverages := byVehicle[vehicle.ID]
+		if len(vehicleCoverages) == 0 && vehicle.HasWarranty && vehicle.WarrantyExpirationDate != nil {
+			coverageType := models.WarrantyCoverageType(vehicle.WarrantyType)
+			if coverageType == "" {
+				coverageType = models.WarrantyCoverageOther
+			}
+			vehicleCoverages = []models.WarrantyCoverage{{Type: coverageType, ExpirationDate: vehicle.WarrantyExpirationDate}}
+		}
+
+		for j := range vehicleCoverages {
+			if item, ok := vehicleCoverages[j].Expiring(vehicle, now, days, miles); ok {
+				expiring = append(expiring, item)
+			}
+		}
+	}
+	return expiring, nil
+}
+
+// SendWarrantyExpiryDigests emails every location with expiring coverage the list of it and
+// returns how many locations were emailed
+func SendWarrantyExpiryDigests(db *gorm.DB, mailer email.Service, now time.Time, days, miles int) (int, error) {
+	var locations []models.Location
+	if err := db.Where("is_active = ?", true).Find(&locations).Error; err != nil {
+		return 0, fmt.Errorf("error loading locations: %w", err)
+	}
+
+	sent := 0
+	for _, location := range locations {
+		var vehicles []models.Vehicle
+		if err := db.Where("location_id = ? AND status <> ?", location.ID, models.VehicleStatusInactive).
+			Find(&vehicles).Error; err != nil {
+			return sent, fmt.Errorf("error loading vehicles: %w", err)
+		}
+
+		expiring, err := ExpiringWarranties(db, vehicles, now, days, miles)
+		if err != nil {
+			return sent, err
+		}
+		if len(expiring) == 0 {
+			continue
+		}
+		if location.Email == "" {
+			log.Printf("Location %s has %d expiring warranties but no email address", location.Name, len(expiring))
+			continue
+		}
+
+		items := make([]string, len(expiring))
+		for i, item := range expiring {
+			items[i] = describeExpiringWarranty(item)
+		}
+		if err := mailer.SendWarrantyExpiryEmail(location.Email, location.Name, items); err != nil {
+			return sent, fmt.Errorf("error sending warranty digest: %w", err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// describeExpiringWarranty formats a line of the digest email
+func describeExpiringWarranty(item models.ExpiringWarranty) string {
+	line := fmt.Sprintf("%d %s %s (VIN %s): %s coverage", item.Year, item.Make, item.Model, item.VIN, item.Type)
+	if item.ExpirationDate != nil {
+		line += fmt.Sprintf(", ends %s", item.ExpirationDate.Format("2006-01-02"))
+	}
+	if item.MileageLimit != nil {
+		line += fmt.Sprintf(", ends at %d mi (now %d mi)", *item.MileageLimit, item.Mileage)
+	}
+	return line
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"fleetpass/internal/models"
+	"testing"
+	"time"
+)
+
+func TestWarrantyCoverageExpiring(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	inDays := func(days int) *time.Time {
+		date := now.AddDate(0, 0, days)
+		return &date
+	}
+	limit := func(miles int) *int { return &miles }
+	vehicle := &models.Vehicle{ID: "vehicle-1", Mileage: 35500}
+
+	tests := []struct {
+		name     string
+		coverage models.WarrantyCoverage
+		want     bool
+	}{
+		{"ends within days", models.WarrantyCoverage{ExpirationDate: inDays(20)}, true},
+		{"ends later", models.WarrantyCoverage{ExpirationDate: inDays(90)}, false},
+		{"already ended", models.WarrantyCoverage{ExpirationDate: inDays(-1)}, false},
+		{"close to mileage limit", models.WarrantyCoverage{ExpirationDate: inDays(365), MileageLimit: limit(36000)}, true},
+		{"past mileage limit", models.WarrantyCoverage{ExpirationDate: inDays(20), MileageLimit: limit(35000)}, false},
+		{"far from mileage limit", models.WarrantyCoverage{MileageLimit: limit(60000)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, expiring := tt.coverage.Expiring(vehicle, now, 30, 1000)
+			if expiring != tt.want {
+				t.Errorf("Expected expiring=%v, got %v (%+v)", tt.want, expiring, item)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func assertJSONEqual(t *testing.T, expected string, actual []byte) {
+	t.Helper()
+
+	var want, got interface{}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		t.Fatalf("Invalid expected JSON: %v", err)
+	}
+	if err := json.Unmarshal(actual, &got); err != nil {
+		t.Fatalf("Invalid result JSON: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected %s, got %s", expected, string(actual))
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"replace value", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add value", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove value", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"replace array", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"nested object", `{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":"g"}}`, `{"a":{"b":"c","f":"g"}}`},
+		{"leave others untouched", `{"daily_rate":49.99,"status":"available"}`, `{"mileage":100}`, `{"daily_rate":49.99,"status":"available","mileage":100}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			assertJSONEqual(t, tt.expected, result)
+		})
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"add field", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add to array", `{"a":["x","z"]}`, `[{"op":"add","path":"/a/1","value":"y"}]`, `{"a":["x","y","z"]}`},
+		{"append to array", `{"a":["x"]}`, `[{"op":"add","path":"/a/-","value":"y"}]`, `{"a":["x","y"]}`},
+		{"remove field", `{"a":1,"b":2}`, `[{"op":"remove","path":"/b"}]`, `{"a":1}`},
+		{"remove array element", `{"a":["x","y"]}`, `[{"op":"remove","path":"/a/0"}]`, `{"a":["y"]}`},
+		{"replace field", `{"a":1}`, `[{"op":"replace","path":"/a","value":"b"}]`, `{"a":"b"}`},
+		{"move field", `{"a":1}`, `[{"op":"move","from":"/a","path":"/b"}]`, `{"b":1}`},
+		{"copy field", `{"a":[1]}`, `[{"op":"copy","from":"/a","path":"/b"}]`, `{"a":[1],"b":[1]}`},
+		{"test then replace", `{"a":1}`, `[{"op":"test","path":"/a","value":1},{"op":"replace","path":"/a","value":2}]`, `{"a":2}`},
+		{"escaped pointer", `{"a/b":1}`, `[{"op":"replace","path":"/a~1b","value":2}]`, `{"a/b":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := DecodeOperations([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Failed to decode operations: %v", err)
+			}
+			result, err := ApplyPatch([]byte(tt.doc), ops)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			assertJSONEqual(t, tt.expected, result)
+		})
+	}
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+	}{
+		{"failed test", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`},
+		{"replace missing", `{"a":1}`, `[{"op":"replace","path":"/b","value":2}]`},
+		{"remove missing", `{"a":1}`, `[{"op":"remove","path":"/b"}]`},
+		{"index out of range", `{"a":[1]}`, `[{"op":"add","path":"/a/5","value":2}]`},
+		{"unknown op", `{"a":1}`, `[{"op":"frobnicate","path":"/a"}]`},
+		{"invalid pointer", `{"a":1}`, `[{"op":"remove","path":"a"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := DecodeOperations([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Failed to decode operations: %v", err)
+			}
+			if _, err := ApplyPatch([]byte(tt.doc), ops); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
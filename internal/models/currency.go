@@ -0,0 +1,137 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultCurrency is the currency of organizations created without one
+const DefaultCurrency = "USD"
+
+// currencyDigits lists the ISO 4217 currencies whose minor unit is not a hundredth
+var currencyDigits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyDigits is the number of decimal places in a currency's minor unit: 2 for cents,
+// 0 for currencies such as JPY that have none
+func CurrencyDigits(currency string) int {
+	if digits, ok := currencyDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// Money is an amount in the minor units of its currency, such as cents, so that sums of
+// money stay exact. Models store it as a pair of columns named by their embedded prefix.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"not null;default:0"`
+	Currency string `json:"currency" gorm:"type:varchar(3)"`
+}
+
+// NewMoney rounds a decimal amount to the currency's minor unit
+func NewMoney(amount float64, currency string) Money {
+	scale := math.Pow10(CurrencyDigits(currency))
+	return Money{Amount: int64(math.Round(amount * scale)), Currency: currency}
+}
+
+// RoundMoney rounds a decimal amount to the currency's minor unit. Decimal money columns keep
+// three decimal places so that every currency's minor unit fits.
+func RoundMoney(amount float64, currency string) float64 {
+	return NewMoney(amount, currency).Float()
+}
+
+// Float is the amount in major units, such as dollars
+func (m Money) Float() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyDigits(m.Currency))
+}
+
+// Add sums two amounts of the same currency
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+// Sub takes an amount of the same currency away
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// Convert changes the money into another currency at rate units of it per unit of m's
+// currency, rounded to the new currency's minor unit
+func (m Money) Convert(currency string, rate float64) Money {
+	if currency == m.Currency {
+		return m
+	}
+	return NewMoney(m.Float()*rate, currency)
+}
+
+// ExchangeRate is how many units of QuoteCurrency one unit of BaseCurrency buys, from
+// EffectiveOn until a later rate for the pair takes over. A rate also converts the other way,
+// at its inverse, when the reverse pair has no rate of its own.
+type ExchangeRate struct {
+	ID             string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string    `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_exchange_rate"`
+	BaseCurrency   string    `json:"base_currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate"`
+	QuoteCurrency  string    `json:"quote_currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate"`
+	EffectiveOn    time.Time `json:"effective_on" gorm:"type:date;not null;uniqueIndex:idx_exchange_rate"`
+	Rate           float64   `json:"rate" gorm:"type:decimal(18,8);not null"`
+	UploadedBy     *string   `json:"uploaded_by,omitempty" gorm:"type:uuid"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+// ExchangeRateUploadResult reports an exchange rate file. Files are applied whole or not at
+// all, so Errors being non-empty means nothing was saved.
+type ExchangeRateUploadResult struct {
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Errors  []string       `json:"errors"`
+	Rates   []ExchangeRate `json:"rates"`
+}
+
+// Quote prices a prospective booking in the currency of its pickup location. When asked for
+// another currency the totals are also given converted at the day's exchange rate.
+type Quote struct {
+	VehicleID        string       `json:"vehicle_id"`
+	PickupLocationID string       `json:"pickup_location_id"`
+	PickupAt         time.Time    `json:"pickup_at"`
+	ReturnAt         time.Time    `json:"return_at"`
+	Currency         string       `json:"currency"`
+	Lines            InvoiceLines `json:"lines"`
+	Taxes            InvoiceTaxes `json:"taxes"`
+	Subtotal         Money        `json:"subtotal"`
+	TaxTotal         Money        `json:"tax_total"`
+	Total            Money        `json:"total"`
+
+	ExchangeRate   float64 `json:"exchange_rate,omitempty"`
+	ConvertedTotal *Money  `json:"converted_total,omitempty"`
+}
+
+// LocationRevenue totals the invoices of rentals picked up at one location, in the currency
+// they were issued in and converted into the report's currency
+type LocationRevenue struct {
+	LocationID   string `json:"location_id"`
+	LocationName string `json:"location_name"`
+	Invoices     int    `json:"invoices"`
+	Subtotal     Money  `json:"subtotal"`
+	TaxTotal     Money  `json:"tax_total"`
+	Total        Money  `json:"total"`
+	Converted    Money  `json:"converted"`
+}
+
+// RevenueReport totals an organization's invoices issued between two dates. Each invoice is
+// converted at the exchange rate in effect on its issue date.
+type RevenueReport struct {
+	OrganizationID string            `json:"organization_id"`
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	Currency       string            `json:"currency"`
+	Locations      []LocationRevenue `json:"locations"`
+	Total          Money             `json:"total"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// DefaultMinimumDriverAge applies to organizations that have not set their own minimum
+const DefaultMinimumDriverAge = 21
+
+// DriverProfile holds a user's driving license and the details checked before they may drive a
+// rental. Staff verify the license against the physical card; changing the license details
+// clears the verification.
+type DriverProfile struct {
+	ID                string     `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID            string     `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	LicenseNumber     string     `json:"license_number" gorm:"type:varchar(50);not null"`
+	LicenseState      string     `json:"license_state" gorm:"type:varchar(50)"`
+	LicenseCountry    string     `json:"license_country" gorm:"type:varchar(100);not null"`
+	LicenseClass      string     `json:"license_class" gorm:"type:varchar(20)"`
+	LicenseExpiry     time.Time  `json:"license_expiry" gorm:"type:date;not null"`
+	DateOfBirth       time.Time  `json:"date_of_birth" gorm:"type:date;not null"`
+	AddressLine1      string     `json:"address_line1" gorm:"type:varchar(255)"`
+	AddressLine2      string     `json:"address_line2" gorm:"type:varchar(255)"`
+	City              string     `json:"city" gorm:"type:varchar(100)"`
+	State             string     `json:"state" gorm:"type:varchar(50)"`
+	ZipCode           string     `json:"zip_code" gorm:"type:varchar(20)"`
+	Country           string     `json:"country" gorm:"type:varchar(100)"`
+	LicenseVerified   bool       `json:"license_verified" gorm:"default:false"`
+	LicenseVerifiedAt *time.Time `json:"license_verified_at,omitempty"`
+	LicenseVerifiedBy *string    `json:"license_verified_by,omitempty" gorm:"type:uuid"`
+	CreatedAt         time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (DriverProfile) TableName() string {
+	return "driver_profiles"
+}
+
+// AgeOn returns the driver's age in whole years on the given day
+func (p *DriverProfile) AgeOn(t time.Time) int {
+	age := t.Year() - p.DateOfBirth.Year()
+	if t.Month() < p.DateOfBirth.Month() || (t.Month() == p.DateOfBirth.Month() && t.Day() < p.DateOfBirth.Day()) {
+		age--
+	}
+	return age
+}
+
+// LicenseValidOn reports whether the license has not expired by the given day. A license is
+// valid through its expiry date.
+func (p *DriverProfile) LicenseValidOn(t time.Time) bool {
+	expiry := p.LicenseExpiry
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return !time.Date(expiry.Year(), expiry.Month(), expiry.Day(), 0, 0, 0, 0, time.UTC).Before(day)
+}
+
+// RentalDriver names an additional driver authorized on a rental besides its customer
+type RentalDriver struct {
+	ID        string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	RentalID  string         `json:"rental_id" gorm:"type:uuid;not null;uniqueIndex:idx_rental_driver"`
+	UserID    string         `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_rental_driver"`
+	AddedBy   *string        `json:"added_by,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	Driver    *DriverProfile `json:"driver,omitempty" gorm:"-"`
+}
+
+func (RentalDriver) TableName() string {
+	return "rental_drivers"
+}
+
+type DriverProfileRequest struct {
+	LicenseNumber  string    `json:"license_number"`
+	LicenseState   string    `json:"license_state"`
+	LicenseCountry string    `json:"license_country"`
+	LicenseClass   string    `json:"license_class"`
+	LicenseExpiry  time.Time `json:"license_expiry"`
+	DateOfBirth    time.Time `json:"date_of_birth"`
+	AddressLine1   string    `json:"address_line1"`
+	AddressLine2   string    `json:"address_line2"`
+	City           string    `json:"city"`
+	State          string    `json:"state"`
+	ZipCode        string    `json:"zip_code"`
+	Country        string    `json:"country"`
+}
+
+type AddRentalDriverRequest struct {
+	UserID string `json:"user_id"`
+}
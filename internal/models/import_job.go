@@ -0,0 +1,145 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ImportJobStatus string
+
+const (
+	ImportJobStatusQueued    ImportJobStatus = "queued"
+	ImportJobStatusRunning   ImportJobStatus = "running"
+	ImportJobStatusCompleted ImportJobStatus = "completed"
+	ImportJobStatusFailed    ImportJobStatus = "failed"
+	ImportJobStatusCancelled ImportJobStatus = "cancelled"
+)
+
+// ImportRowError describes a single problem with an imported row. Row is the line number in
+// the file, counting the header as row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ImportRowErrors is a JSONB list of row errors
+type ImportRowErrors []ImportRowError
+
+func (e *ImportRowErrors) Scan(value interface{}) error {
+	if value == nil {
+		*e = ImportRowErrors{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan ImportRowErrors")
+	}
+	return json.Unmarshal(bytes, e)
+}
+
+func (e ImportRowErrors) Value() (driver.Value, error) {
+	if len(e) == 0 {
+		return json.Marshal([]ImportRowError{})
+	}
+	return json.Marshal([]ImportRowError(e))
+}
+
+// ImportRejectedRow keeps an imported row's original values so it can be corrected and re-uploaded
+type ImportRejectedRow struct {
+	Row    int      `json:"row"`
+	Record []string `json:"record"`
+	Errors []string `json:"errors"`
+}
+
+// ImportRejectedRows is a JSONB list of rejected rows
+type ImportRejectedRows []ImportRejectedRow
+
+func (r *ImportRejectedRows) Scan(value interface{}) error {
+	if value == nil {
+		*r = ImportRejectedRows{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan ImportRejectedRows")
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+func (r ImportRejectedRows) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return json.Marshal([]ImportRejectedRow{})
+	}
+	return json.Marshal([]ImportRejectedRow(r))
+}
+
+// ImportJob tracks an inventory file being imported in the background
+type ImportJob struct {
+	ID                string          `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID    string          `json:"organization_id" gorm:"type:uuid;not null;index"`
+	LocationID        string          `json:"location_id" gorm:"type:uuid;not null"`
+	CreatedBy         *string         `json:"created_by,omitempty" gorm:"type:uuid"`
+	Filename          string          `json:"filename" gorm:"type:varchar(255)"`
+	Format            string          `json:"format" gorm:"type:varchar(10);default:'csv'"`
+	Sheet             string          `json:"sheet,omitempty" gorm:"type:varchar(255)"`
+	ProfileID         *string         `json:"profile_id,omitempty" gorm:"type:uuid"`
+	FilePath          string          `json:"-" gorm:"type:text"`
+	FileSize          int64           `json:"file_size"`
+	Mode              string          `json:"mode" gorm:"type:varchar(20);not null"`
+	OnConflict        string          `json:"on_conflict" gorm:"type:varchar(20);not null"`
+	DeactivateMissing bool            `json:"deactivate_missing" gorm:"default:false"`
+	Status            ImportJobStatus `json:"status" gorm:"type:varchar(20);default:'queued';index"`
+	CancelRequested   bool            `json:"cancel_requested" gorm:"default:false"`
+	Error             string          `json:"error,omitempty" gorm:"type:text"`
+	Warning           string          `json:"warning,omitempty" gorm:"type:text"`
+
+	// Progress
+	BytesProcessed int64 `json:"bytes_processed"`
+	ProcessedRows  int   `json:"processed_rows"`
+	Progress       int   `json:"progress" gorm:"-"` // percent of the file read
+
+	// Results
+	Success     int `json:"success"`
+	Failed      int `json:"failed"`
+	Created     int `json:"created"`
+	Updated     int `json:"updated"`
+	Unchanged   int `json:"unchanged"`
+	Skipped     int `json:"skipped"`
+	Deactivated int `json:"deactivated"`
+
+	Report          ImportRowErrors    `json:"report" gorm:"type:jsonb"`
+	ReportTruncated bool               `json:"report_truncated" gorm:"default:false"`
+	Headers         StringArray        `json:"-" gorm:"type:jsonb"`
+	Rejected        ImportRejectedRows `json:"-" gorm:"type:jsonb"`
+
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
+
+// AfterFind derives the progress percentage from the bytes read so far
+func (j *ImportJob) AfterFind(tx *gorm.DB) error {
+	switch {
+	case j.Status == ImportJobStatusCompleted:
+		j.Progress = 100
+	case j.FileSize > 0:
+		j.Progress = int(j.BytesProcessed * 100 / j.FileSize)
+	}
+	return nil
+}
+
+// IsFinished reports whether the job has reached a terminal status
+func (j *ImportJob) IsFinished() bool {
+	return j.Status == ImportJobStatusCompleted || j.Status == ImportJobStatusFailed || j.Status == ImportJobStatusCancelled
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Import transform types
+const (
+	ImportTransformUnit = "unit" // convert between units, e.g. km to mi
+	ImportTransformMap  = "map"  // map source values to field values, e.g. "Pre-Owned" to used
+	ImportTransformDate = "date" // parse dates in the given format
+)
+
+// ImportTransform converts a source value before it is validated.
+// Unit uses From and To; map uses Values and an optional Default; date uses Format, written
+// either as a Go layout or with YYYY, YY, MMM, MM, M, DD and D tokens (e.g. MM/DD/YYYY).
+type ImportTransform struct {
+	Type    string            `json:"type"`
+	From    string            `json:"from,omitempty"`
+	To      string            `json:"to,omitempty"`
+	Values  map[string]string `json:"values,omitempty"`
+	Default string            `json:"default,omitempty"`
+	Format  string            `json:"format,omitempty"`
+}
+
+// ImportColumnMapping maps one source header to a vehicle field
+type ImportColumnMapping struct {
+	Source    string           `json:"source"`
+	Field     string           `json:"field"`
+	Transform *ImportTransform `json:"transform,omitempty"`
+}
+
+// ImportColumnMappings is a JSONB list of column mappings
+type ImportColumnMappings []ImportColumnMapping
+
+func (m *ImportColumnMappings) Scan(value interface{}) error {
+	if value == nil {
+		*m = ImportColumnMappings{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan ImportColumnMappings")
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+func (m ImportColumnMappings) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return json.Marshal([]ImportColumnMapping{})
+	}
+	return json.Marshal([]ImportColumnMapping(m))
+}
+
+// ImportProfile is a saved, per-organization mapping from a dealer feed's headers to vehicle fields
+type ImportProfile struct {
+	ID             string               `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string               `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_import_profile_org_name"`
+	Name           string               `json:"name" gorm:"type:varchar(255);not null;uniqueIndex:idx_import_profile_org_name"`
+	Columns        ImportColumnMappings `json:"columns" gorm:"type:jsonb"`
+	CreatedAt      time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ImportProfile) TableName() string {
+	return "import_profiles"
+}
+
+type CreateImportProfileRequest struct {
+	OrganizationID string                `json:"organization_id"`
+	Name           string                `json:"name"`
+	Columns        []ImportColumnMapping `json:"columns"`
+}
+
+type UpdateImportProfileRequest struct {
+	Name    string                `json:"name"`
+	Columns []ImportColumnMapping `json:"columns"`
+}
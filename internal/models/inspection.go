@@ -0,0 +1,151 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type InspectionType string
+type InspectionCleanliness string
+type DamageSeverity string
+
+const (
+	InspectionTypeCheckout InspectionType = "checkout"
+	InspectionTypeCheckin  InspectionType = "checkin"
+
+	InspectionCleanlinessClean      InspectionCleanliness = "clean"
+	InspectionCleanlinessAcceptable InspectionCleanliness = "acceptable"
+	InspectionCleanlinessDirty      InspectionCleanliness = "dirty"
+
+	DamageSeverityMinor    DamageSeverity = "minor"
+	DamageSeverityModerate DamageSeverity = "moderate"
+	DamageSeveritySevere   DamageSeverity = "severe"
+)
+
+// DamagePanels lists the body panels damage can be recorded against
+var DamagePanels = []string{
+	"front_bumper", "rear_bumper", "hood", "roof", "trunk", "windshield", "rear_window",
+	"front_left_door", "front_right_door", "rear_left_door", "rear_right_door",
+	"front_left_fender", "front_right_fender", "rear_left_quarter", "rear_right_quarter",
+	"left_mirror", "right_mirror", "front_left_wheel", "front_right_wheel",
+	"rear_left_wheel", "rear_right_wheel", "interior", "undercarriage",
+}
+
+// Rank orders severities so worsening damage can be detected
+func (s DamageSeverity) Rank() int {
+	switch s {
+	case DamageSeverityMinor:
+		return 1
+	case DamageSeverityModerate:
+		return 2
+	case DamageSeveritySevere:
+		return 3
+	}
+	return 0
+}
+
+// Damage is one damaged area found during an inspection
+type Damage struct {
+	Panel    string         `json:"panel"`
+	Severity DamageSeverity `json:"severity"`
+	Photos   []string       `json:"photos,omitempty"`
+	Notes    string         `json:"notes,omitempty"`
+}
+
+// Damages is a JSONB list of damage
+type Damages []Damage
+
+func (d *Damages) Scan(value interface{}) error {
+	if value == nil {
+		*d = Damages{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan Damages")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+func (d Damages) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return json.Marshal([]Damage{})
+	}
+	return json.Marshal([]Damage(d))
+}
+
+// Inspection records the state of a vehicle when it goes out on a rental (checkout) or comes
+// back (checkin). Inspections are an audit trail and are not edited after they are taken.
+type Inspection struct {
+	ID             string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string         `json:"organization_id" gorm:"type:uuid;not null;index"`
+	LocationID     string         `json:"location_id" gorm:"type:uuid;not null;index"`
+	VehicleID      string         `json:"vehicle_id" gorm:"type:uuid;not null;index"`
+	RentalID       *string        `json:"rental_id,omitempty" gorm:"type:uuid;index"`
+	Type           InspectionType `json:"type" gorm:"type:varchar(20);not null"`
+	Mileage        int            `json:"mileage"`
+	// Fuel or battery charge, as a percentage
+	FuelLevel   int                   `json:"fuel_level"`
+	Cleanliness InspectionCleanliness `json:"cleanliness" gorm:"type:varchar(20)"`
+	Damages     Damages               `json:"damages" gorm:"type:jsonb"`
+	Notes       string                `json:"notes" gorm:"type:text"`
+	// For a checkin, the checkout it is compared against
+	CheckoutID  *string   `json:"checkout_id,omitempty" gorm:"type:uuid"`
+	InspectedBy *string   `json:"inspected_by,omitempty" gorm:"type:uuid"`
+	InspectedAt time.Time `json:"inspected_at" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Charges a rental checkin raised under the organization's return policy
+	Charges []RentalCharge `json:"charges,omitempty" gorm:"-"`
+}
+
+func (Inspection) TableName() string {
+	return "inspections"
+}
+
+// NewDamage returns the damage on this checkin that was not on the checkout: panels that were
+// undamaged before, or damage that has become more severe
+func (i *Inspection) NewDamage(checkout *Inspection) []Damage {
+	worst := make(map[string]int)
+	if checkout != nil {
+		for _, damage := range checkout.Damages {
+			if rank := damage.Severity.Rank(); rank > worst[damage.Panel] {
+				worst[damage.Panel] = rank
+			}
+		}
+	}
+
+	newDamage := []Damage{}
+	for _, damage := range i.Damages {
+		if damage.Severity.Rank() > worst[damage.Panel] {
+			newDamage = append(newDamage, damage)
+		}
+	}
+	return newDamage
+}
+
+// InspectionComparison sets a checkin against the checkout before it
+type InspectionComparison struct {
+	Checkin     *Inspection `json:"checkin"`
+	Checkout    *Inspection `json:"checkout"`
+	NewDamage   []Damage    `json:"new_damage"`
+	MilesDriven *int        `json:"miles_driven,omitempty"`
+	FuelUsed    *int        `json:"fuel_used,omitempty"`
+}
+
+type CreateInspectionRequest struct {
+	Type        InspectionType        `json:"type"`
+	LocationID  string                `json:"location_id"`
+	RentalID    *string               `json:"rental_id"`
+	Mileage     int                   `json:"mileage"`
+	FuelLevel   int                   `json:"fuel_level"`
+	Cleanliness InspectionCleanliness `json:"cleanliness"`
+	Damages     []Damage              `json:"damages"`
+	Notes       string                `json:"notes"`
+	// When a checkout without a rental is due back; a day after checkout if omitted
+	ReturnAt *time.Time `json:"return_at"`
+	// Check a vehicle in at another location even if that location is full
+	AllowOverCapacity bool `json:"allow_over_capacity"`
+}
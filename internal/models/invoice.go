@@ -0,0 +1,179 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// TaxRate is a named tax charged at a location, as a percentage of taxable amounts
+type TaxRate struct {
+	Name string  `json:"name"`
+	Rate float64 `json:"rate"`
+}
+
+// TaxRates is a JSONB list of the taxes charged at a location
+type TaxRates []TaxRate
+
+func (t *TaxRates) Scan(value interface{}) error {
+	if value == nil {
+		*t = TaxRates{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan TaxRates")
+	}
+	return json.Unmarshal(bytes, t)
+}
+
+func (t TaxRates) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return json.Marshal([]TaxRate{})
+	}
+	return json.Marshal([]TaxRate(t))
+}
+
+type RentalChargeKind string
+
+const (
+	RentalChargeFuel       RentalChargeKind = "fuel"
+	RentalChargeLateReturn RentalChargeKind = "late_return"
+	RentalChargeDamage     RentalChargeKind = "damage"
+	RentalChargeMileage    RentalChargeKind = "mileage"
+	RentalChargeOther      RentalChargeKind = "other"
+)
+
+// RentalCharges lists the kinds of extra charge a rental can carry
+var RentalCharges = []RentalChargeKind{RentalChargeFuel, RentalChargeLateReturn, RentalChargeDamage, RentalChargeMileage, RentalChargeOther}
+
+// RentalCharge is an extra billed on a rental on top of its pricing quote. Charges raised at
+// check-in under the organization's return policy name the checkin inspection.
+type RentalCharge struct {
+	ID          string           `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	RentalID    string           `json:"rental_id" gorm:"type:uuid;not null;index"`
+	Kind        RentalChargeKind `json:"kind" gorm:"type:varchar(20);not null"`
+	Description string           `json:"description" gorm:"type:varchar(255);not null"`
+	Quantity    float64          `json:"quantity" gorm:"type:decimal(10,2);default:1"`
+	UnitPrice   float64          `json:"unit_price" gorm:"type:decimal(15,3);not null"`
+	Amount      float64          `json:"amount" gorm:"type:decimal(15,3);not null"`
+	Taxable     bool             `json:"taxable" gorm:"default:true"`
+	CreatedBy   *string          `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time        `json:"created_at" gorm:"autoCreateTime"`
+
+	InspectionID *string `json:"inspection_id,omitempty" gorm:"type:uuid;index"`
+}
+
+func (RentalCharge) TableName() string {
+	return "rental_charges"
+}
+
+// InvoiceLine is one billed item. Kind is "rental" for the pricing quote or a rental charge kind.
+type InvoiceLine struct {
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+	Taxable     bool    `json:"taxable"`
+}
+
+// InvoiceLines is a JSONB list of invoice lines
+type InvoiceLines []InvoiceLine
+
+func (l *InvoiceLines) Scan(value interface{}) error {
+	if value == nil {
+		*l = InvoiceLines{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan InvoiceLines")
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+func (l InvoiceLines) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return json.Marshal([]InvoiceLine{})
+	}
+	return json.Marshal([]InvoiceLine(l))
+}
+
+// InvoiceTax is one tax on an invoice and the amount it was charged on
+type InvoiceTax struct {
+	Name          string  `json:"name"`
+	Rate          float64 `json:"rate"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	Amount        float64 `json:"amount"`
+}
+
+// InvoiceTaxes is a JSONB list of invoice taxes
+type InvoiceTaxes []InvoiceTax
+
+func (t *InvoiceTaxes) Scan(value interface{}) error {
+	if value == nil {
+		*t = InvoiceTaxes{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan InvoiceTaxes")
+	}
+	return json.Unmarshal(bytes, t)
+}
+
+func (t InvoiceTaxes) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return json.Marshal([]InvoiceTax{})
+	}
+	return json.Marshal([]InvoiceTax(t))
+}
+
+// Invoice bills a completed rental. Numbers run sequentially per organization without gaps and
+// an issued invoice is never changed, so its lines, taxes and bill-to are copied in at issue.
+type Invoice struct {
+	ID             string       `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string       `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_invoice_number"`
+	RentalID       string       `json:"rental_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Number         string       `json:"number" gorm:"type:varchar(30);not null;uniqueIndex:idx_invoice_number"`
+	Sequence       int          `json:"sequence" gorm:"not null"`
+	CustomerID     *string      `json:"customer_id,omitempty" gorm:"type:uuid;index"`
+	BillToName     string       `json:"bill_to_name" gorm:"type:varchar(255)"`
+	BillToEmail    string       `json:"bill_to_email" gorm:"type:varchar(255)"`
+	BillToAddress  string       `json:"bill_to_address" gorm:"type:text"`
+	Currency       string       `json:"currency" gorm:"type:varchar(3);not null;default:'USD'"`
+	Lines          InvoiceLines `json:"lines" gorm:"type:jsonb"`
+	Taxes          InvoiceTaxes `json:"taxes" gorm:"type:jsonb"`
+	Subtotal       Money        `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+	TaxTotal       Money        `json:"tax_total" gorm:"embedded;embeddedPrefix:tax_total_"`
+	Total          Money        `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	AmountPaid     Money        `json:"amount_paid" gorm:"embedded;embeddedPrefix:amount_paid_"`
+	BalanceDue     Money        `json:"balance_due" gorm:"embedded;embeddedPrefix:balance_due_"`
+	IssuedAt       time.Time    `json:"issued_at" gorm:"not null"`
+	IssuedBy       *string      `json:"issued_by,omitempty" gorm:"type:uuid"`
+	EmailedAt      *time.Time   `json:"emailed_at,omitempty"`
+	EmailedTo      string       `json:"emailed_to,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+type CreateRentalChargeRequest struct {
+	Kind        RentalChargeKind `json:"kind"`
+	Description string           `json:"description"`
+	// Defaults to 1
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	// Defaults to true
+	Taxable *bool `json:"taxable"`
+}
+
+type EmailInvoiceRequest struct {
+	// Defaults to the invoice's bill-to email
+	To string `json:"to"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Location struct {
 	ID             string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -17,6 +21,29 @@ type Location struct {
 	IsActive       bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Business hours. TimeZone is an IANA zone name; pickup and return times are checked
+	// against the opening hours and shown in it.
+	TimeZone     string       `json:"time_zone" gorm:"type:varchar(64);default:'UTC'"`
+	OpeningHours OpeningHours `json:"opening_hours" gorm:"type:jsonb"`
+	// Returns outside opening hours are accepted into a key drop and flagged
+	AllowsKeyDrop bool `json:"allows_key_drop" gorm:"default:false"`
+
+	// Geocoded from the address unless set explicitly
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+
+	// Number of vehicles the lot holds; nil means unlimited
+	Capacity *int `json:"capacity,omitempty"`
+
+	// Taxes charged on rentals picked up here
+	Taxes TaxRates `json:"taxes" gorm:"type:jsonb"`
+	// ISO 4217 code that rentals picked up here are priced and invoiced in
+	Currency string `json:"currency" gorm:"type:varchar(3);not null;default:'USD'"`
+
+	// Soft delete
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	DeletedBy *string        `json:"deleted_by,omitempty" gorm:"type:uuid"`
 }
 
 func (Location) TableName() string {
@@ -34,8 +61,25 @@ type CreateLocationRequest struct {
 	Country        string `json:"country"`
 	Phone          string `json:"phone"`
 	Email          string `json:"email"`
+
+	TimeZone string `json:"time_zone"`
+	// Omitted means always open
+	OpeningHours  []OpeningPeriod `json:"opening_hours"`
+	AllowsKeyDrop bool            `json:"allows_key_drop"`
+
+	// Geocoded from the address when omitted
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+
+	// Omitted means unlimited
+	Capacity *int `json:"capacity"`
+
+	Taxes []TaxRate `json:"taxes"`
+	// Defaults to the organization's currency
+	Currency string `json:"currency"`
 }
 
+// UpdateLocationRequest replaces a location whole; omitted fields take their create defaults
 type UpdateLocationRequest struct {
 	Name         string `json:"name"`
 	AddressLine1 string `json:"address_line1"`
@@ -47,4 +91,27 @@ type UpdateLocationRequest struct {
 	Phone        string `json:"phone"`
 	Email        string `json:"email"`
 	IsActive     bool   `json:"is_active"`
+
+	TimeZone string `json:"time_zone"`
+	// Omitted means always open
+	OpeningHours  []OpeningPeriod `json:"opening_hours"`
+	AllowsKeyDrop bool            `json:"allows_key_drop"`
+
+	// Geocoded from the address when omitted
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+
+	// Omitted means unlimited
+	Capacity *int `json:"capacity"`
+
+	Taxes []TaxRate `json:"taxes"`
+	// Defaults to the organization's currency
+	Currency string `json:"currency"`
+}
+
+// NearbyLocation is a location found by a distance search
+type NearbyLocation struct {
+	Location
+	DistanceMiles     float64 `json:"distance_miles"`
+	AvailableVehicles int64   `json:"available_vehicles"`
 }
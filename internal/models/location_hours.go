@@ -0,0 +1,161 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// Embed the zone database so time zones resolve on hosts without one
+	_ "time/tzdata"
+)
+
+// Weekdays maps the day names used in opening hours to time.Weekday
+var Weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// OpeningPeriod is a stretch of a weekday a location is open, in its local time. Opens and
+// Closes are "HH:MM"; Closes may be "24:00" to run to midnight.
+type OpeningPeriod struct {
+	Day    string `json:"day"`
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// OpeningHours is a JSONB weekly schedule. A location without any periods is always open;
+// a day without periods is closed.
+type OpeningHours []OpeningPeriod
+
+func (h *OpeningHours) Scan(value interface{}) error {
+	if value == nil {
+		*h = OpeningHours{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan OpeningHours")
+	}
+	return json.Unmarshal(bytes, h)
+}
+
+func (h OpeningHours) Value() (driver.Value, error) {
+	if len(h) == 0 {
+		return json.Marshal([]OpeningPeriod{})
+	}
+	return json.Marshal([]OpeningPeriod(h))
+}
+
+// parseClock turns "HH:MM" into minutes after midnight
+func parseClock(clock string) (int, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(clock, "%d:%d", &hours, &minutes); err != nil || len(clock) != 5 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes > 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return hours*60 + minutes, nil
+}
+
+// Validate checks every period names a weekday and opens before it closes
+func (h OpeningHours) Validate() error {
+	for _, period := range h {
+		if _, ok := Weekdays[strings.ToLower(period.Day)]; !ok {
+			return fmt.Errorf("invalid day: %s", period.Day)
+		}
+		opens, err := parseClock(period.Opens)
+		if err != nil {
+			return err
+		}
+		closes, err := parseClock(period.Closes)
+		if err != nil {
+			return err
+		}
+		if closes <= opens {
+			return fmt.Errorf("%s opens at %s but closes at %s", period.Day, period.Opens, period.Closes)
+		}
+	}
+	return nil
+}
+
+// OpenAt reports whether the schedule is open at the given local wall-clock time
+func (h OpeningHours) OpenAt(local time.Time) bool {
+	if len(h) == 0 {
+		return true
+	}
+	minute := local.Hour()*60 + local.Minute()
+	for _, period := range h {
+		if Weekdays[strings.ToLower(period.Day)] != local.Weekday() {
+			continue
+		}
+		opens, err := parseClock(period.Opens)
+		if err != nil {
+			continue
+		}
+		closes, err := parseClock(period.Closes)
+		if err != nil {
+			continue
+		}
+		if minute >= opens && minute < closes {
+			return true
+		}
+	}
+	return false
+}
+
+// LocationHoliday is a day a location is closed regardless of its opening hours
+type LocationHoliday struct {
+	ID         string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	LocationID string    `json:"location_id" gorm:"type:uuid;not null;uniqueIndex:idx_location_holiday_date"`
+	Date       time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_location_holiday_date"`
+	Name       string    `json:"name" gorm:"type:varchar(255)"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (LocationHoliday) TableName() string {
+	return "location_holidays"
+}
+
+// Zone returns the location's time zone, or UTC if it has none or it is unknown
+func (l *Location) Zone() *time.Location {
+	if l.TimeZone == "" {
+		return time.UTC
+	}
+	zone, err := time.LoadLocation(l.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return zone
+}
+
+// OpenAt reports whether the location is open at t, taking its holidays into account
+func (l *Location) OpenAt(t time.Time, holidays []LocationHoliday) bool {
+	local := t.In(l.Zone())
+	day := local.Format("2006-01-02")
+	for _, holiday := range holidays {
+		if holiday.Date.Format("2006-01-02") == day {
+			return false
+		}
+	}
+	return l.OpeningHours.OpenAt(local)
+}
+
+// LocationHours describes when a location is open, as seen from its own time zone
+type LocationHours struct {
+	LocationID    string            `json:"location_id"`
+	TimeZone      string            `json:"time_zone"`
+	LocalTime     time.Time         `json:"local_time"`
+	OpenNow       bool              `json:"open_now"`
+	OpeningHours  OpeningHours      `json:"opening_hours"`
+	AllowsKeyDrop bool              `json:"allows_key_drop"`
+	Holidays      []LocationHoliday `json:"holidays"`
+}
+
+type CreateLocationHolidayRequest struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
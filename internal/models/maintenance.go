@@ -0,0 +1,114 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type MaintenanceType string
+
+const (
+	MaintenanceTypeOilChange    MaintenanceType = "oil_change"
+	MaintenanceTypeTireRotation MaintenanceType = "tire_rotation"
+	MaintenanceTypeTires        MaintenanceType = "tires"
+	MaintenanceTypeBrakes       MaintenanceType = "brakes"
+	MaintenanceTypeInspection   MaintenanceType = "inspection"
+	MaintenanceTypeRepair       MaintenanceType = "repair"
+	MaintenanceTypeRecall       MaintenanceType = "recall"
+	MaintenanceTypeBodywork     MaintenanceType = "bodywork"
+	MaintenanceTypeOther        MaintenanceType = "other"
+)
+
+// MaintenanceTypes lists the valid maintenance types
+var MaintenanceTypes = []MaintenanceType{
+	MaintenanceTypeOilChange, MaintenanceTypeTireRotation, MaintenanceTypeTires, MaintenanceTypeBrakes,
+	MaintenanceTypeInspection, MaintenanceTypeRepair, MaintenanceTypeRecall, MaintenanceTypeBodywork,
+	MaintenanceTypeOther,
+}
+
+// MaintenancePart is a part used during a service
+type MaintenancePart struct {
+	Name       string  `json:"name"`
+	PartNumber string  `json:"part_number,omitempty"`
+	Quantity   int     `json:"quantity"`
+	UnitCost   float64 `json:"unit_cost"`
+}
+
+// MaintenanceParts is a JSONB list of parts
+type MaintenanceParts []MaintenancePart
+
+func (p *MaintenanceParts) Scan(value interface{}) error {
+	if value == nil {
+		*p = MaintenanceParts{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan MaintenanceParts")
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+func (p MaintenanceParts) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return json.Marshal([]MaintenancePart{})
+	}
+	return json.Marshal([]MaintenancePart(p))
+}
+
+// MaintenanceRecord is a piece of service work on a vehicle. While a record is open
+// (ClosedAt is nil) the vehicle is in maintenance and cannot be rented.
+type MaintenanceRecord struct {
+	ID                string           `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID    string           `json:"organization_id" gorm:"type:uuid;not null;index"`
+	VehicleID         string           `json:"vehicle_id" gorm:"type:uuid;not null;index"`
+	Type              MaintenanceType  `json:"type" gorm:"type:varchar(50);not null"`
+	Vendor            string           `json:"vendor" gorm:"type:varchar(255)"`
+	OdometerAtService int              `json:"odometer_at_service"`
+	Cost              float64          `json:"cost" gorm:"type:decimal(10,2);default:0"`
+	Parts             MaintenanceParts `json:"parts" gorm:"type:jsonb"`
+	Notes             string           `json:"notes" gorm:"type:text"`
+	Attachments       StringArray      `json:"attachments" gorm:"type:jsonb"`
+	OpenedAt          time.Time        `json:"opened_at" gorm:"not null"`
+	ClosedAt          *time.Time       `json:"closed_at,omitempty" gorm:"index"`
+	// Status the vehicle returns to once its last open record is closed
+	PreviousStatus VehicleStatus `json:"previous_status,omitempty" gorm:"type:varchar(50)"`
+	CreatedBy      *string       `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (MaintenanceRecord) TableName() string {
+	return "maintenance_records"
+}
+
+// IsOpen reports whether the work is still in progress
+func (m *MaintenanceRecord) IsOpen() bool {
+	return m.ClosedAt == nil
+}
+
+type CreateMaintenanceRecordRequest struct {
+	Type              MaintenanceType   `json:"type"`
+	Vendor            string            `json:"vendor"`
+	OdometerAtService int               `json:"odometer_at_service"`
+	Cost              float64           `json:"cost"`
+	Parts             []MaintenancePart `json:"parts"`
+	Notes             string            `json:"notes"`
+	Attachments       []string          `json:"attachments"`
+	OpenedAt          *time.Time        `json:"opened_at"`
+	ClosedAt          *time.Time        `json:"closed_at"`
+}
+
+type UpdateMaintenanceRecordRequest struct {
+	Type              MaintenanceType   `json:"type"`
+	Vendor            string            `json:"vendor"`
+	OdometerAtService int               `json:"odometer_at_service"`
+	Cost              float64           `json:"cost"`
+	Parts             []MaintenancePart `json:"parts"`
+	Notes             string            `json:"notes"`
+	Attachments       []string          `json:"attachments"`
+	OpenedAt          *time.Time        `json:"opened_at"`
+	ClosedAt          *time.Time        `json:"closed_at"`
+}
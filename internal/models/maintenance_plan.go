@@ -0,0 +1,198 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type MaintenanceDueStatus string
+
+const (
+	MaintenanceDueStatusOK      MaintenanceDueStatus = "ok"
+	MaintenanceDueStatusDueSoon MaintenanceDueStatus = "due_soon"
+	MaintenanceDueStatusOverdue MaintenanceDueStatus = "overdue"
+)
+
+// MaintenancePlan schedules recurring service of one type, every IntervalMiles or
+// IntervalMonths, whichever comes first. A plan attaches either to a single vehicle
+// or to every vehicle matching Make, Model and the year range; blank criteria match
+// any vehicle. A vehicle plan overrides make/model plans of the same type.
+type MaintenancePlan struct {
+	ID             string          `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string          `json:"organization_id" gorm:"type:uuid;not null;index"`
+	Name           string          `json:"name" gorm:"type:varchar(255);not null"`
+	Type           MaintenanceType `json:"type" gorm:"type:varchar(50);not null"`
+	VehicleID      *string         `json:"vehicle_id,omitempty" gorm:"type:uuid;index"`
+	Make           string          `json:"make" gorm:"type:varchar(100)"`
+	Model          string          `json:"model" gorm:"type:varchar(100)"`
+	YearMin        *int            `json:"year_min,omitempty"`
+	YearMax        *int            `json:"year_max,omitempty"`
+	IntervalMiles  int             `json:"interval_miles" gorm:"default:0"`
+	IntervalMonths int             `json:"interval_months" gorm:"default:0"`
+	// How early a vehicle is reported as due soon
+	DueSoonMiles int       `json:"due_soon_miles" gorm:"default:500"`
+	DueSoonDays  int       `json:"due_soon_days" gorm:"default:14"`
+	IsActive     bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (MaintenancePlan) TableName() string {
+	return "maintenance_plans"
+}
+
+// AppliesTo reports whether the plan covers the vehicle
+func (p *MaintenancePlan) AppliesTo(vehicle *Vehicle) bool {
+	if p.OrganizationID != vehicle.OrganizationID {
+		return false
+	}
+	if p.VehicleID != nil {
+		return *p.VehicleID == vehicle.ID
+	}
+	if p.Make != "" && !strings.EqualFold(p.Make, vehicle.Make) {
+		return false
+	}
+	if p.Model != "" && !strings.EqualFold(p.Model, vehicle.Model) {
+		return false
+	}
+	if p.YearMin != nil && vehicle.Year < *p.YearMin {
+		return false
+	}
+	if p.YearMax != nil && vehicle.Year > *p.YearMax {
+		return false
+	}
+	return true
+}
+
+// MaintenanceDue is where a vehicle stands against one of its plans
+type MaintenanceDue struct {
+	VehicleID          string               `json:"vehicle_id"`
+	LocationID         string               `json:"location_id"`
+	VIN                string               `json:"vin"`
+	Make               string               `json:"make"`
+	Model              string               `json:"model"`
+	Year               int                  `json:"year"`
+	StockNumber        string               `json:"stock_number"`
+	PlanID             string               `json:"plan_id"`
+	PlanName           string               `json:"plan_name"`
+	Type               MaintenanceType      `json:"type"`
+	Status             MaintenanceDueStatus `json:"status"`
+	Mileage            int                  `json:"mileage"`
+	LastServiceID      *string              `json:"last_service_id,omitempty"`
+	LastServiceAt      *time.Time           `json:"last_service_at,omitempty"`
+	LastServiceMileage *int                 `json:"last_service_mileage,omitempty"`
+	NextDueMileage     *int                 `json:"next_due_mileage,omitempty"`
+	NextDueAt          *time.Time           `json:"next_due_at,omitempty"`
+	MilesRemaining     *int                 `json:"miles_remaining,omitempty"`
+	DaysRemaining      *int                 `json:"days_remaining,omitempty"`
+}
+
+// StartedFor is when the plan began covering the vehicle: the later of the two's creation
+func (p *MaintenancePlan) StartedFor(vehicle *Vehicle) time.Time {
+	if p.CreatedAt.After(vehicle.CreatedAt) {
+		return p.CreatedAt
+	}
+	return vehicle.CreatedAt
+}
+
+// Due computes the next service for the vehicle from its last closed record of the plan's
+// type. Without a record, the count starts when the plan began covering the vehicle, at
+// startMileage, the odometer reading of that time.
+func (p *MaintenancePlan) Due(vehicle *Vehicle, last *MaintenanceRecord, startMileage int, now time.Time) MaintenanceDue {
+	due := MaintenanceDue{
+		VehicleID:   vehicle.ID,
+		LocationID:  vehicle.LocationID,
+		VIN:         vehicle.VIN,
+		Make:        vehicle.Make,
+		Model:       vehicle.Model,
+		Year:        vehicle.Year,
+		StockNumber: vehicle.StockNumber,
+		PlanID:      p.ID,
+		PlanName:    p.Name,
+		Type:        p.Type,
+		Status:      MaintenanceDueStatusOK,
+		Mileage:     vehicle.Mileage,
+	}
+
+	baseMileage, baseTime := startMileage, p.StartedFor(vehicle)
+	if last != nil && last.ClosedAt != nil {
+		baseMileage, baseTime = last.OdometerAtService, *last.ClosedAt
+		due.LastServiceID = &last.ID
+		due.LastServiceAt = last.ClosedAt
+		due.LastServiceMileage = &last.OdometerAtService
+	}
+
+	if p.IntervalMiles > 0 {
+		nextMileage := baseMileage + p.IntervalMiles
+		remaining := nextMileage - vehicle.Mileage
+		due.NextDueMileage = &nextMileage
+		due.MilesRemaining = &remaining
+		due.Status = worseDueStatus(due.Status, remaining <= 0, remaining <= p.DueSoonMiles)
+	}
+
+	if p.IntervalMonths > 0 {
+		nextAt := baseTime.AddDate(0, p.IntervalMonths, 0)
+		days := int(nextAt.Sub(now).Hours() / 24)
+		due.NextDueAt = &nextAt
+		due.DaysRemaining = &days
+		due.Status = worseDueStatus(due.Status, !now.Before(nextAt), !now.AddDate(0, 0, p.DueSoonDays).Before(nextAt))
+	}
+
+	return due
+}
+
+func worseDueStatus(current MaintenanceDueStatus, overdue, dueSoon bool) MaintenanceDueStatus {
+	switch {
+	case overdue || current == MaintenanceDueStatusOverdue:
+		return MaintenanceDueStatusOverdue
+	case dueSoon:
+		return MaintenanceDueStatusDueSoon
+	default:
+		return current
+	}
+}
+
+// MaintenanceAlert records that a due soon or overdue alert was sent, so each threshold
+// is reported once per service interval. LastServiceID identifies the interval.
+type MaintenanceAlert struct {
+	ID            string               `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	PlanID        string               `json:"plan_id" gorm:"type:uuid;not null;index:idx_maintenance_alert"`
+	VehicleID     string               `json:"vehicle_id" gorm:"type:uuid;not null;index:idx_maintenance_alert"`
+	LastServiceID *string              `json:"last_service_id,omitempty" gorm:"type:uuid"`
+	Status        MaintenanceDueStatus `json:"status" gorm:"type:varchar(20);not null"`
+	SentAt        time.Time            `json:"sent_at"`
+}
+
+func (MaintenanceAlert) TableName() string {
+	return "maintenance_alerts"
+}
+
+type CreateMaintenancePlanRequest struct {
+	OrganizationID string          `json:"organization_id"`
+	Name           string          `json:"name"`
+	Type           MaintenanceType `json:"type"`
+	VehicleID      *string         `json:"vehicle_id"`
+	Make           string          `json:"make"`
+	Model          string          `json:"model"`
+	YearMin        *int            `json:"year_min"`
+	YearMax        *int            `json:"year_max"`
+	IntervalMiles  int             `json:"interval_miles"`
+	IntervalMonths int             `json:"interval_months"`
+	DueSoonMiles   *int            `json:"due_soon_miles"`
+	DueSoonDays    *int            `json:"due_soon_days"`
+}
+
+type UpdateMaintenancePlanRequest struct {
+	Name           string          `json:"name"`
+	Type           MaintenanceType `json:"type"`
+	VehicleID      *string         `json:"vehicle_id"`
+	Make           string          `json:"make"`
+	Model          string          `json:"model"`
+	YearMin        *int            `json:"year_min"`
+	YearMax        *int            `json:"year_max"`
+	IntervalMiles  int             `json:"interval_miles"`
+	IntervalMonths int             `json:"interval_months"`
+	DueSoonMiles   int             `json:"due_soon_miles"`
+	DueSoonDays    int             `json:"due_soon_days"`
+	IsActive       bool            `json:"is_active"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type OdometerSource string
+type OdometerReadingKind string
+
+const (
+	OdometerSourceManual     OdometerSource = "manual"
+	OdometerSourceCheckout   OdometerSource = "checkout"
+	OdometerSourceReturn     OdometerSource = "return"
+	OdometerSourceTelematics OdometerSource = "telematics"
+	OdometerSourceImport     OdometerSource = "import"
+	OdometerSourceTransfer   OdometerSource = "transfer"
+
+	// A reading must not be lower than the one before it
+	OdometerReadingKindReading OdometerReadingKind = "reading"
+	// The odometer wrapped around to zero
+	OdometerReadingKindRollover OdometerReadingKind = "rollover"
+	// An administrator fixed a wrong reading or replaced the odometer
+	OdometerReadingKindCorrection OdometerReadingKind = "correction"
+)
+
+// OdometerReading is one entry in a vehicle's odometer log. Vehicle.Mileage always holds
+// the latest reading that is not flagged as an anomaly.
+type OdometerReading struct {
+	ID             string              `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string              `json:"organization_id" gorm:"type:uuid;not null;index"`
+	VehicleID      string              `json:"vehicle_id" gorm:"type:uuid;not null;index:idx_odometer_vehicle_read_at"`
+	Reading        int                 `json:"reading" gorm:"not null"`
+	Source         OdometerSource      `json:"source" gorm:"type:varchar(20);not null"`
+	Kind           OdometerReadingKind `json:"kind" gorm:"type:varchar(20);not null;default:'reading'"`
+	ReadAt         time.Time           `json:"read_at" gorm:"not null;index:idx_odometer_vehicle_read_at"`
+	Notes          string              `json:"notes" gorm:"type:text"`
+	// Flagged readings are kept for review but do not move Vehicle.Mileage
+	Flagged    bool      `json:"flagged" gorm:"default:false"`
+	Anomaly    string    `json:"anomaly,omitempty" gorm:"type:varchar(255)"`
+	RecordedBy *string   `json:"recorded_by,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (OdometerReading) TableName() string {
+	return "odometer_readings"
+}
+
+type CreateOdometerReadingRequest struct {
+	Reading int                 `json:"reading"`
+	Source  OdometerSource      `json:"source"`
+	Kind    OdometerReadingKind `json:"kind"`
+	ReadAt  *time.Time          `json:"read_at"`
+	Notes   string              `json:"notes"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Organization struct {
 	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -9,19 +13,80 @@ type Organization struct {
 	IsActive  bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Drivers younger than this on the pickup date cannot drive a rental
+	MinimumDriverAge int `json:"minimum_driver_age" gorm:"default:21"`
+
+	// ISO 4217 code of the return policy's fees and of reports, and the default for new
+	// locations. Rates are in the currency of the location a vehicle is picked up at.
+	Currency string `json:"currency" gorm:"type:varchar(3);not null;default:'USD'"`
+
+	OrganizationBranding
+	// Sequence of the last invoice issued
+	LastInvoiceNumber int `json:"last_invoice_number" gorm:"default:0"`
+
+	ReturnPolicy
+
+	// Soft delete
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	DeletedBy *string        `json:"deleted_by,omitempty" gorm:"type:uuid"`
 }
 
 func (Organization) TableName() string {
 	return "organizations"
 }
 
+// OrganizationBranding is how an organization presents itself on invoices. Invoices are
+// numbered InvoicePrefix-000001 upwards, with INV as the default prefix.
+type OrganizationBranding struct {
+	LegalName      string `json:"legal_name" gorm:"type:varchar(255)"`
+	BillingAddress string `json:"billing_address" gorm:"type:text"`
+	BillingEmail   string `json:"billing_email" gorm:"type:varchar(255)"`
+	BillingPhone   string `json:"billing_phone" gorm:"type:varchar(50)"`
+	TaxID          string `json:"tax_id" gorm:"type:varchar(50)"`
+	// Hex color such as #1F6FEB used for the invoice header
+	BrandColor    string `json:"brand_color" gorm:"type:varchar(7)"`
+	InvoicePrefix string `json:"invoice_prefix" gorm:"type:varchar(10)"`
+}
+
+// ReturnPolicy sets the charges raised automatically when a rental is checked in. A fee or
+// price of zero turns its charge off.
+type ReturnPolicy struct {
+	// Minutes a return may run past the booked return time before it is late
+	LateGraceMinutes int `json:"late_grace_minutes" gorm:"default:0"`
+	// Charged for each started hour late, at most the vehicle's daily rate for any one day
+	LateHourlyFee float64 `json:"late_hourly_fee" gorm:"type:decimal(15,3);default:0"`
+	// Refill prices for a vehicle returned below its pickup fuel or charge level
+	FuelPricePerGallon float64 `json:"fuel_price_per_gallon" gorm:"type:decimal(15,3);default:0"`
+	ChargePricePerKWh  float64 `json:"charge_price_per_kwh" gorm:"type:decimal(15,3);default:0"`
+	// Miles included for each rental day, with every mile over charged at MileageOverageFee.
+	// Zero means unlimited mileage.
+	IncludedMilesPerDay int     `json:"included_miles_per_day" gorm:"default:0"`
+	MileageOverageFee   float64 `json:"mileage_overage_fee" gorm:"type:decimal(15,3);default:0"`
+}
+
 type CreateOrganizationRequest struct {
 	Name string `json:"name"`
 	Slug string `json:"slug"`
+
+	// Defaults to DefaultMinimumDriverAge
+	MinimumDriverAge *int `json:"minimum_driver_age"`
+	// Defaults to DefaultCurrency
+	Currency string `json:"currency"`
+
+	OrganizationBranding
+	ReturnPolicy
 }
 
 type UpdateOrganizationRequest struct {
 	Name     string `json:"name"`
 	Slug     string `json:"slug"`
 	IsActive bool   `json:"is_active"`
+
+	// Left unchanged if omitted
+	MinimumDriverAge *int   `json:"minimum_driver_age"`
+	Currency         string `json:"currency"`
+
+	OrganizationBranding
+	ReturnPolicy
 }
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ParkingSpace is a named spot on a location's lot. A vehicle based at the location can be
+// assigned to at most one space, and a space holds at most one vehicle.
+type ParkingSpace struct {
+	ID         string     `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	LocationID string     `json:"location_id" gorm:"type:uuid;not null;uniqueIndex:idx_parking_space_name"`
+	Name       string     `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_parking_space_name"`
+	VehicleID  *string    `json:"vehicle_id,omitempty" gorm:"type:uuid;uniqueIndex"`
+	Notes      string     `json:"notes" gorm:"type:text"`
+	AssignedAt *time.Time `json:"assigned_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ParkingSpace) TableName() string {
+	return "parking_spaces"
+}
+
+// LocationOccupancy summarizes how full a location's lot is. Assigned counts the vehicles based
+// at the location, of which OnLot are not out on a rental; Inbound counts open transfers heading
+// there. Available is left empty for locations without a capacity.
+type LocationOccupancy struct {
+	LocationID     string         `json:"location_id"`
+	Capacity       *int           `json:"capacity,omitempty"`
+	Assigned       int64          `json:"assigned"`
+	OnLot          int64          `json:"on_lot"`
+	Rented         int64          `json:"rented"`
+	Inbound        int64          `json:"inbound"`
+	Projected      int64          `json:"projected"`
+	Available      *int64         `json:"available,omitempty"`
+	OverCapacity   bool           `json:"over_capacity"`
+	Spaces         int            `json:"spaces"`
+	OccupiedSpaces int            `json:"occupied_spaces"`
+	ParkingSpaces  []ParkingSpace `json:"parking_spaces"`
+}
+
+type CreateParkingSpaceRequest struct {
+	Name  string `json:"name"`
+	Notes string `json:"notes"`
+}
+
+type AssignParkingSpaceRequest struct {
+	VehicleID string `json:"vehicle_id"`
+}
@@ -0,0 +1,119 @@
+package models
+
+import "time"
+
+type PaymentKind string
+
+const (
+	// PaymentKindCharge pays for the rental
+	PaymentKindCharge PaymentKind = "charge"
+	// PaymentKindDeposit holds a security deposit that is released at check-in
+	PaymentKindDeposit PaymentKind = "deposit"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusAuthorized        PaymentStatus = "authorized"
+	PaymentStatusCaptured          PaymentStatus = "captured"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+	PaymentStatusVoided            PaymentStatus = "voided"
+	PaymentStatusFailed            PaymentStatus = "failed"
+)
+
+// Payment is money taken or held against a rental through the payment gateway. Amount is what
+// was authorized; CapturedAmount and RefundedAmount track what was taken and given back.
+type Payment struct {
+	ID               string        `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID   string        `json:"organization_id" gorm:"type:uuid;not null;index"`
+	RentalID         string        `json:"rental_id" gorm:"type:uuid;not null;index"`
+	Kind             PaymentKind   `json:"kind" gorm:"type:varchar(20);not null"`
+	Status           PaymentStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Amount           Money         `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CapturedAmount   Money         `json:"captured_amount" gorm:"embedded;embeddedPrefix:captured_"`
+	RefundedAmount   Money         `json:"refunded_amount" gorm:"embedded;embeddedPrefix:refunded_"`
+	Currency         string        `json:"currency" gorm:"type:varchar(3);not null;default:'USD'"`
+	Description      string        `json:"description" gorm:"type:text"`
+	Gateway          string        `json:"gateway" gorm:"type:varchar(50);not null"`
+	GatewayReference *string       `json:"gateway_reference,omitempty" gorm:"type:varchar(255);uniqueIndex"`
+	IdempotencyKey   *string       `json:"idempotency_key,omitempty" gorm:"type:varchar(255);uniqueIndex"`
+	PaymentMethod    string        `json:"payment_method" gorm:"type:varchar(255)"`
+	FailureReason    string        `json:"failure_reason,omitempty" gorm:"type:text"`
+	CreatedBy        *string       `json:"created_by,omitempty" gorm:"type:uuid"`
+	AuthorizedAt     *time.Time    `json:"authorized_at,omitempty"`
+	CapturedAt       *time.Time    `json:"captured_at,omitempty"`
+	VoidedAt         *time.Time    `json:"voided_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Payment) TableName() string {
+	return "payments"
+}
+
+// Held reports whether the payment still holds funds that are neither captured nor released
+func (p *Payment) Held() bool {
+	return p.Status == PaymentStatusAuthorized
+}
+
+type PaymentEntryType string
+
+const (
+	PaymentEntryAuthorization PaymentEntryType = "authorization"
+	PaymentEntryCapture       PaymentEntryType = "capture"
+	PaymentEntryVoid          PaymentEntryType = "void"
+	PaymentEntryRefund        PaymentEntryType = "refund"
+	PaymentEntryDecline       PaymentEntryType = "decline"
+)
+
+// PaymentEntry is one gateway operation on a payment. Entries are only ever added, so a
+// rental's entries are its payment ledger.
+type PaymentEntry struct {
+	ID                   string           `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	PaymentID            string           `json:"payment_id" gorm:"type:uuid;not null;index"`
+	RentalID             string           `json:"rental_id" gorm:"type:uuid;not null;index"`
+	Type                 PaymentEntryType `json:"type" gorm:"type:varchar(20);not null"`
+	Amount               Money            `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	GatewayTransactionID string           `json:"gateway_transaction_id,omitempty" gorm:"type:varchar(255)"`
+	Reason               string           `json:"reason,omitempty" gorm:"type:text"`
+	CreatedBy            *string          `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt            time.Time        `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PaymentEntry) TableName() string {
+	return "payment_entries"
+}
+
+// RentalPayments is a rental's payment ledger with its running totals. Net is what the
+// customer has paid after refunds; Held is what is authorized but not yet captured.
+type RentalPayments struct {
+	RentalID string         `json:"rental_id"`
+	Captured Money          `json:"captured"`
+	Refunded Money          `json:"refunded"`
+	Net      Money          `json:"net"`
+	Held     Money          `json:"held"`
+	Payments []Payment      `json:"payments"`
+	Entries  []PaymentEntry `json:"entries"`
+}
+
+type CreatePaymentRequest struct {
+	Kind          PaymentKind `json:"kind"`
+	Amount        float64     `json:"amount"`
+	Currency      string      `json:"currency"`
+	PaymentMethod string      `json:"payment_method"`
+	Description   string      `json:"description"`
+	// Charges are captured straight away unless false; deposits are always only held
+	Capture *bool `json:"capture"`
+}
+
+type CapturePaymentRequest struct {
+	// Defaults to the full authorized amount
+	Amount float64 `json:"amount"`
+}
+
+type RefundPaymentRequest struct {
+	// Defaults to everything captured and not yet refunded
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type PricingAdjustment string
+
+const (
+	// Value is a percentage change to the rate, such as 25 for peak season or -10 for a discount
+	PricingAdjustmentPercent PricingAdjustment = "percent"
+	// Value is added to the rate, or taken off when negative
+	PricingAdjustmentAmount PricingAdjustment = "amount"
+	// Value replaces the rate
+	PricingAdjustmentRate PricingAdjustment = "rate"
+)
+
+// PricingAdjustments lists the ways a rule can change a daily rate
+var PricingAdjustments = []PricingAdjustment{PricingAdjustmentPercent, PricingAdjustmentAmount, PricingAdjustmentRate}
+
+// PricingRule adjusts a vehicle's daily rate on the days of a booking that meet all of its
+// conditions; blank conditions match any day. Matching rules apply in priority order, highest
+// first, each to the rate the rules before it left, so percentages compound. An exclusive rule
+// is the last to apply: lower priority rules are skipped once it matches. Amounts and rates are
+// in the rule's currency and only apply to bookings priced in it; percentages apply in any.
+type PricingRule struct {
+	ID             string            `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string            `json:"organization_id" gorm:"type:uuid;not null;index"`
+	Name           string            `json:"name" gorm:"type:varchar(255);not null"`
+	Priority       int               `json:"priority" gorm:"default:0"`
+	Adjustment     PricingAdjustment `json:"adjustment" gorm:"type:varchar(20);not null"`
+	Value          float64           `json:"value" gorm:"type:decimal(15,3);not null"`
+	Currency       string            `json:"currency" gorm:"type:varchar(3);not null;default:'USD'"`
+	Exclusive      bool              `json:"exclusive" gorm:"default:false"`
+
+	// Calendar: an inclusive date range, days of the week and whether the day is a holiday
+	// at the pickup location
+	StartDate  *time.Time  `json:"start_date,omitempty" gorm:"type:date"`
+	EndDate    *time.Time  `json:"end_date,omitempty" gorm:"type:date"`
+	DaysOfWeek StringArray `json:"days_of_week" gorm:"type:jsonb"`
+	OnHolidays bool        `json:"on_holidays" gorm:"default:false"`
+	// Days between booking and pickup
+	MinLeadDays *int `json:"min_lead_days,omitempty"`
+	MaxLeadDays *int `json:"max_lead_days,omitempty"`
+	// Length of the whole rental in days
+	MinRentalDays *int `json:"min_rental_days,omitempty"`
+	MaxRentalDays *int `json:"max_rental_days,omitempty"`
+	// Pickup locations, vehicle classes and vehicle body styles
+	LocationIDs     StringArray `json:"location_ids" gorm:"type:jsonb"`
+	VehicleClassIDs StringArray `json:"vehicle_class_ids" gorm:"type:jsonb"`
+	BodyStyles      StringArray `json:"body_styles" gorm:"type:jsonb"`
+
+	IsActive  bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (PricingRule) TableName() string {
+	return "pricing_rules"
+}
+
+// PricingDay is one day of a booking being priced
+type PricingDay struct {
+	// Calendar date at the pickup location
+	Date           time.Time
+	Holiday        bool
+	LeadDays       int
+	RentalDays     int
+	LocationID     string
+	VehicleClassID string
+	BodyStyle      string
+	// Currency of the pickup location
+	Currency string
+}
+
+func withinBounds(value int, min, max *int) bool {
+	return (min == nil || value >= *min) && (max == nil || value <= *max)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the day meets every condition of the rule
+func (p *PricingRule) Matches(day PricingDay) bool {
+	date := day.Date.Format("2006-01-02")
+	if p.StartDate != nil && date < p.StartDate.Format("2006-01-02") {
+		return false
+	}
+	if p.EndDate != nil && date > p.EndDate.Format("2006-01-02") {
+		return false
+	}
+	if len(p.DaysOfWeek) > 0 && !containsFold(p.DaysOfWeek, day.Date.Weekday().String()) {
+		return false
+	}
+	if p.OnHolidays && !day.Holiday {
+		return false
+	}
+	if !withinBounds(day.LeadDays, p.MinLeadDays, p.MaxLeadDays) || !withinBounds(day.RentalDays, p.MinRentalDays, p.MaxRentalDays) {
+		return false
+	}
+	if len(p.LocationIDs) > 0 && !containsFold(p.LocationIDs, day.LocationID) {
+		return false
+	}
+	if len(p.VehicleClassIDs) > 0 && !containsFold(p.VehicleClassIDs, day.VehicleClassID) {
+		return false
+	}
+	if len(p.BodyStyles) > 0 && !containsFold(p.BodyStyles, day.BodyStyle) {
+		return false
+	}
+	if p.Adjustment != PricingAdjustmentPercent && p.Currency != day.Currency {
+		return false
+	}
+	return true
+}
+
+// Apply adjusts a rate by the rule
+func (p *PricingRule) Apply(rate float64) float64 {
+	switch p.Adjustment {
+	case PricingAdjustmentPercent:
+		return rate * (1 + p.Value/100)
+	case PricingAdjustmentAmount:
+		return rate + p.Value
+	case PricingAdjustmentRate:
+		return p.Value
+	}
+	return rate
+}
+
+// PricedDay is the effective daily rate of one day and the rules that produced it. BaseRate is
+// the day's share of the daily, weekly or monthly rate its booking is charged at.
+type PricedDay struct {
+	Date     string   `json:"date"`
+	BaseRate float64  `json:"base_rate"`
+	Rate     float64  `json:"rate"`
+	Rules    []string `json:"rules"`
+}
+
+// PriceDay runs the active rules over a day's base rate. The rate never drops below zero.
+func PriceDay(base float64, rules []PricingRule, day PricingDay) PricedDay {
+	ordered := make([]PricingRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.IsActive {
+			ordered = append(ordered, rule)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	priced := PricedDay{Date: day.Date.Format("2006-01-02"), BaseRate: base, Rate: base, Rules: []string{}}
+	for _, rule := range ordered {
+		if !rule.Matches(day) {
+			continue
+		}
+		priced.Rate = rule.Apply(priced.Rate)
+		priced.Rules = append(priced.Rules, rule.Name)
+		if rule.Exclusive {
+			break
+		}
+	}
+	priced.Rate = math.Max(0, math.Round(priced.Rate*100)/100)
+	return priced
+}
+
+// RateCalendar shows a vehicle's effective daily rate for each day of a month, each day priced
+// as the pickup of a booking of RentalDays made on BookedAt. Rates are in Currency, converted
+// from the location's at ExchangeRate when another currency was asked for.
+type RateCalendar struct {
+	VehicleID    string      `json:"vehicle_id"`
+	LocationID   string      `json:"location_id"`
+	Month        string      `json:"month"`
+	RentalDays   int         `json:"rental_days"`
+	BookedAt     string      `json:"booked_at"`
+	Currency     string      `json:"currency"`
+	ExchangeRate float64     `json:"exchange_rate"`
+	Days         []PricedDay `json:"days"`
+}
+
+type CreatePricingRuleRequest struct {
+	OrganizationID string            `json:"organization_id"`
+	Name           string            `json:"name"`
+	Priority       int               `json:"priority"`
+	Adjustment     PricingAdjustment `json:"adjustment"`
+	Value          float64           `json:"value"`
+	// Of amounts and rates; defaults to the organization's currency
+	Currency  string `json:"currency"`
+	Exclusive bool   `json:"exclusive"`
+	// YYYY-MM-DD
+	StartDate       *string  `json:"start_date"`
+	EndDate         *string  `json:"end_date"`
+	DaysOfWeek      []string `json:"days_of_week"`
+	OnHolidays      bool     `json:"on_holidays"`
+	MinLeadDays     *int     `json:"min_lead_days"`
+	MaxLeadDays     *int     `json:"max_lead_days"`
+	MinRentalDays   *int     `json:"min_rental_days"`
+	MaxRentalDays   *int     `json:"max_rental_days"`
+	LocationIDs     []string `json:"location_ids"`
+	VehicleClassIDs []string `json:"vehicle_class_ids"`
+	BodyStyles      []string `json:"body_styles"`
+}
+
+type UpdatePricingRuleRequest struct {
+	CreatePricingRuleRequest
+	IsActive bool `json:"is_active"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+type RentalStatus string
+
+const (
+	RentalStatusReserved  RentalStatus = "reserved"
+	RentalStatusActive    RentalStatus = "active"
+	RentalStatusCompleted RentalStatus = "completed"
+	RentalStatusCancelled RentalStatus = "cancelled"
+)
+
+// RentalStatusesHoldingVehicle are the statuses in which a rental keeps its vehicle booked
+var RentalStatusesHoldingVehicle = []RentalStatus{RentalStatusReserved, RentalStatusActive}
+
+// Rental books a vehicle from a pickup at one location to a return at the same or another
+// location of the organization. Pickup and return times are kept in UTC and shown in each
+// location's time zone. A reservation becomes active at the checkout inspection and completed
+// at the checkin. A reservation made for a vehicle class has no vehicle until the checkout
+// assigns one of the class.
+type Rental struct {
+	ID               string       `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID   string       `json:"organization_id" gorm:"type:uuid;not null;index"`
+	VehicleID        *string      `json:"vehicle_id,omitempty" gorm:"type:uuid;index"`
+	VehicleClassID   *string      `json:"vehicle_class_id,omitempty" gorm:"type:uuid;index"`
+	CustomerID       *string      `json:"customer_id,omitempty" gorm:"type:uuid;index"`
+	PickupLocationID string       `json:"pickup_location_id" gorm:"type:uuid;not null;index"`
+	ReturnLocationID string       `json:"return_location_id" gorm:"type:uuid;not null;index"`
+	Status           RentalStatus `json:"status" gorm:"type:varchar(20);not null;default:'reserved';index"`
+	PickupAt         time.Time    `json:"pickup_at" gorm:"not null;index"`
+	ReturnAt         time.Time    `json:"return_at" gorm:"not null;index"`
+	// The pickup location's currency at booking, which the rental is invoiced and paid in
+	Currency string `json:"currency" gorm:"type:varchar(3);not null;default:'USD'"`
+	// The rental days priced at booking, which the rental is invoiced for whatever rates and
+	// pricing rules change later
+	PricedLines InvoiceLines `json:"priced_lines" gorm:"type:jsonb"`
+	// The return falls outside the return location's hours and goes to its key drop
+	AfterHoursReturn bool       `json:"after_hours_return" gorm:"default:false"`
+	Notes            string     `json:"notes" gorm:"type:text"`
+	PickedUpAt       *time.Time `json:"picked_up_at,omitempty"`
+	ReturnedAt       *time.Time `json:"returned_at,omitempty"`
+	CancelledAt      *time.Time `json:"cancelled_at,omitempty"`
+	CreatedBy        *string    `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Rental) TableName() string {
+	return "rentals"
+}
+
+// RentalAvailability is the answer to whether a vehicle can be booked for a pickup and return
+type RentalAvailability struct {
+	Available        bool     `json:"available"`
+	Reasons          []string `json:"reasons"`
+	AfterHoursReturn bool     `json:"after_hours_return"`
+	// Pickup and return as wall-clock times at their locations
+	PickupAt time.Time `json:"pickup_at"`
+	ReturnAt time.Time `json:"return_at"`
+}
+
+// CreateRentalRequest books either a specific vehicle or any vehicle of a class
+type CreateRentalRequest struct {
+	VehicleID      string  `json:"vehicle_id"`
+	VehicleClassID string  `json:"vehicle_class_id"`
+	CustomerID     *string `json:"customer_id"`
+	// Defaults to the vehicle's location; required when booking a class
+	PickupLocationID string `json:"pickup_location_id"`
+	// Defaults to the pickup location
+	ReturnLocationID string    `json:"return_location_id"`
+	PickupAt         time.Time `json:"pickup_at"`
+	ReturnAt         time.Time `json:"return_at"`
+	Notes            string    `json:"notes"`
+}
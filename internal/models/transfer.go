@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+type TransferStatus string
+
+const (
+	TransferStatusRequested TransferStatus = "requested"
+	TransferStatusInTransit TransferStatus = "in_transit"
+	TransferStatusReceived  TransferStatus = "received"
+	TransferStatusCancelled TransferStatus = "cancelled"
+)
+
+// VehicleTransfer moves a vehicle between two locations of its organization. It is requested,
+// departs (the vehicle is in transit and cannot be rented) and is received at the destination,
+// which becomes the vehicle's location.
+type VehicleTransfer struct {
+	ID                string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID    string         `json:"organization_id" gorm:"type:uuid;not null;index"`
+	VehicleID         string         `json:"vehicle_id" gorm:"type:uuid;not null;index"`
+	FromLocationID    string         `json:"from_location_id" gorm:"type:uuid;not null;index"`
+	ToLocationID      string         `json:"to_location_id" gorm:"type:uuid;not null;index"`
+	Status            TransferStatus `json:"status" gorm:"type:varchar(20);not null;default:'requested';index"`
+	DriverName        string         `json:"driver_name" gorm:"type:varchar(255)"`
+	DriverPhone       string         `json:"driver_phone" gorm:"type:varchar(50)"`
+	DepartureOdometer *int           `json:"departure_odometer,omitempty"`
+	ArrivalOdometer   *int           `json:"arrival_odometer,omitempty"`
+	Notes             string         `json:"notes" gorm:"type:text"`
+	RequestedBy       *string        `json:"requested_by,omitempty" gorm:"type:uuid"`
+	RequestedAt       time.Time      `json:"requested_at" gorm:"not null"`
+	DepartedAt        *time.Time     `json:"departed_at,omitempty"`
+	ReceivedAt        *time.Time     `json:"received_at,omitempty"`
+	CancelledAt       *time.Time     `json:"cancelled_at,omitempty"`
+	CreatedAt         time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (VehicleTransfer) TableName() string {
+	return "vehicle_transfers"
+}
+
+// Open reports whether the transfer has yet to be received or cancelled
+func (t *VehicleTransfer) Open() bool {
+	return t.Status == TransferStatusRequested || t.Status == TransferStatusInTransit
+}
+
+// LocationTransfers lists the transfers arriving at and leaving a location
+type LocationTransfers struct {
+	Inbound  []VehicleTransfer `json:"inbound"`
+	Outbound []VehicleTransfer `json:"outbound"`
+}
+
+type CreateTransferRequest struct {
+	ToLocationID string `json:"to_location_id"`
+	DriverName   string `json:"driver_name"`
+	DriverPhone  string `json:"driver_phone"`
+	Notes        string `json:"notes"`
+	// Request the transfer even if the destination is full
+	AllowOverCapacity bool `json:"allow_over_capacity"`
+}
+
+type DepartTransferRequest struct {
+	// Defaults to the vehicle's current mileage
+	DepartureOdometer int `json:"departure_odometer"`
+	// Replaces the driver named on the request, if given
+	DriverName  string `json:"driver_name"`
+	DriverPhone string `json:"driver_phone"`
+}
+
+type ReceiveTransferRequest struct {
+	ArrivalOdometer int    `json:"arrival_odometer"`
+	Notes           string `json:"notes"`
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // StringArray is a custom type for handling PostgreSQL arrays
@@ -41,6 +43,8 @@ const (
 	VehicleStatusRented      VehicleStatus = "rented"
 	VehicleStatusMaintenance VehicleStatus = "maintenance"
 	VehicleStatusInactive    VehicleStatus = "inactive"
+	// Being driven between locations on a transfer
+	VehicleStatusInTransit VehicleStatus = "in_transit"
 )
 
 type Vehicle struct {
@@ -59,6 +63,10 @@ type Vehicle struct {
 	LicensePlate         string           `json:"license_plate" gorm:"type:varchar(20)"`
 	Status               VehicleStatus    `json:"status" gorm:"type:varchar(50);default:'available';index"`
 	IsEligibleForService bool             `json:"is_eligible_for_service" gorm:"default:true"`
+	// Set while lapsed documents, rather than staff, hold the vehicle out of service
+	DocumentSuspended bool `json:"document_suspended" gorm:"default:false"`
+	// Set while an import's deactivate_missing, rather than staff, holds the vehicle inactive
+	ImportDeactivated bool `json:"import_deactivated" gorm:"default:false"`
 
 	// Warranty
 	HasWarranty            bool       `json:"has_warranty" gorm:"default:false"`
@@ -66,10 +74,10 @@ type Vehicle struct {
 	WarrantyType           string     `json:"warranty_type" gorm:"type:varchar(100)"`
 	WarrantyDetails        string     `json:"warranty_details" gorm:"type:text"`
 
-	// Pricing
-	DailyRate   float64 `json:"daily_rate" gorm:"type:decimal(10,2);default:0"`
-	WeeklyRate  float64 `json:"weekly_rate" gorm:"type:decimal(10,2);default:0"`
-	MonthlyRate float64 `json:"monthly_rate" gorm:"type:decimal(10,2);default:0"`
+	// Pricing, in the currency of the location the vehicle is picked up at
+	DailyRate   Money `json:"daily_rate" gorm:"embedded;embeddedPrefix:daily_rate_"`
+	WeeklyRate  Money `json:"weekly_rate" gorm:"embedded;embeddedPrefix:weekly_rate_"`
+	MonthlyRate Money `json:"monthly_rate" gorm:"embedded;embeddedPrefix:monthly_rate_"`
 
 	// Additional details
 	BodyStyle    string `json:"body_style" gorm:"type:varchar(50)"`
@@ -86,8 +94,18 @@ type Vehicle struct {
 	Features     StringArray `json:"features" gorm:"type:jsonb"`
 	Images       StringArray `json:"images" gorm:"type:jsonb"`
 
+	// Fuel tank size in gallons, or usable battery capacity in kWh for electric vehicles
+	FuelCapacity float64 `json:"fuel_capacity" gorm:"type:decimal(10,2);default:0"`
+
+	// The class the vehicle is reserved as
+	VehicleClassID *string `json:"vehicle_class_id,omitempty" gorm:"type:uuid;index"`
+
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Soft delete
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	DeletedBy *string        `json:"deleted_by,omitempty" gorm:"type:uuid"`
 }
 
 func (Vehicle) TableName() string {
@@ -122,6 +140,16 @@ type CreateVehicleRequest struct {
 	MonthlyRate          float64          `json:"monthly_rate"`
 	Features             []string         `json:"features"`
 	Images               []string         `json:"images"`
+	HasWarranty          bool             `json:"has_warranty"`
+	WarrantyExpirationDate *time.Time     `json:"warranty_expiration_date"`
+	WarrantyType         string           `json:"warranty_type"`
+	WarrantyDetails      string           `json:"warranty_details"`
+
+	FuelCapacity   float64 `json:"fuel_capacity"`
+	VehicleClassID *string `json:"vehicle_class_id"`
+
+	// Create the vehicle even if its location is full
+	AllowOverCapacity bool `json:"allow_over_capacity"`
 }
 
 type UpdateVehicleRequest struct {
@@ -153,4 +181,11 @@ type UpdateVehicleRequest struct {
 	MonthlyRate          float64          `json:"monthly_rate"`
 	Features             []string         `json:"features"`
 	Images               []string         `json:"images"`
+	HasWarranty          bool             `json:"has_warranty"`
+	WarrantyExpirationDate *time.Time     `json:"warranty_expiration_date"`
+	WarrantyType         string           `json:"warranty_type"`
+	WarrantyDetails      string           `json:"warranty_details"`
+
+	FuelCapacity   float64 `json:"fuel_capacity"`
+	VehicleClassID *string `json:"vehicle_class_id"`
 }
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// VehicleClass groups an organization's interchangeable vehicles, such as "midsize SUV", so
+// customers can reserve a class and be given any vehicle of it at pickup. Code is usually an
+// ACRISS code like ICAR or SFAR. Vehicles without rates of their own rent at the class's rates,
+// which like vehicle rates are in the currency of the pickup location.
+type VehicleClass struct {
+	ID             string  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string  `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_vehicle_class_code"`
+	Code           string  `json:"code" gorm:"type:varchar(10);not null;uniqueIndex:idx_vehicle_class_code"`
+	Description    string  `json:"description" gorm:"type:varchar(255);not null"`
+	DailyRate      float64 `json:"daily_rate" gorm:"type:decimal(15,3);default:0"`
+	WeeklyRate     float64 `json:"weekly_rate" gorm:"type:decimal(15,3);default:0"`
+	MonthlyRate    float64 `json:"monthly_rate" gorm:"type:decimal(15,3);default:0"`
+	Seats          int     `json:"seats"`
+	// Representative image shown when booking, since the actual vehicle is not known yet
+	ImageURL  string    `json:"image_url" gorm:"type:varchar(500)"`
+	IsActive  bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (VehicleClass) TableName() string {
+	return "vehicle_classes"
+}
+
+// ClassAvailability is how many vehicles of a class are free to reserve at a pickup location
+// for a period. Booked counts reservations of the class's vehicles there, whether made for a
+// specific vehicle or for the class and not yet assigned.
+type ClassAvailability struct {
+	VehicleClass
+	Total     int `json:"total"`
+	Booked    int `json:"booked"`
+	Available int `json:"available"`
+}
+
+type CreateVehicleClassRequest struct {
+	OrganizationID string  `json:"organization_id"`
+	Code           string  `json:"code"`
+	Description    string  `json:"description"`
+	DailyRate      float64 `json:"daily_rate"`
+	WeeklyRate     float64 `json:"weekly_rate"`
+	MonthlyRate    float64 `json:"monthly_rate"`
+	Seats          int     `json:"seats"`
+	ImageURL       string  `json:"image_url"`
+}
+
+type UpdateVehicleClassRequest struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	DailyRate   float64 `json:"daily_rate"`
+	WeeklyRate  float64 `json:"weekly_rate"`
+	MonthlyRate float64 `json:"monthly_rate"`
+	Seats       int     `json:"seats"`
+	ImageURL    string  `json:"image_url"`
+	IsActive    bool    `json:"is_active"`
+}
@@ -0,0 +1,127 @@
+package models
+
+import "time"
+
+type VehicleDocumentType string
+
+const (
+	VehicleDocumentRegistration     VehicleDocumentType = "registration"
+	VehicleDocumentSafetyInspection VehicleDocumentType = "safety_inspection"
+	VehicleDocumentEmissions        VehicleDocumentType = "emissions_inspection"
+	VehicleDocumentInsurance        VehicleDocumentType = "insurance"
+	VehicleDocumentPermit           VehicleDocumentType = "permit"
+	VehicleDocumentOther            VehicleDocumentType = "other"
+)
+
+// VehicleDocumentTypes lists the valid document types
+var VehicleDocumentTypes = []VehicleDocumentType{
+	VehicleDocumentRegistration, VehicleDocumentSafetyInspection, VehicleDocumentEmissions,
+	VehicleDocumentInsurance, VehicleDocumentPermit, VehicleDocumentOther,
+}
+
+// MandatoryByDefault reports whether a vehicle cannot be rented without a current document of this type
+func (t VehicleDocumentType) MandatoryByDefault() bool {
+	switch t {
+	case VehicleDocumentRegistration, VehicleDocumentSafetyInspection, VehicleDocumentInsurance:
+		return true
+	}
+	return false
+}
+
+type VehicleDocumentStatus string
+
+const (
+	VehicleDocumentStatusValid    VehicleDocumentStatus = "valid"
+	VehicleDocumentStatusExpiring VehicleDocumentStatus = "expiring"
+	VehicleDocumentStatusExpired  VehicleDocumentStatus = "expired"
+)
+
+// VehicleDocument is a registration, inspection certificate, insurance card or similar paper
+// kept for a vehicle. When a mandatory document lapses the vehicle is taken out of service.
+type VehicleDocument struct {
+	ID               string              `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID   string              `json:"organization_id" gorm:"type:uuid;not null;index"`
+	VehicleID        string              `json:"vehicle_id" gorm:"type:uuid;not null;index"`
+	Type             VehicleDocumentType `json:"type" gorm:"type:varchar(50);not null"`
+	Number           string              `json:"number" gorm:"type:varchar(100)"`
+	IssuingAuthority string              `json:"issuing_authority" gorm:"type:varchar(255)"`
+	IssueDate        *time.Time          `json:"issue_date,omitempty"`
+	ExpiryDate       *time.Time          `json:"expiry_date,omitempty" gorm:"index"`
+	FileURL          string              `json:"file_url" gorm:"type:varchar(500)"`
+	FileName         string              `json:"file_name" gorm:"type:varchar(255)"`
+	IsMandatory      bool                `json:"is_mandatory" gorm:"default:false"`
+	Notes            string              `json:"notes" gorm:"type:text"`
+	// Set when this document lapsing took the vehicle out of service, so renewing it can put
+	// the vehicle back
+	SuspendedService bool      `json:"suspended_service" gorm:"default:false"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (VehicleDocument) TableName() string {
+	return "vehicle_documents"
+}
+
+// Lapsed reports whether the document has expired as of now
+func (d *VehicleDocument) Lapsed(now time.Time) bool {
+	return d.ExpiryDate != nil && !now.Before(*d.ExpiryDate)
+}
+
+// Status places the document as valid, expiring within days, or expired
+func (d *VehicleDocument) Status(now time.Time, days int) VehicleDocumentStatus {
+	switch {
+	case d.Lapsed(now):
+		return VehicleDocumentStatusExpired
+	case d.ExpiryDate != nil && !now.AddDate(0, 0, days).Before(*d.ExpiryDate):
+		return VehicleDocumentStatusExpiring
+	}
+	return VehicleDocumentStatusValid
+}
+
+// ExpiringDocument is a document on the expiry dashboard, with the vehicle it belongs to
+type ExpiringDocument struct {
+	VehicleDocument
+	LocationID    string                `json:"location_id"`
+	VIN           string                `json:"vin"`
+	Make          string                `json:"make"`
+	Model         string                `json:"model"`
+	Year          int                   `json:"year"`
+	LicensePlate  string                `json:"license_plate"`
+	Status        VehicleDocumentStatus `json:"status"`
+	DaysRemaining int                   `json:"days_remaining"`
+}
+
+// DocumentExpiryDashboard summarizes an organization's documents that have expired or expire soon
+type DocumentExpiryDashboard struct {
+	OrganizationID    string             `json:"organization_id"`
+	Days              int                `json:"days"`
+	Expired           int                `json:"expired"`
+	Expiring          int                `json:"expiring"`
+	SuspendedVehicles int                `json:"suspended_vehicles"`
+	Documents         []ExpiringDocument `json:"documents"`
+}
+
+type CreateVehicleDocumentRequest struct {
+	Type             VehicleDocumentType `json:"type"`
+	Number           string              `json:"number"`
+	IssuingAuthority string              `json:"issuing_authority"`
+	IssueDate        *time.Time          `json:"issue_date"`
+	ExpiryDate       *time.Time          `json:"expiry_date"`
+	FileURL          string              `json:"file_url"`
+	FileName         string              `json:"file_name"`
+	// Defaults by type: registration, safety inspection and insurance are mandatory
+	IsMandatory *bool  `json:"is_mandatory"`
+	Notes       string `json:"notes"`
+}
+
+type UpdateVehicleDocumentRequest struct {
+	Type             VehicleDocumentType `json:"type"`
+	Number           string              `json:"number"`
+	IssuingAuthority string              `json:"issuing_authority"`
+	IssueDate        *time.Time          `json:"issue_date"`
+	ExpiryDate       *time.Time          `json:"expiry_date"`
+	FileURL          string              `json:"file_url"`
+	FileName         string              `json:"file_name"`
+	IsMandatory      bool                `json:"is_mandatory"`
+	Notes            string              `json:"notes"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import "time"
+
+type WarrantyCoverageType string
+
+const (
+	WarrantyCoverageBumperToBumper WarrantyCoverageType = "bumper_to_bumper"
+	WarrantyCoveragePowertrain     WarrantyCoverageType = "powertrain"
+	WarrantyCoverageCorrosion      WarrantyCoverageType = "corrosion"
+	WarrantyCoverageEmissions      WarrantyCoverageType = "emissions"
+	WarrantyCoverageBattery        WarrantyCoverageType = "battery"
+	WarrantyCoverageExtended       WarrantyCoverageType = "extended"
+	WarrantyCoverageOther          WarrantyCoverageType = "other"
+)
+
+// WarrantyCoverageTypes lists the valid coverage types
+var WarrantyCoverageTypes = []WarrantyCoverageType{
+	WarrantyCoverageBumperToBumper, WarrantyCoveragePowertrain, WarrantyCoverageCorrosion,
+	WarrantyCoverageEmissions, WarrantyCoverageBattery, WarrantyCoverageExtended, WarrantyCoverageOther,
+}
+
+// WarrantyCoverage is one warranty on a vehicle. Coverage ends on ExpirationDate or when the
+// odometer reaches MileageLimit, whichever comes first; either may be left open.
+type WarrantyCoverage struct {
+	ID             string               `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrganizationID string               `json:"organization_id" gorm:"type:uuid;not null;index"`
+	VehicleID      string               `json:"vehicle_id" gorm:"type:uuid;not null;index"`
+	Type           WarrantyCoverageType `json:"type" gorm:"type:varchar(50);not null"`
+	Provider       string               `json:"provider" gorm:"type:varchar(255)"`
+	PolicyNumber   string               `json:"policy_number" gorm:"type:varchar(100)"`
+	StartDate      *time.Time           `json:"start_date,omitempty"`
+	ExpirationDate *time.Time           `json:"expiration_date,omitempty" gorm:"index"`
+	// Odometer reading at which coverage ends
+	MileageLimit *int      `json:"mileage_limit,omitempty"`
+	Details      string    `json:"details" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (WarrantyCoverage) TableName() string {
+	return "warranty_coverages"
+}
+
+// ExpiringWarranty is a coverage that ends within the look-ahead window by date or mileage
+type ExpiringWarranty struct {
+	VehicleID      string               `json:"vehicle_id"`
+	LocationID     string               `json:"location_id"`
+	VIN            string               `json:"vin"`
+	Make           string               `json:"make"`
+	Model          string               `json:"model"`
+	Year           int                  `json:"year"`
+	Mileage        int                  `json:"mileage"`
+	CoverageID     *string              `json:"coverage_id,omitempty"`
+	Type           WarrantyCoverageType `json:"type"`
+	Provider       string               `json:"provider,omitempty"`
+	ExpirationDate *time.Time           `json:"expiration_date,omitempty"`
+	MileageLimit   *int                 `json:"mileage_limit,omitempty"`
+	DaysRemaining  *int                 `json:"days_remaining,omitempty"`
+	MilesRemaining *int                 `json:"miles_remaining,omitempty"`
+}
+
+// Expiring reports where the coverage stands for the vehicle, and whether it is still running
+// but ends within days or miles from now
+func (c *WarrantyCoverage) Expiring(vehicle *Vehicle, now time.Time, days, miles int) (ExpiringWarranty, bool) {
+	item := ExpiringWarranty{
+		VehicleID:      vehicle.ID,
+		LocationID:     vehicle.LocationID,
+		VIN:            vehicle.VIN,
+		Make:           vehicle.Make,
+		Model:          vehicle.Model,
+		Year:           vehicle.Year,
+		Mileage:        vehicle.Mileage,
+		CoverageID:     &c.ID,
+		Type:           c.Type,
+		Provider:       c.Provider,
+		ExpirationDate: c.ExpirationDate,
+		MileageLimit:   c.MileageLimit,
+	}
+	if c.ID == "" {
+		item.CoverageID = nil
+	}
+
+	expired, expiring := false, false
+	if c.ExpirationDate != nil {
+		remaining := int(c.ExpirationDate.Sub(now).Hours() / 24)
+		item.DaysRemaining = &remaining
+		expired = !now.Before(*c.ExpirationDate)
+		expiring = !now.AddDate(0, 0, days).Before(*c.ExpirationDate)
+	}
+	if c.MileageLimit != nil {
+		remaining := *c.MileageLimit - vehicle.Mileage
+		item.MilesRemaining = &remaining
+		expired = expired || remaining <= 0
+		expiring = expiring || remaining <= miles
+	}
+	return item, expiring && !expired
+}
+
+type CreateWarrantyCoverageRequest struct {
+	Type           WarrantyCoverageType `json:"type"`
+	Provider       string               `json:"provider"`
+	PolicyNumber   string               `json:"policy_number"`
+	StartDate      *time.Time           `json:"start_date"`
+	ExpirationDate *time.Time           `json:"expiration_date"`
+	MileageLimit   *int                 `json:"mileage_limit"`
+	Details        string               `json:"details"`
+}
+
+type UpdateWarrantyCoverageRequest struct {
+	Type           WarrantyCoverageType `json:"type"`
+	Provider       string               `json:"provider"`
+	PolicyNumber   string               `json:"policy_number"`
+	StartDate      *time.Time           `json:"start_date"`
+	ExpirationDate *time.Time           `json:"expiration_date"`
+	MileageLimit   *int                 `json:"mileage_limit"`
+	Details        string               `json:"details"`
+}
@@ -0,0 +1,139 @@
+package payments
+
+import (
+	"context"
+	"fleetpass/internal/models"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Payment method tokens the fake gateway declines. Any other non-empty token is approved.
+const (
+	FakeCardDeclined          = "tok_declined"
+	FakeCardInsufficientFunds = "tok_insufficient_funds"
+)
+
+// fakeAuthorization is the fake gateway's record of a hold and what has happened to it
+type fakeAuthorization struct {
+	currency string
+	amount   float64
+	captured float64
+	refunded float64
+	voided   bool
+}
+
+// FakeGateway is an in-process gateway for development and tests. It keeps authorizations in
+// memory and enforces the same rules as a real processor: a hold is captured at most once and
+// for no more than was authorized, only uncaptured holds are voided, and refunds cannot exceed
+// the captured amount.
+type FakeGateway struct {
+	mu             sync.Mutex
+	next           atomic.Int64
+	authorizations map[string]*fakeAuthorization
+	idempotent     map[string]Result
+}
+
+// NewFakeGateway creates an empty fake gateway
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{
+		authorizations: make(map[string]*fakeAuthorization),
+		idempotent:     make(map[string]Result),
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) id(prefix string) string {
+	return fmt.Sprintf("%s_fake_%06d", prefix, g.next.Add(1))
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, req AuthorizeRequest) (Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if req.IdempotencyKey != "" {
+		if result, ok := g.idempotent[req.IdempotencyKey]; ok {
+			return result, nil
+		}
+	}
+	amount := models.RoundMoney(req.Amount, req.Currency)
+	if amount <= 0 {
+		return Result{}, ErrInvalidAmount
+	}
+
+	switch req.PaymentMethod {
+	case "":
+		return Result{DeclineReason: "missing payment method"}, ErrDeclined
+	case FakeCardDeclined:
+		return Result{DeclineReason: "card declined"}, ErrDeclined
+	case FakeCardInsufficientFunds:
+		return Result{DeclineReason: "insufficient funds"}, ErrDeclined
+	}
+
+	result := Result{Reference: g.id("auth"), Amount: amount}
+	result.TransactionID = result.Reference
+	g.authorizations[result.Reference] = &fakeAuthorization{currency: req.Currency, amount: amount}
+	if req.IdempotencyKey != "" {
+		g.idempotent[req.IdempotencyKey] = result
+	}
+	return result, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, reference string, amount float64) (Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[reference]
+	if !ok {
+		return Result{}, ErrNotFound
+	}
+	if auth.voided || auth.captured > 0 {
+		return Result{}, ErrInvalidState
+	}
+	amount = models.RoundMoney(amount, auth.currency)
+	if amount <= 0 || amount > auth.amount {
+		return Result{}, ErrInvalidAmount
+	}
+
+	auth.captured = amount
+	return Result{Reference: reference, TransactionID: g.id("ch"), Amount: amount}, nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, reference string) (Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[reference]
+	if !ok {
+		return Result{}, ErrNotFound
+	}
+	if auth.voided || auth.captured > 0 {
+		return Result{}, ErrInvalidState
+	}
+
+	auth.voided = true
+	return Result{Reference: reference, TransactionID: g.id("void"), Amount: auth.amount}, nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, reference string, amount float64) (Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[reference]
+	if !ok {
+		return Result{}, ErrNotFound
+	}
+	if auth.captured == 0 {
+		return Result{}, ErrInvalidState
+	}
+	amount = models.RoundMoney(amount, auth.currency)
+	if amount <= 0 || models.RoundMoney(auth.refunded+amount, auth.currency) > auth.captured {
+		return Result{}, ErrInvalidAmount
+	}
+
+	auth.refunded = models.RoundMoney(auth.refunded+amount, auth.currency)
+	return Result{Reference: reference, TransactionID: g.id("re"), Amount: amount}, nil
+}
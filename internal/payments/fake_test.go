@@ -0,0 +1,66 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFakeGateway_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	gateway := NewFakeGateway()
+
+	auth, err := gateway.Authorize(ctx, AuthorizeRequest{Amount: 120.50, Currency: "USD", PaymentMethod: "tok_visa"})
+	if err != nil {
+		t.Fatalf("Expected the authorization to succeed: %v", err)
+	}
+
+	if _, err := gateway.Capture(ctx, auth.Reference, 150); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected capturing more than authorized to fail, got %v", err)
+	}
+	if _, err := gateway.Capture(ctx, auth.Reference, 100); err != nil {
+		t.Fatalf("Expected the capture to succeed: %v", err)
+	}
+	if _, err := gateway.Void(ctx, auth.Reference); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected voiding a captured authorization to fail, got %v", err)
+	}
+
+	if _, err := gateway.Refund(ctx, auth.Reference, 60); err != nil {
+		t.Fatalf("Expected the refund to succeed: %v", err)
+	}
+	if _, err := gateway.Refund(ctx, auth.Reference, 40.01); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected refunding more than captured to fail, got %v", err)
+	}
+	if _, err := gateway.Refund(ctx, auth.Reference, 40); err != nil {
+		t.Errorf("Expected refunding the rest to succeed: %v", err)
+	}
+}
+
+func TestFakeGateway_DeclinesAndVoids(t *testing.T) {
+	ctx := context.Background()
+	gateway := NewFakeGateway()
+
+	result, err := gateway.Authorize(ctx, AuthorizeRequest{Amount: 50, PaymentMethod: FakeCardInsufficientFunds})
+	if !errors.Is(err, ErrDeclined) || result.DeclineReason != "insufficient funds" {
+		t.Errorf("Expected an insufficient funds decline, got %v (%q)", err, result.DeclineReason)
+	}
+
+	hold, err := gateway.Authorize(ctx, AuthorizeRequest{Amount: 300, PaymentMethod: "tok_visa", IdempotencyKey: "deposit-1"})
+	if err != nil {
+		t.Fatalf("Expected the hold to succeed: %v", err)
+	}
+	retry, _ := gateway.Authorize(ctx, AuthorizeRequest{Amount: 300, PaymentMethod: "tok_visa", IdempotencyKey: "deposit-1"})
+	if retry.Reference != hold.Reference {
+		t.Errorf("Expected a retried authorization to return %s, got %s", hold.Reference, retry.Reference)
+	}
+
+	if _, err := gateway.Void(ctx, hold.Reference); err != nil {
+		t.Fatalf("Expected the void to succeed: %v", err)
+	}
+	if _, err := gateway.Capture(ctx, hold.Reference, 300); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected capturing a voided hold to fail, got %v", err)
+	}
+	if _, err := gateway.Void(ctx, "auth_missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected an unknown reference to be not found, got %v", err)
+	}
+}
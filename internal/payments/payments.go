@@ -0,0 +1,64 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+)
+
+var (
+	// ErrDeclined is returned when the card issuer refuses an authorization
+	ErrDeclined = errors.New("payment declined")
+	// ErrNotFound is returned when the gateway has no record of an authorization
+	ErrNotFound = errors.New("authorization not found")
+	// ErrInvalidState is returned when an authorization cannot be captured, voided or refunded
+	// as asked, such as voiding one that was already captured
+	ErrInvalidState = errors.New("authorization cannot be changed in its current state")
+	// ErrInvalidAmount is returned for amounts that are not positive or exceed what is available
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+// AuthorizeRequest asks the gateway to hold funds on a payment method
+type AuthorizeRequest struct {
+	Amount   float64
+	Currency string
+	// PaymentMethod is the gateway's token for the card, never the card number itself
+	PaymentMethod string
+	Description   string
+	// IdempotencyKey lets a retried request return the original authorization
+	IdempotencyKey string
+}
+
+// Result is the gateway's answer to an operation. Reference identifies the authorization in
+// later captures, voids and refunds; TransactionID identifies this operation alone.
+type Result struct {
+	Reference     string
+	TransactionID string
+	Amount        float64
+	// DeclineReason explains an ErrDeclined
+	DeclineReason string
+}
+
+// PaymentGateway moves money through a card processor. An authorization holds funds, a
+// capture takes some or all of them, a void releases an uncaptured hold and a refund returns
+// captured money.
+type PaymentGateway interface {
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (Result, error)
+	Capture(ctx context.Context, reference string, amount float64) (Result, error)
+	Void(ctx context.Context, reference string) (Result, error)
+	Refund(ctx context.Context, reference string, amount float64) (Result, error)
+}
+
+// GetGateway returns the configured payment gateway. Only the in-process fake exists so far;
+// a real processor's adapter is selected here by PAYMENT_GATEWAY.
+func GetGateway() PaymentGateway {
+	switch gateway := os.Getenv("PAYMENT_GATEWAY"); gateway {
+	case "", "fake":
+		return NewFakeGateway()
+	default:
+		log.Printf("Warning: unknown PAYMENT_GATEWAY %q, using the fake gateway", gateway)
+		return NewFakeGateway()
+	}
+}
@@ -0,0 +1,154 @@
+package pdf
+
+import (
+	"fleetpass/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultBrandColor is used for organizations without a brand color of their own
+var defaultBrandColor = Color{0.12, 0.16, 0.22}
+
+const (
+	margin       = 40.0
+	pageBottom   = LetterHeight - 60
+	columnQty    = 360.0
+	columnPrice  = 460.0
+	columnAmount = LetterWidth - margin
+)
+
+// FormatMoney writes an amount to its currency's minor unit with thousands separators and its
+// currency code
+func FormatMoney(amount float64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := models.CurrencyDigits(currency)
+	whole := strconv.FormatFloat(amount, 'f', digits, 64)
+	integer, fraction := whole, ""
+	if digits > 0 {
+		integer, fraction = whole[:len(whole)-digits-1], whole[len(whole)-digits-1:]
+	}
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	return fmt.Sprintf("%s%s%s %s", sign, grouped.String(), fraction, currency)
+}
+
+// RenderInvoice lays out an invoice on letter-size pages under the organization's branding:
+// a header band in its brand color, its billing details, the bill-to, the lines and the
+// tax and payment totals. Lines that do not fit continue on further pages.
+func RenderInvoice(invoice *models.Invoice, org *models.Organization) []byte {
+	brand := defaultBrandColor
+	if color, err := ParseHexColor(org.BrandColor); err == nil {
+		brand = color
+	}
+	name := org.LegalName
+	if name == "" {
+		name = org.Name
+	}
+
+	doc := New(LetterWidth, LetterHeight)
+	page := doc.AddPage()
+	page.Rect(0, 0, LetterWidth, 80, brand)
+	page.Text(margin, 48, HelveticaBold, 20, White, name)
+	page.TextRight(columnAmount, 48, HelveticaBold, 20, White, "INVOICE")
+
+	// Seller on the left, invoice details on the right
+	y := 110.0
+	seller := strings.Split(strings.TrimSpace(org.BillingAddress), "\n")
+	for _, detail := range []string{org.BillingEmail, org.BillingPhone} {
+		if detail != "" {
+			seller = append(seller, detail)
+		}
+	}
+	if org.TaxID != "" {
+		seller = append(seller, "Tax ID: "+org.TaxID)
+	}
+	details := [][2]string{
+		{"Invoice", invoice.Number},
+		{"Issued", invoice.IssuedAt.Format("January 2, 2006")},
+		{"Rental", invoice.RentalID},
+	}
+	for i := 0; i < len(seller) || i < len(details); i++ {
+		if i < len(seller) && seller[i] != "" {
+			page.Text(margin, y, Helvetica, 10, Black, strings.TrimSpace(seller[i]))
+		}
+		if i < len(details) {
+			page.TextRight(columnAmount-150, y, HelveticaBold, 10, Black, details[i][0])
+			page.TextRight(columnAmount, y, Helvetica, 10, Black, details[i][1])
+		}
+		y += 14
+	}
+
+	y += 16
+	page.Text(margin, y, HelveticaBold, 10, Gray, "BILL TO")
+	y += 15
+	for _, line := range append([]string{invoice.BillToName, invoice.BillToEmail}, strings.Split(invoice.BillToAddress, "\n")...) {
+		if line = strings.TrimSpace(line); line != "" {
+			page.Text(margin, y, Helvetica, 10, Black, line)
+			y += 14
+		}
+	}
+
+	tableHeader := func() {
+		y += 20
+		page.Rect(margin, y-12, LetterWidth-2*margin, 18, brand)
+		page.Text(margin+6, y, HelveticaBold, 10, White, "Description")
+		page.TextRight(columnQty, y, HelveticaBold, 10, White, "Qty")
+		page.TextRight(columnPrice, y, HelveticaBold, 10, White, "Unit price")
+		page.TextRight(columnAmount-6, y, HelveticaBold, 10, White, "Amount")
+		y += 22
+	}
+	tableHeader()
+
+	for _, line := range invoice.Lines {
+		if y > pageBottom {
+			page = doc.AddPage()
+			y = margin
+			tableHeader()
+		}
+		page.Text(margin+6, y, Helvetica, 10, Black, line.Description)
+		page.TextRight(columnQty, y, Helvetica, 10, Black, strconv.FormatFloat(line.Quantity, 'f', -1, 64))
+		page.TextRight(columnPrice, y, Helvetica, 10, Black, FormatMoney(line.UnitPrice, invoice.Currency))
+		page.TextRight(columnAmount-6, y, Helvetica, 10, Black, FormatMoney(line.Amount, invoice.Currency))
+		y += 8
+		page.Line(margin, y, LetterWidth-margin, y, 0.5, Gray)
+		y += 14
+	}
+
+	totals := [][2]string{{"Subtotal", FormatMoney(invoice.Subtotal.Float(), invoice.Currency)}}
+	for _, tax := range invoice.Taxes {
+		label := fmt.Sprintf("%s (%s%% of %s)", tax.Name, strconv.FormatFloat(tax.Rate, 'f', -1, 64), FormatMoney(tax.TaxableAmount, invoice.Currency))
+		totals = append(totals, [2]string{label, FormatMoney(tax.Amount, invoice.Currency)})
+	}
+	totals = append(totals,
+		[2]string{"Total", FormatMoney(invoice.Total.Float(), invoice.Currency)},
+		[2]string{"Paid", FormatMoney(invoice.AmountPaid.Float(), invoice.Currency)},
+		[2]string{"Balance due", FormatMoney(invoice.BalanceDue.Float(), invoice.Currency)},
+	)
+
+	if y+float64(len(totals))*16+40 > pageBottom {
+		page = doc.AddPage()
+		y = margin
+	}
+	y += 10
+	for _, total := range totals {
+		font := Helvetica
+		if total[0] == "Total" || total[0] == "Balance due" {
+			font = HelveticaBold
+		}
+		page.TextRight(columnPrice, y, font, 10, Black, total[0])
+		page.TextRight(columnAmount-6, y, font, 10, Black, total[1])
+		y += 16
+	}
+
+	page.Text(margin, LetterHeight-30, Helvetica, 9, Gray, "Thank you for renting with "+name+".")
+	return doc.Bytes()
+}
@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"bytes"
+	"fleetpass/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHexColor(t *testing.T) {
+	color, err := ParseHexColor("#FF8000")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if color.R != 1 || color.G < 0.5 || color.G > 0.51 || color.B != 0 {
+		t.Errorf("Unexpected color %+v", color)
+	}
+
+	for _, bad := range []string{"", "FF8000", "#FF80", "#GG8000"} {
+		if _, err := ParseHexColor(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := map[float64]string{
+		0:          "0.00 USD",
+		12.5:       "12.50 USD",
+		1234.567:   "1,234.57 USD",
+		-1000000.1: "-1,000,000.10 USD",
+	}
+	for amount, want := range tests {
+		if got := FormatMoney(amount, "USD"); got != want {
+			t.Errorf("FormatMoney(%v) = %q, want %q", amount, got, want)
+		}
+	}
+
+	if got := FormatMoney(1234.6, "JPY"); got != "1,235 JPY" {
+		t.Errorf("Expected yen without decimals, got %q", got)
+	}
+	if got := FormatMoney(12.5, "KWD"); got != "12.500 KWD" {
+		t.Errorf("Expected dinar to three decimals, got %q", got)
+	}
+}
+
+func TestRenderInvoice_PagesLongInvoices(t *testing.T) {
+	org := &models.Organization{Name: "Acme Rentals"}
+	org.BrandColor = "#1F6FEB"
+	invoice := &models.Invoice{Number: "INV-000001", Currency: "USD", IssuedAt: time.Now(), BillToName: "Jane (Doe)"}
+	for i := 0; i < 60; i++ {
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{Description: "Fuel", Quantity: 1, UnitPrice: 10, Amount: 10})
+	}
+
+	out := RenderInvoice(invoice, org)
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("Expected a complete PDF file")
+	}
+	if strings.Contains(string(out), "/Count 1 ") {
+		t.Error("Expected 60 lines to run onto further pages")
+	}
+	if !strings.Contains(string(out), `Jane \(Doe\)`) {
+		t.Error("Expected parentheses in text to be escaped")
+	}
+}
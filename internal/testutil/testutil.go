@@ -29,6 +29,28 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		&models.Location{},
 		&models.Vehicle{},
 		&models.User{},
+		&models.ImportJob{},
+		&models.ImportProfile{},
+		&models.MaintenanceRecord{},
+		&models.MaintenancePlan{},
+		&models.MaintenanceAlert{},
+		&models.OdometerReading{},
+		&models.Inspection{},
+		&models.WarrantyCoverage{},
+		&models.VehicleDocument{},
+		&models.VehicleTransfer{},
+		&models.LocationHoliday{},
+		&models.Rental{},
+		&models.ParkingSpace{},
+		&models.DriverProfile{},
+		&models.RentalDriver{},
+		&models.Payment{},
+		&models.PaymentEntry{},
+		&models.RentalCharge{},
+		&models.Invoice{},
+		&models.PricingRule{},
+		&models.VehicleClass{},
+		&models.ExchangeRate{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
@@ -42,6 +64,28 @@ func CleanupTestDB(t *testing.T, db *gorm.DB) {
 	t.Helper()
 
 	// Delete in reverse order of dependencies
+	db.Exec("TRUNCATE TABLE exchange_rates CASCADE")
+	db.Exec("TRUNCATE TABLE vehicle_classes CASCADE")
+	db.Exec("TRUNCATE TABLE pricing_rules CASCADE")
+	db.Exec("TRUNCATE TABLE invoices CASCADE")
+	db.Exec("TRUNCATE TABLE rental_charges CASCADE")
+	db.Exec("TRUNCATE TABLE payment_entries CASCADE")
+	db.Exec("TRUNCATE TABLE payments CASCADE")
+	db.Exec("TRUNCATE TABLE rental_drivers CASCADE")
+	db.Exec("TRUNCATE TABLE driver_profiles CASCADE")
+	db.Exec("TRUNCATE TABLE parking_spaces CASCADE")
+	db.Exec("TRUNCATE TABLE rentals CASCADE")
+	db.Exec("TRUNCATE TABLE location_holidays CASCADE")
+	db.Exec("TRUNCATE TABLE vehicle_transfers CASCADE")
+	db.Exec("TRUNCATE TABLE vehicle_documents CASCADE")
+	db.Exec("TRUNCATE TABLE warranty_coverages CASCADE")
+	db.Exec("TRUNCATE TABLE inspections CASCADE")
+	db.Exec("TRUNCATE TABLE odometer_readings CASCADE")
+	db.Exec("TRUNCATE TABLE maintenance_alerts CASCADE")
+	db.Exec("TRUNCATE TABLE maintenance_plans CASCADE")
+	db.Exec("TRUNCATE TABLE maintenance_records CASCADE")
+	db.Exec("TRUNCATE TABLE import_jobs CASCADE")
+	db.Exec("TRUNCATE TABLE import_profiles CASCADE")
 	db.Exec("TRUNCATE TABLE vehicles CASCADE")
 	db.Exec("TRUNCATE TABLE locations CASCADE")
 	db.Exec("TRUNCATE TABLE organizations CASCADE")
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 
 	"fleetpass/internal/database"
+	"fleetpass/internal/email"
+	"fleetpass/internal/geocode"
 	"fleetpass/internal/handlers"
+	"fleetpass/internal/jobs"
+	"fleetpass/internal/payments"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -31,8 +36,27 @@ func main() {
 
 	log.Println("Database initialized successfully")
 
-	// Initialize token auth in handlers
+	// Start background jobs
+	jobConfig := jobs.LoadConfigFromEnv()
+	jobs.Start(context.Background(),
+		jobs.TrashPurgeJob(database.DB, jobConfig.TrashRetention, jobConfig.TrashPurgeInterval),
+		jobs.MaintenanceAlertJob(database.DB, email.GetEmailService(), jobConfig.MaintenanceAlertInterval),
+		jobs.WarrantyExpiryJob(database.DB, email.GetEmailService(), jobConfig.WarrantyExpiryInterval,
+			jobConfig.WarrantyExpiryDays, jobConfig.WarrantyExpiryMiles),
+		jobs.DocumentEligibilityJob(database.DB, jobConfig.DocumentEligibilityInterval),
+	)
+	if err := handlers.StartImportWorkers(context.Background(), handlers.ImportJobConfig{
+		Workers:        jobConfig.ImportWorkers,
+		StorageDir:     jobConfig.ImportStorageDir,
+		MaxUploadBytes: jobConfig.ImportMaxUploadBytes,
+	}); err != nil {
+		log.Fatalf("Failed to start import workers: %v", err)
+	}
+
+	// Initialize token auth, geocoding and payments in handlers
 	handlers.InitTokenAuth(tokenAuth)
+	handlers.InitGeocoder(geocode.GetGeocoder())
+	handlers.InitPaymentGateway(payments.GetGateway())
 
 	r := chi.NewRouter()
 
@@ -41,7 +65,7 @@ func main() {
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -69,6 +93,8 @@ func main() {
 		r.Use(jwtauth.Authenticator(tokenAuth))
 
 		r.Get("/api/profile", handlers.GetProfile)
+		r.Get("/api/profile/driver", handlers.GetMyDriverProfile)
+		r.Put("/api/profile/driver", handlers.SaveMyDriverProfile)
 		r.Get("/api/protected", func(w http.ResponseWriter, r *http.Request) {
 			w.Write([]byte("This is a protected endpoint"))
 		})
@@ -78,22 +104,169 @@ func main() {
 		r.Post("/api/organizations", handlers.CreateOrganization)
 		r.Get("/api/organizations/{id}", handlers.GetOrganization)
 		r.Put("/api/organizations/{id}", handlers.UpdateOrganization)
+		r.Patch("/api/organizations/{id}", handlers.PatchOrganization)
 		r.Delete("/api/organizations/{id}", handlers.DeleteOrganization)
+		r.Post("/api/organizations/{id}/restore", handlers.RestoreOrganization)
 
 		// Locations
 		r.Get("/api/locations", handlers.GetLocations)
 		r.Post("/api/locations", handlers.CreateLocation)
+		r.Get("/api/locations/nearby", handlers.GetNearbyLocations)
 		r.Get("/api/locations/{id}", handlers.GetLocation)
 		r.Put("/api/locations/{id}", handlers.UpdateLocation)
+		r.Patch("/api/locations/{id}", handlers.PatchLocation)
 		r.Delete("/api/locations/{id}", handlers.DeleteLocation)
+		r.Post("/api/locations/{id}/restore", handlers.RestoreLocation)
+		r.Get("/api/locations/{id}/maintenance-due", handlers.GetLocationMaintenanceDue)
+		r.Get("/api/locations/{id}/inspections", handlers.GetLocationInspections)
+		r.Get("/api/locations/{id}/transfers", handlers.GetLocationTransfers)
+		r.Get("/api/locations/{id}/hours", handlers.GetLocationHours)
+		r.Get("/api/locations/{id}/holidays", handlers.GetLocationHolidays)
+		r.Post("/api/locations/{id}/holidays", handlers.CreateLocationHoliday)
+		r.Delete("/api/locations/{id}/holidays/{holidayId}", handlers.DeleteLocationHoliday)
+		r.Get("/api/locations/{id}/occupancy", handlers.GetLocationOccupancy)
+		r.Get("/api/locations/{id}/parking-spaces", handlers.GetParkingSpaces)
+		r.Post("/api/locations/{id}/parking-spaces", handlers.CreateParkingSpace)
+		r.Put("/api/locations/{id}/parking-spaces/{spaceId}", handlers.UpdateParkingSpace)
+		r.Delete("/api/locations/{id}/parking-spaces/{spaceId}", handlers.DeleteParkingSpace)
+		r.Post("/api/locations/{id}/parking-spaces/{spaceId}/assign", handlers.AssignParkingSpace)
+		r.Post("/api/locations/{id}/parking-spaces/{spaceId}/release", handlers.ReleaseParkingSpace)
 
 		// Vehicles
 		r.Get("/api/vehicles", handlers.GetVehicles)
 		r.Post("/api/vehicles", handlers.CreateVehicle)
 		r.Post("/api/vehicles/bulk-upload", handlers.BulkUploadVehicles)
+		r.Get("/api/vehicles/export", handlers.ExportVehicles)
 		r.Get("/api/vehicles/{id}", handlers.GetVehicle)
 		r.Put("/api/vehicles/{id}", handlers.UpdateVehicle)
+		r.Patch("/api/vehicles/{id}", handlers.PatchVehicle)
 		r.Delete("/api/vehicles/{id}", handlers.DeleteVehicle)
+		r.Post("/api/vehicles/{id}/restore", handlers.RestoreVehicle)
+
+		// Maintenance
+		r.Get("/api/vehicles/{id}/maintenance", handlers.GetMaintenanceRecords)
+		r.Post("/api/vehicles/{id}/maintenance", handlers.CreateMaintenanceRecord)
+		r.Get("/api/vehicles/{id}/maintenance/{recordId}", handlers.GetMaintenanceRecord)
+		r.Put("/api/vehicles/{id}/maintenance/{recordId}", handlers.UpdateMaintenanceRecord)
+		r.Post("/api/vehicles/{id}/maintenance/{recordId}/close", handlers.CloseMaintenanceRecord)
+		r.Delete("/api/vehicles/{id}/maintenance/{recordId}", handlers.DeleteMaintenanceRecord)
+		r.Get("/api/vehicles/{id}/maintenance-schedule", handlers.GetVehicleMaintenanceSchedule)
+
+		// Odometer
+		r.Get("/api/vehicles/{id}/odometer", handlers.GetOdometerReadings)
+		r.Post("/api/vehicles/{id}/odometer", handlers.CreateOdometerReading)
+		r.Post("/api/vehicles/{id}/odometer/{readingId}/accept", handlers.AcceptOdometerReading)
+		r.Delete("/api/vehicles/{id}/odometer/{readingId}", handlers.DeleteOdometerReading)
+
+		// Inspections
+		r.Get("/api/vehicles/{id}/inspections", handlers.GetVehicleInspections)
+		r.Post("/api/vehicles/{id}/inspections", handlers.CreateInspection)
+		r.Get("/api/inspections/{id}", handlers.GetInspection)
+		r.Get("/api/inspections/{id}/comparison", handlers.GetInspectionComparison)
+
+		// Warranties
+		r.Get("/api/vehicles/{id}/warranties", handlers.GetWarrantyCoverages)
+		r.Post("/api/vehicles/{id}/warranties", handlers.CreateWarrantyCoverage)
+		r.Put("/api/vehicles/{id}/warranties/{coverageId}", handlers.UpdateWarrantyCoverage)
+		r.Delete("/api/vehicles/{id}/warranties/{coverageId}", handlers.DeleteWarrantyCoverage)
+		r.Get("/api/warranties/expiring", handlers.GetExpiringWarranties)
+
+		// Vehicle documents
+		r.Get("/api/vehicles/{id}/documents", handlers.GetVehicleDocuments)
+		r.Post("/api/vehicles/{id}/documents", handlers.CreateVehicleDocument)
+		r.Get("/api/vehicles/{id}/documents/{documentId}", handlers.GetVehicleDocument)
+		r.Put("/api/vehicles/{id}/documents/{documentId}", handlers.UpdateVehicleDocument)
+		r.Delete("/api/vehicles/{id}/documents/{documentId}", handlers.DeleteVehicleDocument)
+		r.Get("/api/organizations/{id}/document-expiry", handlers.GetDocumentExpiryDashboard)
+
+		// Transfers between locations
+		r.Get("/api/vehicles/{id}/transfers", handlers.GetVehicleTransfers)
+		r.Post("/api/vehicles/{id}/transfers", handlers.CreateTransfer)
+		r.Get("/api/transfers/{id}", handlers.GetTransfer)
+		r.Post("/api/transfers/{id}/depart", handlers.DepartTransfer)
+		r.Post("/api/transfers/{id}/receive", handlers.ReceiveTransfer)
+		r.Post("/api/transfers/{id}/cancel", handlers.CancelTransfer)
+
+		// Rentals
+		r.Get("/api/vehicles/{id}/availability", handlers.GetVehicleAvailability)
+		r.Get("/api/rentals", handlers.GetRentals)
+		r.Post("/api/rentals", handlers.CreateRental)
+		r.Get("/api/rentals/{id}", handlers.GetRental)
+		r.Post("/api/rentals/{id}/cancel", handlers.CancelRental)
+		r.Get("/api/rentals/{id}/drivers", handlers.GetRentalDrivers)
+		r.Post("/api/rentals/{id}/drivers", handlers.AddRentalDriver)
+		r.Delete("/api/rentals/{id}/drivers/{userId}", handlers.RemoveRentalDriver)
+
+		// Payments
+		r.Get("/api/rentals/{id}/payments", handlers.GetRentalPayments)
+		r.Post("/api/rentals/{id}/payments", handlers.CreatePayment)
+		r.Get("/api/payments/{id}", handlers.GetPayment)
+		r.Post("/api/payments/{id}/capture", handlers.CapturePayment)
+		r.Post("/api/payments/{id}/void", handlers.VoidPayment)
+		r.Post("/api/payments/{id}/refund", handlers.RefundPayment)
+
+		// Invoices
+		r.Get("/api/rentals/{id}/charges", handlers.GetRentalCharges)
+		r.Post("/api/rentals/{id}/charges", handlers.CreateRentalCharge)
+		r.Delete("/api/rentals/{id}/charges/{chargeId}", handlers.DeleteRentalCharge)
+		r.Get("/api/rentals/{id}/invoice", handlers.GetRentalInvoice)
+		r.Post("/api/rentals/{id}/invoice", handlers.CreateInvoice)
+		r.Get("/api/invoices", handlers.GetInvoices)
+		r.Get("/api/invoices/{id}", handlers.GetInvoice)
+		r.Get("/api/invoices/{id}.pdf", handlers.GetInvoicePDF)
+		r.Post("/api/invoices/{id}/email", handlers.EmailInvoice)
+		r.Get("/api/organizations/{id}/revenue", handlers.GetRevenueReport)
+
+		// Exchange rates
+		r.Get("/api/exchange-rates", handlers.GetExchangeRates)
+		r.Post("/api/exchange-rates/upload", handlers.UploadExchangeRates)
+		r.Delete("/api/exchange-rates/{id}", handlers.DeleteExchangeRate)
+
+		// Vehicle classes
+		r.Get("/api/vehicle-classes", handlers.GetVehicleClasses)
+		r.Post("/api/vehicle-classes", handlers.CreateVehicleClass)
+		r.Get("/api/vehicle-classes/{id}", handlers.GetVehicleClass)
+		r.Put("/api/vehicle-classes/{id}", handlers.UpdateVehicleClass)
+		r.Delete("/api/vehicle-classes/{id}", handlers.DeleteVehicleClass)
+		r.Get("/api/locations/{id}/class-availability", handlers.GetLocationClassAvailability)
+
+		// Pricing rules
+		r.Get("/api/pricing-rules", handlers.GetPricingRules)
+		r.Post("/api/pricing-rules", handlers.CreatePricingRule)
+		r.Get("/api/pricing-rules/{id}", handlers.GetPricingRule)
+		r.Put("/api/pricing-rules/{id}", handlers.UpdatePricingRule)
+		r.Delete("/api/pricing-rules/{id}", handlers.DeletePricingRule)
+		r.Get("/api/vehicles/{id}/rates", handlers.GetVehicleRateCalendar)
+		r.Get("/api/vehicles/{id}/quote", handlers.GetVehicleQuote)
+
+		// Driver licenses
+		r.Get("/api/users/{id}/driver-profile", handlers.GetUserDriverProfile)
+		r.Post("/api/users/{id}/driver-profile/verify", handlers.VerifyDriverLicense)
+
+		// Maintenance plans
+		r.Get("/api/maintenance-plans", handlers.GetMaintenancePlans)
+		r.Post("/api/maintenance-plans", handlers.CreateMaintenancePlan)
+		r.Get("/api/maintenance-plans/{id}", handlers.GetMaintenancePlan)
+		r.Put("/api/maintenance-plans/{id}", handlers.UpdateMaintenancePlan)
+		r.Delete("/api/maintenance-plans/{id}", handlers.DeleteMaintenancePlan)
+
+		// Import profiles
+		r.Get("/api/import-profiles", handlers.GetImportProfiles)
+		r.Post("/api/import-profiles", handlers.CreateImportProfile)
+		r.Post("/api/import-profiles/suggest", handlers.SuggestImportMappings)
+		r.Get("/api/import-profiles/{id}", handlers.GetImportProfile)
+		r.Put("/api/import-profiles/{id}", handlers.UpdateImportProfile)
+		r.Delete("/api/import-profiles/{id}", handlers.DeleteImportProfile)
+
+		// Import jobs
+		r.Get("/api/import-jobs", handlers.GetImportJobs)
+		r.Post("/api/import-jobs", handlers.CreateImportJob)
+		r.Get("/api/import-jobs/{id}", handlers.GetImportJob)
+		r.Post("/api/import-jobs/{id}/cancel", handlers.CancelImportJob)
+		r.Get("/api/import-jobs/{id}/rejected-rows", handlers.GetImportJobRejectedRows)
+
+		// Trash
+		r.Get("/api/trash", handlers.GetTrash)
 	})
 
 	fmt.Println("Server starting on :8080")